@@ -11,7 +11,6 @@ import (
 	"syscall"
 	"time"
 
-	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/golang-migrate/migrate/v4"
 	migratep "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -23,10 +22,8 @@ import (
 	"google.golang.org/grpc"
 
 	tokentypes "github.com/Decentr-net/decentr/x/token/types"
-	"github.com/Decentr-net/go-broadcaster"
 	"github.com/Decentr-net/logrus/sentry"
 
-	"github.com/Decentr-net/vulcan/internal/blockchain"
 	"github.com/Decentr-net/vulcan/internal/health"
 	"github.com/Decentr-net/vulcan/internal/referral"
 	"github.com/Decentr-net/vulcan/internal/storage/postgres"
@@ -39,16 +36,7 @@ var opts = struct {
 	PostgresMaxIdleConnections int    `long:"postgres.max_idle_connections" env:"POSTGRES_MAX_IDLE_CONNECTIONS" default:"5" description:"postgres maximal idle connections count"`
 	PostgresMigrations         string `long:"postgres.migrations" env:"POSTGRES_MIGRATIONS" default:"migrations/postgres" description:"postgres migrations directory"`
 
-	BlockchainNode               string `long:"blockchain.node" env:"BLOCKCHAIN_NODE" default:"http://zeus.testnet.decentr.xyz:26657" description:"decentr node address"`
-	BlockchainFrom               string `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"decentr account name to send stakes" required:"true"`
-	BlockchainTxMemo             string `long:"blockchain.tx_memo" env:"BLOCKCHAIN_TX_MEMO" description:"decentr tx's memo'"`
-	BlockchainChainID            string `long:"blockchain.chain_id" env:"BLOCKCHAIN_CHAIN_ID" default:"testnet" description:"decentr chain id"`
-	BlockchainClientHome         string `long:"blockchain.client_home" env:"BLOCKCHAIN_CLIENT_HOME" default:"~/.decentrcli" description:"decentrcli home directory"`
-	BlockchainKeyringBackend     string `long:"blockchain.keyring_backend" env:"BLOCKCHAIN_KEYRING_BACKEND" default:"test" description:"decentrcli keyring backend"`
-	BlockchainKeyringPromptInput string `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"decentrcli keyring prompt input"`
-	BlockchainGas                uint64 `long:"blockchain.gas" env:"BLOCKCHAIN_GAS" default:"10" description:"gas amount"`
-	BlockchainFee                string `long:"blockchain.fee" env:"BLOCKCHAIN_FEE" default:"1udec" description:"transaction fee"`
-	BlockchainGRPCNodeURL        string `long:"blockchain.grpc_node_url" env:"BLOCKCHAIN_GRPC_NODE_URL" default:"hera.mainnet.decentr.xyz:9090" description:"GRPC endpoint URL"`
+	BlockchainGRPCNodeURL string `long:"blockchain.grpc_node_url" env:"BLOCKCHAIN_GRPC_NODE_URL" default:"hera.mainnet.decentr.xyz:9090" description:"GRPC endpoint URL"`
 
 	ReferralThresholdPDV  string `long:"referral.threshold_pdv" env:"REFERRAL_THRESHOLD_PDV" default:"0.000100" description:"how many PDV a user should obtain to get a referral reward'"`
 	ReferralThresholdDays int    `long:"referral.threshold_days" env:"REFERRAL_THRESHOLD_DAYS" default:"30" description:"how many days a user should wait to get a referral reward'"`
@@ -112,7 +100,6 @@ func main() {
 		referral.NewRewarder(
 			postgres.New(
 				mustGetDB()),
-			blockchain.New(mustGetBroadcaster()),
 			tokentypes.NewQueryClient(nativeNodeConn),
 			referral.NewConfig(sdk.MustNewDecFromStr(opts.ReferralThresholdPDV), opts.ReferralThresholdDays),
 		).Run(ctx, time.Hour)
@@ -181,29 +168,3 @@ func mustGetDB() *sql.DB {
 
 	return db
 }
-
-func mustGetBroadcaster() broadcaster.Broadcaster {
-	fee, err := sdk.ParseCoinNormalized(opts.BlockchainFee)
-	if err != nil {
-		logrus.WithError(err).Error("failed to parse fee")
-	}
-
-	b, err := broadcaster.New(broadcaster.Config{
-		KeyringRootDir:     opts.BlockchainClientHome,
-		KeyringBackend:     opts.BlockchainKeyringBackend,
-		KeyringPromptInput: opts.BlockchainKeyringPromptInput,
-		NodeURI:            opts.BlockchainNode,
-		BroadcastMode:      cliflags.BroadcastSync,
-		From:               opts.BlockchainFrom,
-		ChainID:            opts.BlockchainChainID,
-		Gas:                opts.BlockchainGas,
-		GasAdjust:          1.2,
-		Fees:               sdk.Coins{fee},
-	})
-
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create main broadcaster")
-	}
-
-	return b
-}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,75 +14,216 @@ import (
 	"syscall"
 	"time"
 
-	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/go-chi/chi"
 	"github.com/golang-migrate/migrate/v4"
 	migratep "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jessevdk/go-flags"
-	"github.com/johntdyer/slackrus"
+	mandrillclient "github.com/keighl/mandrill"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 
-	"github.com/Decentr-net/go-broadcaster"
+	operationstypes "github.com/Decentr-net/decentr/x/operations/types"
 	"github.com/Decentr-net/logrus/sentry"
 	"github.com/Decentr-net/vulcan/internal/blockchain"
+	"github.com/Decentr-net/vulcan/internal/budget"
+	"github.com/Decentr-net/vulcan/internal/captcha"
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/fraud"
 	"github.com/Decentr-net/vulcan/internal/health"
-	"github.com/Decentr-net/vulcan/internal/mail/gmail"
+	"github.com/Decentr-net/vulcan/internal/mail"
+	"github.com/Decentr-net/vulcan/internal/mail/file"
+	"github.com/Decentr-net/vulcan/internal/mail/logger"
+	"github.com/Decentr-net/vulcan/internal/mail/mandrill"
+	"github.com/Decentr-net/vulcan/internal/mail/outbox"
+	"github.com/Decentr-net/vulcan/internal/mail/smtp"
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/payout"
+	"github.com/Decentr-net/vulcan/internal/proof"
 	"github.com/Decentr-net/vulcan/internal/referral"
 	"github.com/Decentr-net/vulcan/internal/server"
 	"github.com/Decentr-net/vulcan/internal/service"
+	"github.com/Decentr-net/vulcan/internal/slack"
+	"github.com/Decentr-net/vulcan/internal/storage"
 	"github.com/Decentr-net/vulcan/internal/storage/postgres"
 	"github.com/Decentr-net/vulcan/internal/supply"
+	"github.com/Decentr-net/vulcan/internal/wallet"
 )
 
 // nolint:lll,gochecknoglobals
 var opts = struct {
-	Host            string        `long:"http.host" env:"HTTP_HOST" default:"0.0.0.0" description:"IP to listen on"`
-	Port            int           `long:"http.port" env:"HTTP_PORT" default:"8080" description:"port to listen on for insecure connections, defaults to a random value"`
-	RequestTimeout  time.Duration `long:"http.request-timeout" env:"HTTP_REQUEST_TIMEOUT" default:"45s" description:"request processing timeout"`
-	RecaptchaSecret string        `long:"http.recaptcha_secret" env:"HTTP_RECAPTCHA_SECRET" required:"true" description:"recaptcha secret"`
+	Host           string        `long:"http.host" env:"HTTP_HOST" default:"0.0.0.0" description:"IP to listen on"`
+	Port           int           `long:"http.port" env:"HTTP_PORT" default:"8080" description:"port to listen on for insecure connections, defaults to a random value"`
+	RequestTimeout time.Duration `long:"http.request-timeout" env:"HTTP_REQUEST_TIMEOUT" default:"45s" description:"request processing timeout"`
+
+	CaptchaProvider   string             `long:"captcha.provider" env:"CAPTCHA_PROVIDER" default:"recaptcha_v3" choice:"recaptcha_v3" choice:"recaptcha_v2" choice:"hcaptcha" choice:"turnstile" choice:"local" description:"captcha provider"`
+	CaptchaSecret     string             `long:"captcha.secret" env:"CAPTCHA_SECRET" description:"captcha secret, required by all providers except local"`
+	CaptchaURL        string             `long:"captcha.url" env:"CAPTCHA_URL" description:"captcha verify url, provider's one is used if it's empty"`
+	CaptchaTimeout    time.Duration      `long:"captcha.timeout" env:"CAPTCHA_TIMEOUT" default:"5s" description:"captcha verify request timeout"`
+	CaptchaMinScore   float64            `long:"captcha.min_score" env:"CAPTCHA_MIN_SCORE" default:"0.8" description:"minimal reCAPTCHA v3 score"`
+	CaptchaMinScores  map[string]float64 `long:"captcha.min_scores" env:"CAPTCHA_MIN_SCORES" env-delim:"," description:"minimal reCAPTCHA v3 scores of actions in action:score format"`
+	CaptchaHostnames  []string           `long:"captcha.hostnames" env:"CAPTCHA_HOSTNAMES" env-delim:"," description:"hostnames allowed to solve captcha, any hostname is allowed if it's empty"`
+	CaptchaTestTokens []string           `long:"captcha.test_tokens" env:"CAPTCHA_TEST_TOKENS" env-delim:"," description:"captcha responses accepted by local provider"`
+
+	CaptchaRegister                string        `long:"captcha.register" env:"CAPTCHA_REGISTER" default:"required" choice:"disabled" choice:"adaptive" choice:"required" description:"captcha policy for registration"`
+	CaptchaResend                  string        `long:"captcha.resend" env:"CAPTCHA_RESEND" default:"required" choice:"disabled" choice:"adaptive" choice:"required" description:"captcha policy for confirmation code resends"`
+	CaptchaConfirm                 string        `long:"captcha.confirm" env:"CAPTCHA_CONFIRM" default:"disabled" choice:"disabled" choice:"adaptive" choice:"required" description:"captcha policy for registration confirmation"`
+	CaptchaDLoan                   string        `long:"captcha.dloan" env:"CAPTCHA_DLOAN" default:"disabled" choice:"disabled" choice:"adaptive" choice:"required" description:"captcha policy for dLoan requests"`
+	CaptchaHesoyam                 string        `long:"captcha.hesoyam" env:"CAPTCHA_HESOYAM" default:"disabled" choice:"disabled" choice:"adaptive" choice:"required" description:"captcha policy for testnet stakes"`
+	CaptchaAdaptiveWindow          time.Duration `long:"captcha.adaptive_window" env:"CAPTCHA_ADAPTIVE_WINDOW" default:"1h" description:"period requests and failures of an IP, email or email domain are counted within in adaptive mode; they are counted in memory of every replica separately"`
+	CaptchaAdaptiveThreshold       int           `long:"captcha.adaptive_threshold" env:"CAPTCHA_ADAPTIVE_THRESHOLD" default:"5" description:"count of requests and failures of an IP or email after which captcha is demanded in adaptive mode, per replica"`
+	CaptchaAdaptiveDomainThreshold int           `long:"captcha.adaptive_domain_threshold" env:"CAPTCHA_ADAPTIVE_DOMAIN_THRESHOLD" default:"50" description:"count of requests and failures of an email domain after which captcha is demanded in adaptive mode, per replica"`
+
+	RateLimitStore           string   `long:"ratelimit.store" env:"RATELIMIT_STORE" default:"postgres" choice:"postgres" choice:"memory" description:"where rate limit counters are kept, memory counters aren't shared between replicas"`
+	RateLimitTrustedProxies  []string `long:"ratelimit.trusted_proxies" env:"RATELIMIT_TRUSTED_PROXIES" env-delim:"," description:"CIDRs of proxies X-Forwarded-For and X-Real-IP headers are trusted from"`
+	RateLimitRegisterIP      string   `long:"ratelimit.register.ip" env:"RATELIMIT_REGISTER_IP" default:"20/1h" description:"registrations per IP in limit/window format, 0 means unlimited"`
+	RateLimitRegisterEmail   string   `long:"ratelimit.register.email" env:"RATELIMIT_REGISTER_EMAIL" default:"5/1h" description:"registrations per email in limit/window format, 0 means unlimited"`
+	RateLimitRegisterAddress string   `long:"ratelimit.register.address" env:"RATELIMIT_REGISTER_ADDRESS" default:"5/1h" description:"registrations per address in limit/window format, 0 means unlimited"`
+	RateLimitResendIP        string   `long:"ratelimit.resend.ip" env:"RATELIMIT_RESEND_IP" default:"20/1h" description:"confirmation code resends per IP in limit/window format, 0 means unlimited"`
+	RateLimitResendEmail     string   `long:"ratelimit.resend.email" env:"RATELIMIT_RESEND_EMAIL" default:"5/1h" description:"confirmation code resends per email in limit/window format, 0 means unlimited"`
+	RateLimitChallengeIP     string   `long:"ratelimit.challenge.ip" env:"RATELIMIT_CHALLENGE_IP" default:"60/1h" description:"wallet ownership challenges per IP in limit/window format, 0 means unlimited"`
+	RateLimitStatusIP        string   `long:"ratelimit.status.ip" env:"RATELIMIT_STATUS_IP" default:"120/1h" description:"registration status requests per IP in limit/window format, 0 means unlimited"`
+	RateLimitConfirmIP       string   `long:"ratelimit.confirm.ip" env:"RATELIMIT_CONFIRM_IP" default:"30/1h" description:"confirmations per IP in limit/window format, 0 means unlimited"`
+	RateLimitConfirmEmail    string   `long:"ratelimit.confirm.email" env:"RATELIMIT_CONFIRM_EMAIL" default:"10/1h" description:"confirmations per email in limit/window format, 0 means unlimited"`
+	RateLimitDLoanIP         string   `long:"ratelimit.dloan.ip" env:"RATELIMIT_DLOAN_IP" default:"10/1h" description:"dLoan requests per IP in limit/window format, 0 means unlimited"`
+	RateLimitDLoanAddress    string   `long:"ratelimit.dloan.address" env:"RATELIMIT_DLOAN_ADDRESS" default:"3/24h" description:"dLoan requests per address in limit/window format, 0 means unlimited"`
+	RateLimitHesoyamIP       string   `long:"ratelimit.hesoyam.ip" env:"RATELIMIT_HESOYAM_IP" default:"10/1h" description:"testnet stakes requests per IP in limit/window format, 0 means unlimited"`
+	RateLimitHesoyamAddress  string   `long:"ratelimit.hesoyam.address" env:"RATELIMIT_HESOYAM_ADDRESS" default:"3/24h" description:"testnet stakes requests per address in limit/window format, 0 means unlimited"`
+
+	EmailNormalizationConfig string `long:"email.normalization_config" env:"EMAIL_NORMALIZATION_CONFIG" description:"path to json file with email normalization rules, built-in rules of gmail, yahoo and yandex are used if it's empty"`
+
+	EmailEncryptionKeys     []string `long:"email.encryption_keys" env:"EMAIL_ENCRYPTION_KEYS" env-delim:"," description:"email encryption keys in id:base64(32 bytes secret) format; the first key is current, the others are previous ones"`
+	EmailEncryptionKeysFile string   `long:"email.encryption_keys_file" env:"EMAIL_ENCRYPTION_KEYS_FILE" description:"path to file with email encryption keys, one key per line; it's used instead of email.encryption_keys"`
+
+	OwnerKeys      []string `long:"owner.keys" env:"OWNER_KEYS" env-delim:"," required:"true" description:"owner HMAC keys in id:secret format, secrets are at least 32 bytes long; the first key is current, the others are previous ones"`
+	OwnerLegacyMD5 bool     `long:"owner.legacy_md5" env:"OWNER_LEGACY_MD5" description:"match unsalted md5 owners of requests which aren't rehashed yet"`
+
+	FraudMXCheck         bool          `long:"fraud.mx_check" env:"FRAUD_MX_CHECK" description:"lookup MX records of email domains to match mx rules"`
+	FraudRequireMX       bool          `long:"fraud.require_mx" env:"FRAUD_REQUIRE_MX" description:"reject email domains without MX records, requires fraud.mx_check"`
+	FraudMXTimeout       time.Duration `long:"fraud.mx_timeout" env:"FRAUD_MX_TIMEOUT" default:"3s" description:"MX records lookup timeout"`
+	FraudRefreshInterval time.Duration `long:"fraud.refresh_interval" env:"FRAUD_REFRESH_INTERVAL" default:"1m" description:"how often fraud rules are reloaded from postgres"`
+
+	AdminTokens map[string]string `long:"admin.tokens" env:"ADMIN_TOKENS" env-delim:"," description:"admin API bearer tokens in actor:token format, tokens are at least 32 characters long; admin API is disabled if it's empty"`
+
+	AuthRegister     string        `long:"auth.register" env:"AUTH_REGISTER" default:"disabled" choice:"disabled" choice:"optional" choice:"required" description:"signature policy for wallet ownership proof at registration, signed registrations should carry a challenge"`
+	AuthChallengeTTL time.Duration `long:"auth.challenge_ttl" env:"AUTH_CHALLENGE_TTL" default:"5m" description:"wallet ownership challenge lifetime"`
+	AuthTrackInstall string        `long:"auth.track_install" env:"AUTH_TRACK_INSTALL" default:"disabled" choice:"disabled" choice:"optional" choice:"required" description:"signature policy for referral installation tracking"`
+	AuthDLoan        string        `long:"auth.dloan" env:"AUTH_DLOAN" default:"disabled" choice:"disabled" choice:"optional" choice:"required" description:"signature policy for dLoan requests"`
 
 	Postgres                   string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
 	PostgresMaxOpenConnections int    `long:"postgres.max_open_connections" env:"POSTGRES_MAX_OPEN_CONNECTIONS" default:"0" description:"postgres maximal open connections count, 0 means unlimited"`
 	PostgresMaxIdleConnections int    `long:"postgres.max_idle_connections" env:"POSTGRES_MAX_IDLE_CONNECTIONS" default:"5" description:"postgres maximal idle connections count"`
 	PostgresMigrations         string `long:"postgres.migrations" env:"POSTGRES_MIGRATIONS" default:"migrations/postgres" description:"postgres migrations directory"`
 
-	MandrillAPIKey                        string `long:"mandrill.api_key" env:"MANDRILL_API_KEY" description:"mandrillapp.com api key" required:"true"`
-	MandrillVerificationEmailSubject      string `long:"mandrill.verification_email_subject" env:"MANDRILL_VERIFICATION_EMAIL_SUBJECT" default:"decentr.xyz - Verification" description:"subject for verification emails"`
-	MandrillVerificationEmailTemplateName string `long:"mandrill.verification_email_template_name" env:"MANDRILL_VERIFICATION_EMAIL_TEMPLATE_NAME" description:"mandrill's verification template to be sent" required:"true"`
-	MandrillWelcomeEmailSubject           string `long:"mandrill.welcome_email_subject" env:"MANDRILL_WELCOME_EMAIL_SUBJECT" default:"decentr.xyz - Verified" description:"subject for welcome emails"`
-	MandrillWelcomeEmailTemplateName      string `long:"mandrill.welcome_email_template_name" env:"MANDRILL_WELCOME_EMAIL_TEMPLATE_NAME" description:"mandrill's welcome template to be sent" required:"true"`
-	MandrillFromName                      string `long:"mandrill.from_name" env:"MANDRILL_FROM_NAME" default:"decentr.xyz" description:"name for emails sender"`
-	MandrillFromEmail                     string `long:"mandrill.from_email" env:"MANDRILL_FROM_EMAIL" default:"noreply@decentrdev.com" description:"email for emails sender"`
-
-	GmailVerificationEmailSubject string `long:"gmail.verification_email_subject" env:"GMAIL_VERIFICATION_EMAIL_SUBJECT" default:"Decentr - Verification" description:"subject for verification emails"`
-	GmailWelcomeEmailSubject      string `long:"gmail.welcome_email_subject" env:"GMAIL_WELCOME_EMAIL_SUBJECT" default:"Decentr - Verified" description:"subject for welcome emails"`
-	GmailFromName                 string `long:"gmail.from_name" env:"GMAIL_FROM_NAME" default:"Decentr" description:"name for emails sender"`
-	GmailFromEmail                string `long:"gmail.from_email" env:"GMAIL_FROM_EMAIL" default:"no-reply@decentrdev.com" description:"email for emails sender"`
-	GmailFromPassword             string `long:"gmail.from_password" env:"GMAIL_FROM_PASSWORD" default:"" description:"password for emails sender"`
-	GmailSMTPHost                 string `long:"gmail.smtp_host" env:"GMAIL_SMTP_HOST" default:"smtp.gmail.com" description:"SMTP host"`
-	GmailSMTPPort                 int    `long:"gmail.smtp_port" env:"GMAIL_SMTP_PORT" default:"587" description:"SMTP port"`
-
-	BlockchainNode               string `long:"blockchain.node" env:"BLOCKCHAIN_NODE" default:"http://zeus.testnet.decentr.xyz:26657" description:"decentr node address"`
-	BlockchainFrom               string `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"decentr account name to send stakes" required:"true"`
-	BlockchainTxMemo             string `long:"blockchain.tx_memo" env:"BLOCKCHAIN_TX_MEMO" description:"decentr tx's memo'"`
-	BlockchainChainID            string `long:"blockchain.chain_id" env:"BLOCKCHAIN_CHAIN_ID" default:"testnet" description:"decentr chain id"`
-	BlockchainClientHome         string `long:"blockchain.client_home" env:"BLOCKCHAIN_CLIENT_HOME" default:"~/.decentrcli" description:"decentrcli home directory"`
-	BlockchainKeyringBackend     string `long:"blockchain.keyring_backend" env:"BLOCKCHAIN_KEYRING_BACKEND" default:"test" description:"decentrcli keyring backend"`
-	BlockchainKeyringPromptInput string `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"decentrcli keyring prompt input"`
-	BlockchainGas                uint64 `long:"blockchain.gas" env:"BLOCKCHAIN_GAS" default:"1000" description:"gas amount"`
-	BlockchainFee                string `long:"blockchain.fee" env:"BLOCKCHAIN_FEE" default:"5000udec" description:"transaction fee"`
+	MailProvider string `long:"mail.provider" env:"MAIL_PROVIDER" default:"gmail" choice:"gmail" choice:"mandrill" choice:"smtp" choice:"file" choice:"log" description:"mail provider emails are sent with"`
+
+	Gmail struct {
+		VerificationEmailSubject string `long:"verification_email_subject" env:"GMAIL_VERIFICATION_EMAIL_SUBJECT" default:"Decentr - Verification" description:"subject for verification emails"`
+		WelcomeEmailSubject      string `long:"welcome_email_subject" env:"GMAIL_WELCOME_EMAIL_SUBJECT" default:"Decentr - Verified" description:"subject for welcome emails"`
+		FromName                 string `long:"from_name" env:"GMAIL_FROM_NAME" default:"Decentr" description:"name for emails sender"`
+		FromEmail                string `long:"from_email" env:"GMAIL_FROM_EMAIL" default:"no-reply@decentrdev.com" description:"email for emails sender"`
+		FromPassword             string `long:"from_password" env:"GMAIL_FROM_PASSWORD" default:"" description:"password for emails sender"`
+		SMTPHost                 string `long:"smtp_host" env:"GMAIL_SMTP_HOST" default:"smtp.gmail.com" description:"SMTP host"`
+		SMTPPort                 int    `long:"smtp_port" env:"GMAIL_SMTP_PORT" default:"587" description:"SMTP port"`
+	} `group:"Gmail" namespace:"gmail"`
+
+	Mandrill struct {
+		APIKey                        string `long:"api_key" env:"MANDRILL_API_KEY" description:"mandrillapp.com api key; required with mandrill provider"`
+		VerificationEmailSubject      string `long:"verification_email_subject" env:"MANDRILL_VERIFICATION_EMAIL_SUBJECT" default:"decentr.xyz - Verification" description:"subject for verification emails"`
+		VerificationEmailTemplateName string `long:"verification_email_template_name" env:"MANDRILL_VERIFICATION_EMAIL_TEMPLATE_NAME" description:"mandrill's verification template to be sent; required with mandrill provider"`
+		WelcomeEmailSubject           string `long:"welcome_email_subject" env:"MANDRILL_WELCOME_EMAIL_SUBJECT" default:"decentr.xyz - Verified" description:"subject for welcome emails"`
+		WelcomeEmailTemplateName      string `long:"welcome_email_template_name" env:"MANDRILL_WELCOME_EMAIL_TEMPLATE_NAME" description:"mandrill's welcome template to be sent; required with mandrill provider"`
+		FromName                      string `long:"from_name" env:"MANDRILL_FROM_NAME" default:"decentr.xyz" description:"name for emails sender"`
+		FromEmail                     string `long:"from_email" env:"MANDRILL_FROM_EMAIL" default:"noreply@decentrdev.com" description:"email for emails sender"`
+	} `group:"Mandrill" namespace:"mandrill"`
+
+	SMTP struct {
+		Host                     string        `long:"host" env:"SMTP_HOST" description:"SMTP host; required with smtp provider"`
+		Port                     int           `long:"port" env:"SMTP_PORT" default:"587" description:"SMTP port"`
+		Username                 string        `long:"username" env:"SMTP_USERNAME" description:"SMTP username, smtp.from_email is used if it's empty"`
+		Password                 string        `long:"password" env:"SMTP_PASSWORD" description:"SMTP password"`
+		TLS                      string        `long:"tls" env:"SMTP_TLS" default:"starttls" choice:"starttls" choice:"tls" choice:"insecure" description:"how the connection is secured: STARTTLS, implicit TLS or no TLS at all for development servers"`
+		CAFile                   string        `long:"ca_file" env:"SMTP_CA_FILE" description:"path to PEM file with CA certificates trusted in addition to the system ones"`
+		PoolSize                 int           `long:"pool_size" env:"SMTP_POOL_SIZE" default:"2" description:"maximal count of idle connections kept open"`
+		IdleTimeout              time.Duration `long:"idle_timeout" env:"SMTP_IDLE_TIMEOUT" default:"1m" description:"how long a connection can be idle before it's closed"`
+		Timeout                  time.Duration `long:"timeout" env:"SMTP_TIMEOUT" default:"30s" description:"timeout of dialing and sending an email"`
+		VerificationEmailSubject string        `long:"verification_email_subject" env:"SMTP_VERIFICATION_EMAIL_SUBJECT" default:"Decentr - Verification" description:"subject for verification emails"`
+		WelcomeEmailSubject      string        `long:"welcome_email_subject" env:"SMTP_WELCOME_EMAIL_SUBJECT" default:"Decentr - Verified" description:"subject for welcome emails"`
+		FromName                 string        `long:"from_name" env:"SMTP_FROM_NAME" default:"Decentr" description:"name for emails sender"`
+		FromEmail                string        `long:"from_email" env:"SMTP_FROM_EMAIL" description:"email for emails sender; required with smtp provider"`
+	} `group:"SMTP" namespace:"smtp"`
+
+	File struct {
+		Dir                      string `long:"dir" env:"FILE_DIR" description:"maildir emails are written to as .eml files; required with file provider"`
+		VerificationEmailSubject string `long:"verification_email_subject" env:"FILE_VERIFICATION_EMAIL_SUBJECT" default:"Decentr - Verification" description:"subject for verification emails"`
+		WelcomeEmailSubject      string `long:"welcome_email_subject" env:"FILE_WELCOME_EMAIL_SUBJECT" default:"Decentr - Verified" description:"subject for welcome emails"`
+		FromName                 string `long:"from_name" env:"FILE_FROM_NAME" default:"Decentr" description:"name for emails sender"`
+		FromEmail                string `long:"from_email" env:"FILE_FROM_EMAIL" default:"no-reply@localhost" description:"email for emails sender"`
+	} `group:"File" namespace:"file"`
+
+	BlockchainNode               string        `long:"blockchain.node" env:"BLOCKCHAIN_NODE" default:"http://zeus.testnet.decentr.xyz:26657" description:"decentr node address"`
+	BlockchainFrom               string        `long:"blockchain.from" env:"BLOCKCHAIN_FROM" description:"decentr account name to send stakes" required:"true"`
+	BlockchainTxMemo             string        `long:"blockchain.tx_memo" env:"BLOCKCHAIN_TX_MEMO" description:"decentr tx's memo'"`
+	BlockchainChainID            string        `long:"blockchain.chain_id" env:"BLOCKCHAIN_CHAIN_ID" default:"testnet" description:"decentr chain id"`
+	BlockchainClientHome         string        `long:"blockchain.client_home" env:"BLOCKCHAIN_CLIENT_HOME" default:"~/.decentrcli" description:"decentrcli home directory"`
+	BlockchainKeyringBackend     string        `long:"blockchain.keyring_backend" env:"BLOCKCHAIN_KEYRING_BACKEND" default:"test" description:"decentrcli keyring backend"`
+	BlockchainKeyringPromptInput string        `long:"blockchain.keyring_prompt_input" env:"BLOCKCHAIN_KEYRING_PROMPT_INPUT" description:"decentrcli keyring prompt input"`
+	BlockchainGasAdjustment      float64       `long:"blockchain.gas_adjustment" env:"BLOCKCHAIN_GAS_ADJUSTMENT" default:"1.2" description:"multiplier applied to the simulated gas"`
+	BlockchainGasPriceInterval   time.Duration `long:"blockchain.gas_price_interval" env:"BLOCKCHAIN_GAS_PRICE_INTERVAL" default:"1m" description:"how often the minimal gas price is refreshed"`
+	BlockchainGRPCNodeURL        string        `long:"blockchain.grpc_node_url" env:"BLOCKCHAIN_GRPC_NODE_URL" default:"hera.mainnet.decentr.xyz:9090" description:"GRPC endpoint URL"`
 
 	LogLevel  string `long:"log.level" env:"LOG_LEVEL" default:"info" description:"Log level" choice:"debug" choice:"info" choice:"warning" choice:"error"`
 	SentryDSN string `long:"sentry.dsn" env:"SENTRY_DSN" description:"sentry dsn"`
 
 	InitialStakes int64 `long:"blockchain.initial_stakes" env:"BLOCKCHAIN_INITIAL_STAKES" default:"1000000" description:"stakes count to be sent"`
 
+	WalletCheckInterval   time.Duration `long:"wallet.check_interval" env:"WALLET_CHECK_INTERVAL" default:"1m" description:"how often the wallet balance is checked"`
+	WalletAlertThresholds []int         `long:"wallet.alert_thresholds" env:"WALLET_ALERT_THRESHOLDS" env-delim:"," default:"1000" default:"100" default:"10" description:"counts of registrations the balance is enough for to alert about low wallet balance"`
+
+	ConfirmationCodeLength     int           `long:"confirmation.code_length" env:"CONFIRMATION_CODE_LENGTH" default:"6" description:"length of confirmation code"`
+	ConfirmationCodeAlphabet   string        `long:"confirmation.code_alphabet" env:"CONFIRMATION_CODE_ALPHABET" default:"0123456789abcdef" description:"symbols confirmation code consists of"`
+	ConfirmationCodeTTL        time.Duration `long:"confirmation.code_ttl" env:"CONFIRMATION_CODE_TTL" default:"24h" description:"confirmation code lifetime, 0 means the code never expires"`
+	ConfirmationMaxAttempts    int           `long:"confirmation.max_attempts" env:"CONFIRMATION_MAX_ATTEMPTS" default:"5" description:"count of wrong codes before the request is locked, 0 means unlimited"`
+	ConfirmationResendInterval time.Duration `long:"confirmation.resend_interval" env:"CONFIRMATION_RESEND_INTERVAL" default:"1m" description:"minimal interval between confirmation code resends"`
+	ConfirmationMaxResends     int           `long:"confirmation.max_resends" env:"CONFIRMATION_MAX_RESENDS" default:"5" description:"count of confirmation code resends per registration, 0 means unlimited"`
+
+	ConfirmationLinkURL        string        `long:"confirmation.link_url" env:"CONFIRMATION_LINK_URL" description:"public url of /v1/confirm/link endpoint, verification emails go without confirmation link if it's empty"`
+	ConfirmationLinkSecret     string        `long:"confirmation.link_secret" env:"CONFIRMATION_LINK_SECRET" description:"secret confirmation link tokens are signed with, at least 32 bytes long; required with confirmation.link_url"`
+	ConfirmationLinkTTL        time.Duration `long:"confirmation.link_ttl" env:"CONFIRMATION_LINK_TTL" default:"24h" description:"confirmation link lifetime"`
+	ConfirmationLinkSuccessURL string        `long:"confirmation.link_success_url" env:"CONFIRMATION_LINK_SUCCESS_URL" description:"url confirmation link redirects to on success; required with confirmation.link_url"`
+	ConfirmationLinkFailureURL string        `long:"confirmation.link_failure_url" env:"CONFIRMATION_LINK_FAILURE_URL" description:"url confirmation link redirects to on failure with reason query param; required with confirmation.link_url"`
+
+	StatusProofSecret string        `long:"status.proof_secret" env:"STATUS_PROOF_SECRET" required:"true" description:"secret registration status proofs are signed with, at least 32 bytes long"`
+	StatusProofTTL    time.Duration `long:"status.proof_ttl" env:"STATUS_PROOF_TTL" default:"720h" description:"registration status proof lifetime"`
+
+	OutboxInterval    time.Duration `long:"outbox.interval" env:"OUTBOX_INTERVAL" default:"5s" description:"how often queued emails are sent"`
+	OutboxBatchSize   int           `long:"outbox.batch_size" env:"OUTBOX_BATCH_SIZE" default:"20" description:"maximal count of emails sent in one batch"`
+	OutboxMaxAttempts int           `long:"outbox.max_attempts" env:"OUTBOX_MAX_ATTEMPTS" default:"10" description:"count of delivery attempts before the email is considered as failed"`
+	OutboxMinBackoff  time.Duration `long:"outbox.min_backoff" env:"OUTBOX_MIN_BACKOFF" default:"30s" description:"delay before the second delivery attempt, it's doubled on every next attempt"`
+	OutboxMaxBackoff  time.Duration `long:"outbox.max_backoff" env:"OUTBOX_MAX_BACKOFF" default:"1h" description:"maximal delay between delivery attempts"`
+	OutboxLease       time.Duration `long:"outbox.lease" env:"OUTBOX_LEASE" default:"5m" description:"how long emails taken by a worker are hidden from other workers, it should cover sending of a batch"`
+
+	PayoutInterval      time.Duration `long:"payout.interval" env:"PAYOUT_INTERVAL" default:"10s" description:"how often pending payouts are broadcast"`
+	PayoutBatchSize     int           `long:"payout.batch_size" env:"PAYOUT_BATCH_SIZE" default:"20" description:"maximal count of payouts sent in one tx"`
+	PayoutBatchWindow   time.Duration `long:"payout.batch_window" env:"PAYOUT_BATCH_WINDOW" default:"30s" description:"how long a payout can wait for others to be sent in one tx"`
+	PayoutTrackInterval time.Duration `long:"payout.track_interval" env:"PAYOUT_TRACK_INTERVAL" default:"10s" description:"how often broadcast payouts are checked for inclusion in a block"`
+	PayoutTrackTimeout  time.Duration `long:"payout.track_timeout" env:"PAYOUT_TRACK_TIMEOUT" default:"5m" description:"how long to wait for payout tx inclusion before it's considered as failed"`
+
+	CapRegistrationDaily       int64 `long:"cap.registration.daily" env:"CAP_REGISTRATION_DAILY" default:"0" description:"uDEC amount of registration stakes sent per day, 0 means unlimited"`
+	CapRegistrationMonthly     int64 `long:"cap.registration.monthly" env:"CAP_REGISTRATION_MONTHLY" default:"0" description:"uDEC amount of registration stakes sent per month, 0 means unlimited"`
+	CapReferralSenderDaily     int64 `long:"cap.referral_sender.daily" env:"CAP_REFERRAL_SENDER_DAILY" default:"0" description:"uDEC amount of referral sender rewards sent per day, 0 means unlimited"`
+	CapReferralSenderMonthly   int64 `long:"cap.referral_sender.monthly" env:"CAP_REFERRAL_SENDER_MONTHLY" default:"0" description:"uDEC amount of referral sender rewards sent per month, 0 means unlimited"`
+	CapReferralReceiverDaily   int64 `long:"cap.referral_receiver.daily" env:"CAP_REFERRAL_RECEIVER_DAILY" default:"0" description:"uDEC amount of referral receiver rewards sent per day, 0 means unlimited"`
+	CapReferralReceiverMonthly int64 `long:"cap.referral_receiver.monthly" env:"CAP_REFERRAL_RECEIVER_MONTHLY" default:"0" description:"uDEC amount of referral receiver rewards sent per month, 0 means unlimited"`
+	CapTestnetDaily            int64 `long:"cap.testnet.daily" env:"CAP_TESTNET_DAILY" default:"0" description:"uDEC amount of testnet stakes sent per day, 0 means unlimited"`
+	CapTestnetMonthly          int64 `long:"cap.testnet.monthly" env:"CAP_TESTNET_MONTHLY" default:"0" description:"uDEC amount of testnet stakes sent per month, 0 means unlimited"`
+
 	ReferralThresholdPDV  string `long:"referral.threshold_pdv" env:"REFERRAL_THRESHOLD_PDV" default:"0.000100" description:"how many PDV a user should obtain to get a referral reward'"`
 	ReferralThresholdDays int    `long:"referral.threshold_days" env:"REFERRAL_THRESHOLD_DAYS" default:"30" description:"how many days a user should wait to get a referral reward'"`
 
@@ -107,11 +250,50 @@ func main() {
 		logrus.WithError(err).Fatal("error occurred while parsing flags")
 	}
 
+	if opts.ConfirmationCodeLength <= 0 || opts.ConfirmationCodeAlphabet == "" {
+		logrus.Fatal("confirmation code length and alphabet should not be empty")
+	}
+
+	if opts.ConfirmationLinkURL != "" && (opts.ConfirmationLinkSuccessURL == "" || opts.ConfirmationLinkFailureURL == "") {
+		logrus.Fatal("confirmation link success and failure urls should not be empty")
+	}
+
+	if opts.PayoutBatchSize <= 0 {
+		logrus.Fatal("payout batch size should be positive")
+	}
+
+	if opts.OutboxBatchSize <= 0 || opts.OutboxMaxAttempts <= 0 {
+		logrus.Fatal("outbox batch size and max attempts should be positive")
+	}
+
+	if opts.OutboxMinBackoff <= 0 || opts.OutboxMaxBackoff < opts.OutboxMinBackoff {
+		logrus.Fatal("outbox min backoff should be positive and not greater than max backoff")
+	}
+
+	if opts.OutboxLease <= 0 {
+		logrus.Fatal("outbox lease should be positive")
+	}
+
+	if opts.BlockchainGasAdjustment < 1 {
+		logrus.Fatal("gas adjustment should not be less than 1")
+	}
+
+	if opts.CaptchaAdaptiveThreshold <= 0 || opts.CaptchaAdaptiveDomainThreshold <= 0 || opts.CaptchaAdaptiveWindow <= 0 {
+		logrus.Fatal("captcha adaptive threshold and window should be positive")
+	}
+
+	if opts.FraudRequireMX && !opts.FraudMXCheck {
+		logrus.Fatal("fraud.require_mx requires fraud.mx_check")
+	}
+
+	if opts.FraudRefreshInterval <= 0 {
+		logrus.Fatal("fraud refresh interval should be positive")
+	}
+
 	lvl, _ := logrus.ParseLevel(opts.LogLevel) // err will always be nil
 	logrus.SetLevel(lvl)
 
 	logrus.Info("service started")
-	logrus.Infof("%+v", opts)
 
 	if opts.SentryDSN != "" {
 		hook, err := sentry.NewHook(sentry.Options{
@@ -131,34 +313,16 @@ func main() {
 	}
 
 	if opts.SlackHookURL != "" && opts.SlackChannel != "" {
-		logrus.AddHook(&slackrus.SlackrusHook{
-			HookURL:        opts.SlackHookURL,
-			AcceptedLevels: []logrus.Level{logrus.InfoLevel},
-			Channel:        opts.SlackChannel,
-			IconEmoji:      ":bread:",
-			Username:       "vulcan",
-			Filters: []slackrus.Filter{
-				func(entry *logrus.Entry) bool {
-					return entry.Data["sender"] == "slack"
-				},
-			},
-		})
+		logrus.AddHook(slack.NewHook(opts.SlackHookURL, opts.SlackChannel))
 	}
 
 	r := chi.NewMux()
 
 	db := mustGetDB()
 
-	mailSender := gmail.New(&gmail.Config{
-		VerificationSubject: opts.GmailVerificationEmailSubject,
-		WelcomeSubject:      opts.GmailWelcomeEmailSubject,
-		FromName:            opts.GmailFromName,
-		FromEmail:           opts.GmailFromEmail,
-		FromPassword:        opts.GmailFromPassword,
+	keyring := mustGetKeyring()
 
-		SMTPPort: opts.GmailSMTPPort,
-		SMTPHost: opts.GmailSMTPHost,
-	})
+	mailTransport := mustGetMailTransport()
 
 	nativeNodeConn, err := grpc.Dial(
 		opts.SupplyNativeNode,
@@ -169,30 +333,127 @@ func main() {
 	}
 
 	sup := supply.New(banktypes.NewQueryClient(nativeNodeConn), opts.SupplyERC20Node)
-	bc := mustGetBroadcaster()
+
+	blockchainNodeConn, err := grpc.Dial(
+		opts.BlockchainGRPCNodeURL,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create grpc conn to blockchain node")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	gp := blockchain.NewGasPricer(operationstypes.NewQueryClient(blockchainNodeConn))
+	if err := gp.Refresh(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to get gas price")
+	}
+	gp.Run(ctx, opts.BlockchainGasPriceInterval)
+
+	bc := mustGetBroadcaster(gp)
+	bcc := blockchain.New(bc, txtypes.NewServiceClient(blockchainNodeConn))
+
+	wm := wallet.NewMonitor(banktypes.NewQueryClient(blockchainNodeConn), bc.From(), sdk.NewInt(opts.InitialStakes), opts.WalletAlertThresholds)
+	wm.Run(ctx, opts.WalletCheckInterval)
 
 	rc := referral.NewConfig(sdk.MustNewDecFromStr(opts.ReferralThresholdPDV), opts.ReferralThresholdDays)
 
+	b := budget.New(map[storage.PayoutPurpose]budget.Cap{
+		storage.RegistrationPayoutPurpose:     {Daily: sdk.NewInt(opts.CapRegistrationDaily), Monthly: sdk.NewInt(opts.CapRegistrationMonthly)},
+		storage.ReferralSenderPayoutPurpose:   {Daily: sdk.NewInt(opts.CapReferralSenderDaily), Monthly: sdk.NewInt(opts.CapReferralSenderMonthly)},
+		storage.ReferralReceiverPayoutPurpose: {Daily: sdk.NewInt(opts.CapReferralReceiverDaily), Monthly: sdk.NewInt(opts.CapReferralReceiverMonthly)},
+		storage.TestnetPayoutPurpose:          {Daily: sdk.NewInt(opts.CapTestnetDaily), Monthly: sdk.NewInt(opts.CapTestnetMonthly)},
+	})
+
+	payout.NewWorker(postgres.New(db), bcc, b, opts.PayoutBatchSize, opts.PayoutBatchWindow).Run(ctx, opts.PayoutInterval)
+	payout.NewTracker(postgres.New(db), bcc, opts.PayoutTrackTimeout).Run(ctx, opts.PayoutTrackInterval)
+
+	mailSender := outbox.New(postgres.New(db), keyring, mailTransport, outbox.Config{
+		BatchSize:   opts.OutboxBatchSize,
+		MaxAttempts: opts.OutboxMaxAttempts,
+		MinBackoff:  opts.OutboxMinBackoff,
+		MaxBackoff:  opts.OutboxMaxBackoff,
+		Lease:       opts.OutboxLease,
+	})
+	mailSender.Run(ctx, opts.OutboxInterval)
+
+	hasher := mustGetHasher()
+	mustCheckOwners(ctx, postgres.New(db), hasher)
+
+	fe := mustGetFraudEngine(db)
+	if err := fe.Refresh(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to load fraud rules")
+	}
+	fe.Run(ctx, opts.FraudRefreshInterval)
+
+	rl := mustGetRateLimitConfig(db, hasher)
+	rl.Store.Run(ctx, time.Minute)
+
+	ct := captcha.NewTracker(opts.CaptchaAdaptiveWindow, opts.CaptchaAdaptiveThreshold)
+	ct.Run(ctx, opts.CaptchaAdaptiveWindow)
+
+	cdt := captcha.NewTracker(opts.CaptchaAdaptiveWindow, opts.CaptchaAdaptiveDomainThreshold)
+	cdt.Run(ctx, opts.CaptchaAdaptiveWindow)
+
 	server.SetupRouter(
 		service.New(
 			postgres.New(db),
 			mailSender,
-			blockchain.New(bc),
+			fe,
+			hasher,
+			keyring,
+			mustGetProofSigner(),
+			mustGetLinkSigner(),
 			sdk.NewInt(opts.InitialStakes),
 			opts.BlockchainTxMemo,
 			rc,
-			opts.RecaptchaSecret,
+			service.CodeConfig{
+				Length:      opts.ConfirmationCodeLength,
+				Alphabet:    opts.ConfirmationCodeAlphabet,
+				TTL:         opts.ConfirmationCodeTTL,
+				MaxAttempts: opts.ConfirmationMaxAttempts,
+
+				ResendInterval: opts.ConfirmationResendInterval,
+				MaxResends:     opts.ConfirmationMaxResends,
+
+				LinkURL: opts.ConfirmationLinkURL,
+			},
+			opts.AuthChallengeTTL,
 		),
 		sup,
 		r,
 		opts.RequestTimeout,
 		strings.Contains(opts.BlockchainNode, "testnet"),
+		server.AuthConfig{
+			Verifier:     server.NewVerifier(),
+			Register:     server.SignaturePolicy(opts.AuthRegister),
+			TrackInstall: server.SignaturePolicy(opts.AuthTrackInstall),
+			DLoan:        server.SignaturePolicy(opts.AuthDLoan),
+		},
+		server.CaptchaConfig{
+			Verifier:      mustGetCaptcha(),
+			Tracker:       ct,
+			DomainTracker: cdt,
+			Hasher:        hasher,
+			Register:      server.CaptchaPolicy(opts.CaptchaRegister),
+			Resend:        server.CaptchaPolicy(opts.CaptchaResend),
+			Confirm:       server.CaptchaPolicy(opts.CaptchaConfirm),
+			DLoan:         server.CaptchaPolicy(opts.CaptchaDLoan),
+			Hesoyam:       server.CaptchaPolicy(opts.CaptchaHesoyam),
+		},
+		rl,
+		mustGetAdminConfig(db, hasher),
+		server.ConfirmLinkConfig{
+			SuccessURL: opts.ConfirmationLinkSuccessURL,
+			FailureURL: opts.ConfirmationLinkFailureURL,
+		},
 	)
 
 	health.SetupRouter(r,
 		health.SubjectPinger("postgres", db.PingContext),
 		health.SubjectPinger("blockchain", bc.PingContext),
 		health.SubjectPinger("supply", sup.PingContext),
+		wm,
 	)
 
 	srv := http.Server{
@@ -211,6 +472,8 @@ func main() {
 
 		logrus.Infof("terminating by %s signal", s)
 
+		cancel()
+
 		if err := srv.Shutdown(context.Background()); err != nil {
 			logrus.WithError(err).Error("failed to gracefully shutdown server")
 		}
@@ -225,6 +488,282 @@ func main() {
 	}
 }
 
+func mustGetMailTransport() mail.Transport {
+	switch opts.MailProvider {
+	case "mandrill":
+		if opts.Mandrill.APIKey == "" ||
+			opts.Mandrill.VerificationEmailTemplateName == "" || opts.Mandrill.WelcomeEmailTemplateName == "" {
+			logrus.Fatal("mandrill api key and template names should not be empty")
+		}
+
+		return mandrill.New(mandrillclient.ClientWithKey(opts.Mandrill.APIKey), &mandrill.Config{
+			VerificationSubject:      opts.Mandrill.VerificationEmailSubject,
+			VerificationTemplateName: opts.Mandrill.VerificationEmailTemplateName,
+			WelcomeSubject:           opts.Mandrill.WelcomeEmailSubject,
+			WelcomeTemplateName:      opts.Mandrill.WelcomeEmailTemplateName,
+			FromName:                 opts.Mandrill.FromName,
+			FromEmail:                opts.Mandrill.FromEmail,
+		})
+	case "smtp":
+		if opts.SMTP.Host == "" || opts.SMTP.FromEmail == "" {
+			logrus.Fatal("smtp host and from email should not be empty")
+		}
+
+		if opts.SMTP.PoolSize < 0 || opts.SMTP.IdleTimeout <= 0 || opts.SMTP.Timeout <= 0 {
+			logrus.Fatal("smtp pool size should not be negative, idle timeout and timeout should be positive")
+		}
+
+		username := opts.SMTP.Username
+		if username == "" {
+			username = opts.SMTP.FromEmail
+		}
+
+		return smtp.New(&smtp.Config{
+			Host:     opts.SMTP.Host,
+			Port:     opts.SMTP.Port,
+			Username: username,
+			Password: opts.SMTP.Password,
+
+			TLSMode: smtp.TLSMode(opts.SMTP.TLS),
+			RootCAs: mustGetSMTPRootCAs(),
+
+			PoolSize:    opts.SMTP.PoolSize,
+			IdleTimeout: opts.SMTP.IdleTimeout,
+			Timeout:     opts.SMTP.Timeout,
+
+			VerificationSubject: opts.SMTP.VerificationEmailSubject,
+			WelcomeSubject:      opts.SMTP.WelcomeEmailSubject,
+			FromName:            opts.SMTP.FromName,
+			FromEmail:           opts.SMTP.FromEmail,
+		})
+	case "file":
+		if opts.File.Dir == "" {
+			logrus.Fatal("file dir should not be empty")
+		}
+
+		t, err := file.New(&file.Config{
+			Dir:                 opts.File.Dir,
+			VerificationSubject: opts.File.VerificationEmailSubject,
+			WelcomeSubject:      opts.File.WelcomeEmailSubject,
+			FromName:            opts.File.FromName,
+			FromEmail:           opts.File.FromEmail,
+		})
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to create file mail transport")
+		}
+
+		return t
+	case "log":
+		logrus.Warn("emails are only logged")
+
+		return logger.New()
+	default:
+		return smtp.New(&smtp.Config{
+			Host:     opts.Gmail.SMTPHost,
+			Port:     opts.Gmail.SMTPPort,
+			Username: opts.Gmail.FromEmail,
+			Password: opts.Gmail.FromPassword,
+
+			TLSMode:  smtp.StartTLSMode,
+			PoolSize: 1,
+
+			VerificationSubject: opts.Gmail.VerificationEmailSubject,
+			WelcomeSubject:      opts.Gmail.WelcomeEmailSubject,
+			FromName:            opts.Gmail.FromName,
+			FromEmail:           opts.Gmail.FromEmail,
+		})
+	}
+}
+
+func mustGetSMTPRootCAs() *x509.CertPool {
+	if opts.SMTP.CAFile == "" {
+		return nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load system certificates")
+	}
+
+	b, err := os.ReadFile(opts.SMTP.CAFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to read smtp ca file")
+	}
+
+	if !pool.AppendCertsFromPEM(b) {
+		logrus.Fatal("smtp ca file doesn't contain any certificate")
+	}
+
+	return pool
+}
+
+func mustGetCaptcha() captcha.Verifier {
+	v, err := captcha.New(captcha.Config{
+		Provider:   captcha.Provider(opts.CaptchaProvider),
+		URL:        opts.CaptchaURL,
+		Secret:     opts.CaptchaSecret,
+		Timeout:    opts.CaptchaTimeout,
+		MinScore:   opts.CaptchaMinScore,
+		MinScores:  opts.CaptchaMinScores,
+		Hostnames:  opts.CaptchaHostnames,
+		TestTokens: opts.CaptchaTestTokens,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create captcha verifier")
+	}
+
+	return v
+}
+
+func mustGetNormalizer() *normalizer.Normalizer {
+	n, err := normalizer.Open(opts.EmailNormalizationConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create email normalizer")
+	}
+
+	return n
+}
+
+func mustGetHasher() *owner.Hasher {
+	keys, err := owner.ParseKeys(opts.OwnerKeys)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to parse owner keys")
+	}
+
+	h, err := owner.NewHasher(mustGetNormalizer(), keys, opts.OwnerLegacyMD5)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create owner hasher")
+	}
+
+	return h
+}
+
+// mustCheckOwners refuses to start if requests are stored with owners the hasher doesn't match,
+// since duplicated registrations of their emails wouldn't be detected.
+func mustCheckOwners(ctx context.Context, s storage.Storage, h *owner.Hasher) {
+	ids, err := s.GetOwnerKeyIDs(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to get owner key ids")
+	}
+
+	for _, v := range ids {
+		switch {
+		case h.Matches(v):
+		case v == "":
+			logrus.Fatal("requests with md5 owners are stored: enable owner.legacy_md5 till rehash-owners is run")
+		default:
+			logrus.WithField("key_id", v).Fatal("requests with owners of unknown key are stored: add the key to owner.keys till rehash-owners is run")
+		}
+	}
+}
+
+func mustGetProofSigner() *proof.Signer {
+	s, err := proof.NewSigner([]byte(opts.StatusProofSecret), opts.StatusProofTTL)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create status proof signer")
+	}
+
+	return s
+}
+
+func mustGetLinkSigner() *proof.Signer {
+	if opts.ConfirmationLinkURL == "" {
+		return nil
+	}
+
+	s, err := proof.NewSigner([]byte(opts.ConfirmationLinkSecret), opts.ConfirmationLinkTTL)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create confirmation link signer")
+	}
+
+	return s
+}
+
+func mustGetKeyring() *envelope.Keyring {
+	k, err := envelope.OpenKeyring(opts.EmailEncryptionKeys, opts.EmailEncryptionKeysFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create email keyring")
+	}
+
+	return k
+}
+
+func mustGetFraudEngine(db *sql.DB) *fraud.Engine {
+	mx := fraud.MXConfig{
+		RequireMX: opts.FraudRequireMX,
+		Timeout:   opts.FraudMXTimeout,
+	}
+	if opts.FraudMXCheck {
+		mx.Resolver = net.DefaultResolver
+	}
+
+	return fraud.NewEngine(postgres.New(db), mx)
+}
+
+func mustGetAdminConfig(db *sql.DB, hasher *owner.Hasher) server.AdminConfig {
+	cfg := server.AdminConfig{
+		Service: service.NewAdmin(postgres.New(db), hasher),
+		Tokens:  make(map[string]string, len(opts.AdminTokens)),
+	}
+
+	for actor, token := range opts.AdminTokens {
+		if actor == "" || len(token) < 32 {
+			logrus.WithField("actor", actor).Fatal("admin token should be at least 32 characters long")
+		}
+		if _, ok := cfg.Tokens[token]; ok {
+			logrus.WithField("actor", actor).Fatal("admin token is duplicated")
+		}
+		cfg.Tokens[token] = actor
+	}
+
+	return cfg
+}
+
+func mustGetRateLimitConfig(db *sql.DB, hasher *owner.Hasher) server.RateLimitConfig {
+	cfg := server.RateLimitConfig{
+		Store:  server.NewStorageRateLimitStore(postgres.New(db)),
+		Hasher: hasher,
+	}
+	if opts.RateLimitStore == "memory" {
+		cfg.Store = server.NewMemoryRateLimitStore()
+	}
+
+	for _, v := range opts.RateLimitTrustedProxies {
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to parse trusted proxy")
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, n)
+	}
+
+	for _, v := range []struct {
+		dst *server.RateLimit
+		s   string
+	}{
+		{&cfg.Register.IP, opts.RateLimitRegisterIP},
+		{&cfg.Register.Email, opts.RateLimitRegisterEmail},
+		{&cfg.Register.Address, opts.RateLimitRegisterAddress},
+		{&cfg.Resend.IP, opts.RateLimitResendIP},
+		{&cfg.Resend.Email, opts.RateLimitResendEmail},
+		{&cfg.Status.IP, opts.RateLimitStatusIP},
+		{&cfg.Challenge.IP, opts.RateLimitChallengeIP},
+		{&cfg.Confirm.IP, opts.RateLimitConfirmIP},
+		{&cfg.Confirm.Email, opts.RateLimitConfirmEmail},
+		{&cfg.DLoan.IP, opts.RateLimitDLoanIP},
+		{&cfg.DLoan.Address, opts.RateLimitDLoanAddress},
+		{&cfg.Hesoyam.IP, opts.RateLimitHesoyamIP},
+		{&cfg.Hesoyam.Address, opts.RateLimitHesoyamAddress},
+	} {
+		l, err := server.ParseRateLimit(v.s)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to parse rate limit")
+		}
+		*v.dst = l
+	}
+
+	return cfg
+}
+
 func mustGetDB() *sql.DB {
 	db, err := sql.Open("postgres", opts.Postgres)
 	if err != nil {
@@ -268,24 +807,16 @@ func mustGetDB() *sql.DB {
 	return db
 }
 
-func mustGetBroadcaster() broadcaster.Broadcaster {
-	fee, err := sdk.ParseCoinNormalized(opts.BlockchainFee)
-	if err != nil {
-		logrus.WithError(err).Error("failed to parse fee")
-	}
-
-	b, err := broadcaster.New(broadcaster.Config{
+func mustGetBroadcaster(gp *blockchain.GasPricer) blockchain.Broadcaster {
+	b, err := blockchain.NewBroadcaster(blockchain.BroadcasterConfig{
 		KeyringRootDir:     opts.BlockchainClientHome,
 		KeyringBackend:     opts.BlockchainKeyringBackend,
 		KeyringPromptInput: opts.BlockchainKeyringPromptInput,
 		NodeURI:            opts.BlockchainNode,
-		BroadcastMode:      cliflags.BroadcastSync,
 		From:               opts.BlockchainFrom,
 		ChainID:            opts.BlockchainChainID,
-		Gas:                opts.BlockchainGas,
-		GasAdjust:          1.2,
-		Fees:               sdk.Coins{fee},
-	})
+		GasAdjust:          opts.BlockchainGasAdjustment,
+	}, gp)
 
 	if err != nil {
 		logrus.WithError(err).Fatal("failed to create main broadcaster")
@@ -24,7 +24,10 @@ require (
 	github.com/lib/pq v1.10.4
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
+	github.com/tendermint/spm v0.1.8-0.20211026072440-6f215802f3ec
+	github.com/tendermint/tendermint v0.34.14
 	github.com/testcontainers/testcontainers-go v0.11.0
+	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 	google.golang.org/grpc v1.42.0
 )
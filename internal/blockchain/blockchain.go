@@ -2,15 +2,19 @@
 package blockchain
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/avast/retry-go"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 
 	"github.com/Decentr-net/decentr/config"
-	"github.com/Decentr-net/go-broadcaster"
 )
 
 //go:generate mockgen -destination=./mock/blockchain.go -package=mock -source=blockchain.go
@@ -23,53 +27,222 @@ func init() {
 // ErrInvalidAddress is returned when address is invalid. It is unexpected situation.
 var ErrInvalidAddress = errors.New("invalid address")
 
+// ErrTxRejected is returned when the node refused the tx, so it is guaranteed it wasn't included in a block.
+var ErrTxRejected = errors.New("tx rejected")
+
+// ErrTxNotFound is returned when tx is not included in a block (yet).
+var ErrTxNotFound = errors.New("tx not found")
+
+var errNoStakes = errors.New("no stakes to send")
+
+const (
+	retryAttempts = 3
+	retryDelay    = 2 * time.Second
+
+	// findTxPageSize is the maximal page size of txs search.
+	findTxPageSize = 100
+)
+
+// safeErrors are parts of errors' messages which mean the tx wasn't accepted by the node.
+// nolint:gochecknoglobals
+var safeErrors = []string{
+	"account sequence mismatch",
+	"connection refused",
+}
+
 // Stake ...
 type Stake struct {
 	Address string
 	Amount  sdk.Int
 }
 
+// TxResult is a result of tx included in a block.
+type TxResult struct {
+	Height int64
+	Code   uint32
+	RawLog string
+}
+
 // Blockchain is interface for interacting with the blockchain.
 type Blockchain interface {
-	SendStakes(stakes []Stake, memo string) error
+	// SendStakes sends stakes in one tx.
+	// ErrTxRejected is returned if the node refused the tx, e.g. because of an invalid message.
+	// The key is an idempotency key put into memo: if tx with the key is already sent, it is returned.
+	SendStakes(ctx context.Context, key string, stakes []Stake, memo string) (*Tx, error)
+	// FindTx returns the tx sent to the address with the key in memo, the tx is looked for in the mempool and in blocks.
+	// ErrTxNotFound is returned if there is no such tx.
+	// ErrMempoolTruncated is returned if the mempool can't be looked through, the tx mustn't be sent again then.
+	FindTx(ctx context.Context, key string, address string) (*Tx, error)
+	// GetTx returns result of the included tx. ErrTxNotFound is returned if tx is not included in a block.
+	GetTx(ctx context.Context, hash string) (*TxResult, error)
 }
 
 type blockchain struct {
-	b broadcaster.Broadcaster
+	b   Broadcaster
+	txs txtypes.ServiceClient
+
+	retryDelay time.Duration
 }
 
 // New returns new instance of Blockchain.
-func New(b broadcaster.Broadcaster) Blockchain {
+func New(b Broadcaster, txs txtypes.ServiceClient) Blockchain {
 	return blockchain{
-		b: b,
+		b:   b,
+		txs: txs,
+
+		retryDelay: retryDelay,
 	}
 }
 
 // SendStakes ...
-func (b blockchain) SendStakes(stakes []Stake, memo string) error {
+func (b blockchain) SendStakes(ctx context.Context, key string, stakes []Stake, memo string) (*Tx, error) {
+	if len(stakes) == 0 {
+		return nil, errNoStakes
+	}
+
+	messages := make([]sdk.Msg, len(stakes))
+	for idx, stake := range stakes {
+		to, err := sdk.AccAddressFromBech32(stake.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, stake.Address)
+		}
+
+		messages[idx] = banktypes.NewMsgSend(b.b.From(), to, sdk.Coins{sdk.Coin{
+			Denom:  config.DefaultBondDenom,
+			Amount: stake.Amount,
+		}})
+		if err := messages[idx].ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrTxRejected, err)
+		}
+	}
+
+	memo = memoWithKey(memo, key)
+
+	var out *Tx
 	sendStakes := func() error {
-		messages := make([]sdk.Msg, len(stakes))
-		for idx, stake := range stakes {
-			to, err := sdk.AccAddressFromBech32(stake.Address)
-			if err != nil {
-				return fmt.Errorf("%w: %s", ErrInvalidAddress, stake.Address)
+		// the previous attempt (or the previous run) could deliver the tx in spite of the error
+		tx, err := b.FindTx(ctx, key, stakes[0].Address)
+		switch {
+		case err == nil:
+			out = tx
+			return nil
+		case !errors.Is(err, ErrTxNotFound):
+			return fmt.Errorf("failed to check tx existence: %w", err)
+		}
+
+		tx, err = b.b.Broadcast(messages, memo)
+		if err != nil {
+			return fmt.Errorf("failed to broadcast msg: %w", err)
+		}
+		out = tx
+
+		return nil
+	}
+
+	if err := retry.Do(sendStakes,
+		retry.Context(ctx),
+		retry.Attempts(retryAttempts),
+		retry.Delay(b.retryDelay),
+		retry.RetryIf(isSafeToRetry),
+		retry.LastErrorOnly(true),
+	); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// FindTx looks for the tx sent to the address with the key in memo.
+// The mempool is checked first, so a tx included in a block in the meantime is found among included txs.
+func (b blockchain) FindTx(ctx context.Context, key string, address string) (*Tx, error) {
+	unconfirmed, err := b.b.UnconfirmedTxs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range unconfirmed {
+		if hasKey(v.Memo, key) {
+			tx := v.Tx
+			return &tx, nil
+		}
+	}
+
+	// new txs are put at the beginning, so pages can only repeat txs but not skip them
+	for offset := uint64(0); ; offset += findTxPageSize {
+		resp, err := b.txs.GetTxsEvent(ctx, &txtypes.GetTxsEventRequest{
+			Events: []string{
+				fmt.Sprintf("message.sender='%s'", b.b.From()),
+				fmt.Sprintf("transfer.recipient='%s'", address),
+			},
+			Pagination: &query.PageRequest{Offset: offset, Limit: findTxPageSize},
+			OrderBy:    txtypes.OrderBy_ORDER_BY_DESC,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, tx := range resp.Txs {
+			if tx.Body == nil || !hasKey(tx.Body.Memo, key) || i >= len(resp.TxResponses) {
+				continue
 			}
 
-			messages[idx] = banktypes.NewMsgSend(b.b.From(), to, sdk.Coins{sdk.Coin{
-				Denom:  config.DefaultBondDenom,
-				Amount: stake.Amount,
-			}})
-			if err := messages[idx].ValidateBasic(); err != nil {
-				return err
+			out := &Tx{Hash: resp.TxResponses[i].TxHash}
+			if tx.AuthInfo != nil && tx.AuthInfo.Fee != nil {
+				out.Fee = tx.AuthInfo.Fee.Amount
+				out.Gas = tx.AuthInfo.Fee.GasLimit
 			}
+
+			return out, nil
 		}
 
-		if _, err := b.b.Broadcast(messages, memo); err != nil {
-			return fmt.Errorf("failed to broadcast msg: %w", err)
+		if len(resp.Txs) == 0 || resp.Pagination == nil || offset+uint64(len(resp.Txs)) >= resp.Pagination.Total {
+			return nil, ErrTxNotFound
+		}
+	}
+}
+
+// isSafeToRetry returns true for errors which guarantee the tx was not accepted by the node.
+// ErrTxInMempoolCache is retried too, since the next attempt will find the tx in the mempool or in a block.
+func isSafeToRetry(err error) bool {
+	if errors.Is(err, ErrTxInMempoolCache) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, v := range safeErrors {
+		if strings.Contains(msg, v) {
+			return true
 		}
+	}
 
-		return nil
+	return false
+}
+
+func memoWithKey(memo, key string) string {
+	if memo == "" {
+		return "[" + key + "]"
+	}
+	return memo + " [" + key + "]"
+}
+
+func hasKey(memo, key string) bool {
+	return strings.HasSuffix(memo, "["+key+"]")
+}
+
+// GetTx ...
+func (b blockchain) GetTx(ctx context.Context, hash string) (*TxResult, error) {
+	resp, err := b.txs.GetTx(ctx, &txtypes.GetTxRequest{Hash: hash})
+	if err != nil {
+		// node doesn't use grpc status codes for unknown txs, so only message can be checked
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("%w: %s", ErrTxNotFound, hash)
+		}
+		return nil, fmt.Errorf("failed to get tx: %w", err)
 	}
 
-	return retry.Do(sendStakes, retry.Attempts(3))
+	return &TxResult{
+		Height: resp.TxResponse.Height,
+		Code:   resp.TxResponse.Code,
+		RawLog: resp.TxResponse.RawLog,
+	}, nil
 }
@@ -0,0 +1,244 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const testAddress = "decentr1vg085ra5hw8mx5rrheqf8fruks0xv4urqkuqga"
+
+var (
+	errTest = errors.New("test")
+	testFee = sdk.NewCoins(sdk.NewInt64Coin("udec", 10))
+)
+
+type fakeBroadcaster struct {
+	Broadcaster
+
+	errs  []error
+	calls int
+	memo  string
+
+	unconfirmed    []*UnconfirmedTx
+	unconfirmedErr error
+}
+
+func (f *fakeBroadcaster) UnconfirmedTxs(_ context.Context) ([]*UnconfirmedTx, error) {
+	return f.unconfirmed, f.unconfirmedErr
+}
+
+func (f *fakeBroadcaster) From() sdk.AccAddress {
+	return sdk.AccAddress("from")
+}
+
+func (f *fakeBroadcaster) Broadcast(_ []sdk.Msg, memo string) (*Tx, error) {
+	f.calls++
+	f.memo = memo
+
+	if len(f.errs) >= f.calls && f.errs[f.calls-1] != nil {
+		return nil, f.errs[f.calls-1]
+	}
+
+	return &Tx{Hash: "broadcast", Fee: testFee, Gas: 100}, nil
+}
+
+type fakeTxs struct {
+	txtypes.ServiceClient
+
+	// memos are returned by the n-th GetTxsEvent call
+	memos [][]string
+	calls int
+}
+
+func (f *fakeTxs) GetTxsEvent(_ context.Context, _ *txtypes.GetTxsEventRequest, _ ...grpc.CallOption) (*txtypes.GetTxsEventResponse, error) {
+	f.calls++
+
+	resp := &txtypes.GetTxsEventResponse{}
+	if len(f.memos) < f.calls {
+		return resp, nil
+	}
+
+	for _, memo := range f.memos[f.calls-1] {
+		resp.Txs = append(resp.Txs, &txtypes.Tx{
+			Body:     &txtypes.TxBody{Memo: memo},
+			AuthInfo: &txtypes.AuthInfo{Fee: &txtypes.Fee{Amount: testFee, GasLimit: 100}},
+		})
+		resp.TxResponses = append(resp.TxResponses, &sdk.TxResponse{TxHash: "found"})
+	}
+
+	return resp, nil
+}
+
+func TestBlockchain_SendStakes(t *testing.T) {
+	tt := []struct {
+		name           string
+		errs           []error
+		memos          [][]string
+		unconfirmedErr error
+		hash           string
+		broadcasts     int
+		err            error
+	}{
+		{
+			name:       "success",
+			hash:       "broadcast",
+			broadcasts: 1,
+		},
+		{
+			name:       "already sent",
+			memos:      [][]string{{"other [key2]", "memo [key]"}},
+			hash:       "found",
+			broadcasts: 0,
+		},
+		{
+			name:       "retry safe error",
+			errs:       []error{errors.New("account sequence mismatch, expected 2, got 1")},
+			hash:       "broadcast",
+			broadcasts: 2,
+		},
+		{
+			name:       "delivered in spite of error",
+			errs:       []error{ErrTxInMempoolCache},
+			memos:      [][]string{nil, {"memo [key]"}},
+			hash:       "found",
+			broadcasts: 1,
+		},
+		{
+			name:       "rejected",
+			errs:       []error{fmt.Errorf("%w: code 5: insufficient funds", ErrTxRejected)},
+			broadcasts: 1,
+			err:        ErrTxRejected,
+		},
+		{
+			name:           "truncated mempool",
+			unconfirmedErr: ErrMempoolTruncated,
+			broadcasts:     0,
+			err:            ErrMempoolTruncated,
+		},
+		{
+			name:       "unsafe error",
+			errs:       []error{errTest},
+			broadcasts: 1,
+			err:        errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &fakeBroadcaster{errs: tc.errs, unconfirmedErr: tc.unconfirmedErr}
+			bc := blockchain{b: b, txs: &fakeTxs{memos: tc.memos}}
+
+			tx, err := bc.SendStakes(context.Background(), "key", []Stake{{Address: testAddress, Amount: sdk.NewInt(1)}}, "memo")
+			assert.ErrorIs(t, err, tc.err)
+			if tc.hash != "" {
+				require.NotNil(t, tx)
+				assert.Equal(t, &Tx{Hash: tc.hash, Fee: testFee, Gas: 100}, tx)
+			}
+			assert.Equal(t, tc.broadcasts, b.calls)
+			if b.calls > 0 {
+				assert.Equal(t, "memo [key]", b.memo)
+			}
+		})
+	}
+}
+
+// pagedTxs returns txs with the memos page by page.
+type pagedTxs struct {
+	txtypes.ServiceClient
+
+	memos []string
+	calls int
+}
+
+func (f *pagedTxs) GetTxsEvent(_ context.Context, req *txtypes.GetTxsEventRequest, _ ...grpc.CallOption) (*txtypes.GetTxsEventResponse, error) {
+	f.calls++
+
+	resp := &txtypes.GetTxsEventResponse{Pagination: &query.PageResponse{Total: uint64(len(f.memos))}}
+	for i := req.Pagination.Offset; i < req.Pagination.Offset+req.Pagination.Limit && i < uint64(len(f.memos)); i++ {
+		resp.Txs = append(resp.Txs, &txtypes.Tx{Body: &txtypes.TxBody{Memo: f.memos[i]}})
+		resp.TxResponses = append(resp.TxResponses, &sdk.TxResponse{TxHash: fmt.Sprintf("tx%d", i)})
+	}
+
+	return resp, nil
+}
+
+func TestBlockchain_FindTx(t *testing.T) {
+	memos := make([]string, findTxPageSize*2+10)
+	for i := range memos {
+		memos[i] = fmt.Sprintf("memo [key%d]", i)
+	}
+
+	t.Run("older page", func(t *testing.T) {
+		txs := &pagedTxs{memos: memos}
+		bc := blockchain{b: &fakeBroadcaster{}, txs: txs}
+
+		tx, err := bc.FindTx(context.Background(), fmt.Sprintf("key%d", findTxPageSize*2+5), testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("tx%d", findTxPageSize*2+5), tx.Hash)
+		assert.Equal(t, 3, txs.calls)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		txs := &pagedTxs{memos: memos}
+		bc := blockchain{b: &fakeBroadcaster{}, txs: txs}
+
+		_, err := bc.FindTx(context.Background(), "key", testAddress)
+		assert.ErrorIs(t, err, ErrTxNotFound)
+		assert.Equal(t, 3, txs.calls)
+	})
+
+	t.Run("mempool", func(t *testing.T) {
+		txs := &pagedTxs{memos: memos}
+		bc := blockchain{b: &fakeBroadcaster{unconfirmed: []*UnconfirmedTx{
+			{Tx: Tx{Hash: "other"}, Memo: "memo [key2]"},
+			{Tx: Tx{Hash: "unconfirmed", Fee: testFee, Gas: 100}, Memo: "memo [key]"},
+		}}, txs: txs}
+
+		tx, err := bc.FindTx(context.Background(), "key", testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, &Tx{Hash: "unconfirmed", Fee: testFee, Gas: 100}, tx)
+		assert.Zero(t, txs.calls)
+	})
+
+	t.Run("truncated mempool", func(t *testing.T) {
+		txs := &pagedTxs{memos: memos}
+		bc := blockchain{b: &fakeBroadcaster{unconfirmedErr: ErrMempoolTruncated}, txs: txs}
+
+		_, err := bc.FindTx(context.Background(), "key1", testAddress)
+		assert.ErrorIs(t, err, ErrMempoolTruncated)
+		assert.Zero(t, txs.calls, "tx mustn't be reported as sent or not sent")
+	})
+
+	t.Run("mempool error", func(t *testing.T) {
+		bc := blockchain{b: &fakeBroadcaster{unconfirmedErr: errTest}, txs: &pagedTxs{}}
+
+		_, err := bc.FindTx(context.Background(), "key", testAddress)
+		assert.ErrorIs(t, err, errTest)
+	})
+}
+
+func TestBlockchain_SendStakes_InvalidAddress(t *testing.T) {
+	bc := blockchain{b: &fakeBroadcaster{}, txs: &fakeTxs{}}
+
+	_, err := bc.SendStakes(context.Background(), "key", []Stake{{Address: "invalid", Amount: sdk.NewInt(1)}}, "")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+func Test_memoWithKey(t *testing.T) {
+	assert.Equal(t, "[key]", memoWithKey("", "key"))
+	assert.Equal(t, "memo [key]", memoWithKey("memo", "key"))
+	assert.True(t, hasKey(memoWithKey("memo", "key"), "key"))
+	assert.False(t, hasKey(memoWithKey("memo", "key1"), "key"))
+}
@@ -0,0 +1,314 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	cliflags "github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/tendermint/spm/cosmoscmd"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"google.golang.org/grpc/status"
+
+	"github.com/Decentr-net/decentr/app"
+	"github.com/Decentr-net/decentr/config"
+)
+
+// ErrTxInMempoolCache is returned when tx is already broadcast and exists in mempool cache.
+var ErrTxInMempoolCache = errors.New("tx is already in mempool cache")
+
+// ErrMempoolTruncated is returned when the node's mempool has more txs than can be looked through,
+// so it's unknown whether a tx is there.
+var ErrMempoolTruncated = errors.New("mempool is truncated")
+
+// mempoolLimit is the maximal count of unconfirmed txs the node returns, the mempool can't be paged through.
+const mempoolLimit = 100
+
+// nolint:gochecknoglobals
+var accountSequenceMismatchErrorRegExp = regexp.MustCompile(`account sequence mismatch, expected (\d+), got \d+`)
+
+// Tx is a broadcast tx.
+type Tx struct {
+	Hash string
+	Fee  sdk.Coins
+	Gas  uint64
+}
+
+// UnconfirmedTx is a tx which is in the node's mempool.
+type UnconfirmedTx struct {
+	Tx
+	Memo string
+}
+
+// Broadcaster signs and broadcasts txs to the node.
+type Broadcaster interface {
+	// From returns address of broadcaster.
+	From() sdk.AccAddress
+	// Broadcast broadcasts messages in one tx. ErrTxRejected is returned if the node refused the tx.
+	Broadcast(msgs []sdk.Msg, memo string) (*Tx, error)
+	// UnconfirmedTxs returns txs of the broadcaster which are in the node's mempool.
+	// ErrMempoolTruncated is returned if the mempool has more than mempoolLimit txs.
+	UnconfirmedTxs(ctx context.Context) ([]*UnconfirmedTx, error)
+	// PingContext pings node.
+	PingContext(ctx context.Context) error
+}
+
+// BroadcasterConfig ...
+type BroadcasterConfig struct {
+	KeyringRootDir     string
+	KeyringBackend     string
+	KeyringPromptInput string
+
+	NodeURI string
+
+	From    string
+	ChainID string
+
+	// GasAdjust is multiplier applied to the simulated gas.
+	GasAdjust float64
+}
+
+// broadcaster simulates every tx to estimate gas and pays fee according to the current minimal gas price.
+type broadcaster struct {
+	ctx    client.Context
+	txf    tx.Factory
+	pricer *GasPricer
+
+	mu sync.Mutex
+}
+
+// NewBroadcaster returns new instance of Broadcaster.
+func NewBroadcaster(cfg BroadcasterConfig, pricer *GasPricer) (Broadcaster, error) {
+	kr, err := keyring.New(
+		config.AppName,
+		cfg.KeyringBackend,
+		cfg.KeyringRootDir,
+		strings.NewReader(cfg.KeyringPromptInput),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+
+	acc, err := kr.Key(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	c, err := client.NewClientFromNode(cfg.NodeURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	encodingConfig := cosmoscmd.MakeEncodingConfig(app.ModuleBasics)
+	ctx := client.Context{}.
+		WithCodec(encodingConfig.Marshaler).
+		WithChainID(cfg.ChainID).
+		WithInterfaceRegistry(encodingConfig.InterfaceRegistry).
+		WithTxConfig(encodingConfig.TxConfig).
+		WithLegacyAmino(encodingConfig.Amino).
+		WithAccountRetriever(authtypes.AccountRetriever{}).
+		WithBroadcastMode(cliflags.BroadcastSync).
+		WithHomeDir(cfg.KeyringRootDir).
+		WithKeyring(kr).
+		WithFrom(acc.GetName()).
+		WithFromName(acc.GetName()).
+		WithFromAddress(acc.GetAddress()).
+		WithNodeURI(cfg.NodeURI).
+		WithClient(c)
+
+	txf := tx.Factory{}.
+		WithTxConfig(ctx.TxConfig).
+		WithAccountRetriever(ctx.AccountRetriever).
+		WithKeybase(kr).
+		WithChainID(cfg.ChainID).
+		WithGasAdjustment(cfg.GasAdjust)
+
+	b := &broadcaster{
+		ctx:    ctx,
+		txf:    txf,
+		pricer: pricer,
+	}
+
+	if err := b.refreshSequence(); err != nil {
+		return nil, fmt.Errorf("failed to refresh sequence: %w", err)
+	}
+
+	return b, nil
+}
+
+// From returns address of broadcaster.
+func (b *broadcaster) From() sdk.AccAddress {
+	return b.ctx.FromAddress
+}
+
+// Broadcast broadcasts messages in one tx.
+func (b *broadcaster) Broadcast(msgs []sdk.Msg, memo string) (*Tx, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out, err := b.broadcast(msgs, memo, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+
+	return out, nil
+}
+
+// UnconfirmedTxs returns txs of the broadcaster which are in the node's mempool.
+func (b *broadcaster) UnconfirmedTxs(ctx context.Context) ([]*UnconfirmedTx, error) {
+	c, err := b.ctx.GetNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rpc client: %w", err)
+	}
+
+	limit := mempoolLimit
+	res, err := c.UnconfirmedTxs(ctx, &limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unconfirmed txs: %w", err)
+	}
+
+	if res.Total > len(res.Txs) {
+		return nil, fmt.Errorf("%w: %d of %d txs are returned", ErrMempoolTruncated, len(res.Txs), res.Total)
+	}
+
+	decode := b.ctx.TxConfig.TxDecoder()
+
+	var out []*UnconfirmedTx
+	for _, raw := range res.Txs {
+		tx, err := decode(raw)
+		if err != nil {
+			// txs of other chains' formats can't be ours
+			continue
+		}
+
+		feeTx, ok := tx.(sdk.FeeTx)
+		if !ok || !feeTx.FeePayer().Equals(b.ctx.FromAddress) {
+			continue
+		}
+
+		utx := &UnconfirmedTx{
+			Tx: Tx{
+				Hash: fmt.Sprintf("%X", tmtypes.Tx(raw).Hash()),
+				Fee:  feeTx.GetFee(),
+				Gas:  feeTx.GetGas(),
+			},
+		}
+		if memoTx, ok := tx.(sdk.TxWithMemo); ok {
+			utx.Memo = memoTx.GetMemo()
+		}
+
+		out = append(out, utx)
+	}
+
+	return out, nil
+}
+
+// PingContext pings node.
+func (b *broadcaster) PingContext(ctx context.Context) error {
+	c, err := b.ctx.GetNode()
+	if err != nil {
+		return fmt.Errorf("failed to get rpc client: %w", err)
+	}
+	if _, err := c.ABCIInfo(ctx); err != nil {
+		return fmt.Errorf("failed to check node status: %w", err)
+	}
+
+	return nil
+}
+
+func (b *broadcaster) broadcast(msgs []sdk.Msg, memo string, isRetry bool) (*Tx, error) {
+	txf := b.txf.WithMemo(memo)
+
+	_, gas, err := tx.CalculateGas(b.ctx, txf, msgs...)
+	if err != nil {
+		if seq := getNextSequence(err.Error()); seq != 0 && !isRetry {
+			b.txf = b.txf.WithSequence(seq)
+			return b.broadcast(msgs, memo, true)
+		}
+
+		// the node responds with status error if simulation is failed, otherwise the node is unavailable
+		if _, ok := status.FromError(err); ok {
+			return nil, fmt.Errorf("%w: failed to simulate tx: %s", ErrTxRejected, err)
+		}
+		return nil, fmt.Errorf("failed to simulate tx: %w", err)
+	}
+
+	fee := b.pricer.Fee(gas)
+	txf = txf.WithGas(gas).WithFees(fee.String())
+
+	unsignedTx, err := tx.BuildUnsignedTx(txf, msgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tx: %w", err)
+	}
+
+	if err := tx.Sign(txf, b.ctx.GetFromName(), unsignedTx, true); err != nil {
+		return nil, fmt.Errorf("failed to sign tx: %w", err)
+	}
+
+	txBytes, err := b.ctx.TxConfig.TxEncoder()(unsignedTx.GetTx())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tx: %w", err)
+	}
+
+	resp, err := b.ctx.BroadcastTx(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast tx: %w", err)
+	}
+
+	if resp.Code != 0 {
+		if sdkerrors.ErrTxInMempoolCache.ABCICode() == resp.Code {
+			return nil, ErrTxInMempoolCache
+		}
+
+		if seq := getNextSequence(resp.RawLog); seq != 0 && !isRetry {
+			b.txf = b.txf.WithSequence(seq)
+			return b.broadcast(msgs, memo, true)
+		}
+
+		return nil, fmt.Errorf("%w: code %d: %s", ErrTxRejected, resp.Code, resp.RawLog)
+	}
+
+	b.txf = b.txf.WithSequence(b.txf.Sequence() + 1)
+
+	return &Tx{
+		Hash: resp.TxHash,
+		Fee:  fee,
+		Gas:  gas,
+	}, nil
+}
+
+func (b *broadcaster) refreshSequence() error {
+	if err := b.txf.AccountRetriever().EnsureExists(b.ctx, b.From()); err != nil {
+		return fmt.Errorf("failed to ensure account exists: %w", err)
+	}
+
+	num, seq, err := b.txf.AccountRetriever().GetAccountNumberSequence(b.ctx, b.From())
+	if err != nil {
+		return fmt.Errorf("failed to get account number and sequence: %w", err)
+	}
+
+	b.txf = b.txf.WithAccountNumber(num).WithSequence(seq)
+
+	return nil
+}
+
+func getNextSequence(m string) uint64 {
+	s := accountSequenceMismatchErrorRegExp.FindStringSubmatch(m)
+	if len(s) != 2 {
+		return 0
+	}
+
+	seq, _ := strconv.ParseUint(s[1], 10, 64)
+
+	return seq
+}
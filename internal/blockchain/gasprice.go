@@ -0,0 +1,81 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	log "github.com/sirupsen/logrus"
+
+	operationstypes "github.com/Decentr-net/decentr/x/operations/types"
+)
+
+// GasPricer keeps the minimal gas price of the operations module.
+type GasPricer struct {
+	ops operationstypes.QueryClient
+
+	mu    sync.RWMutex
+	price sdk.DecCoin
+}
+
+// NewGasPricer returns new instance of GasPricer. Refresh should be called before the price is used.
+func NewGasPricer(ops operationstypes.QueryClient) *GasPricer {
+	return &GasPricer{
+		ops: ops,
+	}
+}
+
+// Run refreshes the price periodically.
+func (p *GasPricer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.Refresh(ctx); err != nil {
+					log.WithError(err).Error("failed to refresh gas price")
+				}
+			}
+		}
+	}(ticker)
+}
+
+// Refresh fetches the current minimal gas price.
+func (p *GasPricer) Refresh(ctx context.Context) error {
+	resp, err := p.ops.MinGasPrice(ctx, &operationstypes.MinGasPriceRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to get min gas price: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.price.String() != resp.MinGasPrice.String() {
+		log.WithField("price", resp.MinGasPrice).Info("gas price is changed")
+	}
+	p.price = resp.MinGasPrice
+
+	return nil
+}
+
+// GasPrice returns the last fetched minimal gas price.
+func (p *GasPricer) GasPrice() sdk.DecCoin {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.price
+}
+
+// Fee returns fee for the given gas according to the current price.
+func (p *GasPricer) Fee(gas uint64) sdk.Coins {
+	price := p.GasPrice()
+	if price.Denom == "" {
+		return sdk.Coins{}
+	}
+
+	return sdk.NewCoins(sdk.NewCoin(price.Denom, price.Amount.MulInt64(int64(gas)).Ceil().RoundInt()))
+}
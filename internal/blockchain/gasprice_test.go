@@ -0,0 +1,43 @@
+package blockchain
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	operationstypes "github.com/Decentr-net/decentr/x/operations/types"
+)
+
+type fakeOperations struct {
+	operationstypes.QueryClient
+
+	price sdk.DecCoin
+	err   error
+}
+
+func (f *fakeOperations) MinGasPrice(_ context.Context, _ *operationstypes.MinGasPriceRequest, _ ...grpc.CallOption) (*operationstypes.MinGasPriceResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &operationstypes.MinGasPriceResponse{MinGasPrice: f.price}, nil
+}
+
+func TestGasPricer(t *testing.T) {
+	ops := &fakeOperations{price: sdk.NewDecCoinFromDec("udec", sdk.MustNewDecFromStr("0.025"))}
+	p := NewGasPricer(ops)
+
+	assert.Equal(t, sdk.Coins{}, p.Fee(1000))
+
+	require.NoError(t, p.Refresh(context.Background()))
+	assert.Equal(t, ops.price, p.GasPrice())
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("udec", 25)), p.Fee(1000))
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("udec", 26)), p.Fee(1001), "fee should be rounded up")
+
+	ops.err = errTest
+	require.ErrorIs(t, p.Refresh(context.Background()), errTest)
+	assert.Equal(t, sdk.MustNewDecFromStr("0.025"), p.GasPrice().Amount, "the last price should be kept")
+}
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	blockchain "github.com/Decentr-net/vulcan/internal/blockchain"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -34,15 +35,46 @@ func (m *MockBlockchain) EXPECT() *MockBlockchainMockRecorder {
 }
 
 // SendStakes mocks base method
-func (m *MockBlockchain) SendStakes(stakes []blockchain.Stake, memo string) error {
+func (m *MockBlockchain) SendStakes(ctx context.Context, key string, stakes []blockchain.Stake, memo string) (*blockchain.Tx, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendStakes", stakes, memo)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "SendStakes", ctx, key, stakes, memo)
+	ret0, _ := ret[0].(*blockchain.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // SendStakes indicates an expected call of SendStakes
-func (mr *MockBlockchainMockRecorder) SendStakes(stakes, memo interface{}) *gomock.Call {
+func (mr *MockBlockchainMockRecorder) SendStakes(ctx, key, stakes, memo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendStakes", reflect.TypeOf((*MockBlockchain)(nil).SendStakes), stakes, memo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendStakes", reflect.TypeOf((*MockBlockchain)(nil).SendStakes), ctx, key, stakes, memo)
+}
+
+// FindTx mocks base method
+func (m *MockBlockchain) FindTx(ctx context.Context, key, address string) (*blockchain.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTx", ctx, key, address)
+	ret0, _ := ret[0].(*blockchain.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTx indicates an expected call of FindTx
+func (mr *MockBlockchainMockRecorder) FindTx(ctx, key, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTx", reflect.TypeOf((*MockBlockchain)(nil).FindTx), ctx, key, address)
+}
+
+// GetTx mocks base method
+func (m *MockBlockchain) GetTx(ctx context.Context, hash string) (*blockchain.TxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTx", ctx, hash)
+	ret0, _ := ret[0].(*blockchain.TxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTx indicates an expected call of GetTx
+func (mr *MockBlockchainMockRecorder) GetTx(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTx", reflect.TypeOf((*MockBlockchain)(nil).GetTx), ctx, hash)
 }
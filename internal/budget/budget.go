@@ -0,0 +1,92 @@
+// Package budget contains spending caps for payouts.
+package budget
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+// Cap limits amount of payouts of one purpose. Zero limit means unlimited.
+type Cap struct {
+	Daily   sdk.Int
+	Monthly sdk.Int
+}
+
+// Excess is a payout exceeding a cap.
+type Excess struct {
+	Payout *storage.Payout
+	Reason string
+}
+
+// Budget checks payouts against spending caps.
+type Budget struct {
+	caps map[storage.PayoutPurpose]Cap
+}
+
+// New returns new instance of Budget. Purposes without caps are unlimited.
+func New(caps map[storage.PayoutPurpose]Cap) *Budget {
+	return &Budget{
+		caps: caps,
+	}
+}
+
+// Split splits payouts into allowed to be sent and exceeding caps. Manually approved payouts are always allowed.
+// Spending is read from the storage, so it should be locked with LockSpending till allowed payouts are batched.
+func (b *Budget) Split(ctx context.Context, s storage.Storage, payouts []*storage.Payout) ([]*storage.Payout, []Excess, error) {
+	var (
+		allowed  []*storage.Payout
+		excesses []Excess
+		spending = make(map[storage.PayoutPurpose]*storage.Spending)
+	)
+
+	for _, p := range payouts {
+		c, ok := b.caps[p.Purpose]
+		if !ok || (!isLimited(c.Daily) && !isLimited(c.Monthly)) {
+			allowed = append(allowed, p)
+			continue
+		}
+
+		spent, ok := spending[p.Purpose]
+		if !ok {
+			var err error
+			if spent, err = s.GetSpending(ctx, p.Purpose); err != nil {
+				return nil, nil, fmt.Errorf("failed to get %s spending: %w", p.Purpose, err)
+			}
+			spending[p.Purpose] = spent
+		}
+
+		if !p.ApprovedAt.Valid {
+			if reason := check(p, c, spent); reason != "" {
+				excesses = append(excesses, Excess{Payout: p, Reason: reason})
+				continue
+			}
+		}
+
+		// the payout is counted in spending to check the rest of payouts sent in the same tx
+		spent.Day = spent.Day.Add(p.Amount)
+		spent.Month = spent.Month.Add(p.Amount)
+		allowed = append(allowed, p)
+	}
+
+	return allowed, excesses, nil
+}
+
+func check(p *storage.Payout, c Cap, spent *storage.Spending) string {
+	if isLimited(c.Daily) && spent.Day.Add(p.Amount).GT(c.Daily) {
+		return fmt.Sprintf("daily %s cap %s is exceeded: %s is already spent", p.Purpose, c.Daily, spent.Day)
+	}
+
+	if isLimited(c.Monthly) && spent.Month.Add(p.Amount).GT(c.Monthly) {
+		return fmt.Sprintf("monthly %s cap %s is exceeded: %s is already spent", p.Purpose, c.Monthly, spent.Month)
+	}
+
+	return ""
+}
+
+func isLimited(v sdk.Int) bool {
+	return !v.IsNil() && v.IsPositive()
+}
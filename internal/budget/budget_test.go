@@ -0,0 +1,112 @@
+package budget
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+func newPayout(id int64, purpose storage.PayoutPurpose, amount int64) *storage.Payout {
+	return &storage.Payout{ID: id, Purpose: purpose, Amount: sdk.NewInt(amount)}
+}
+
+func TestBudget_Split(t *testing.T) {
+	b := New(map[storage.PayoutPurpose]Cap{
+		storage.RegistrationPayoutPurpose:   {Daily: sdk.NewInt(300), Monthly: sdk.NewInt(1000)},
+		storage.TestnetPayoutPurpose:        {Monthly: sdk.NewInt(100)},
+		storage.ReferralSenderPayoutPurpose: {},
+	})
+
+	approved := newPayout(5, storage.TestnetPayoutPurpose, 500)
+	approved.ApprovedAt = sql.NullTime{Valid: true, Time: time.Now()}
+
+	tt := []struct {
+		name          string
+		payouts       []*storage.Payout
+		mockSetupFunc func(s *storagemock.MockStorage)
+		allowed       []int64
+		exceeded      []int64
+	}{
+		{
+			name: "unlimited",
+			payouts: []*storage.Payout{
+				newPayout(1, storage.ReferralSenderPayoutPurpose, 1e9),
+				newPayout(2, storage.ReferralReceiverPayoutPurpose, 1e9),
+			},
+			mockSetupFunc: func(s *storagemock.MockStorage) {},
+			allowed:       []int64{1, 2},
+		},
+		{
+			name: "daily cap",
+			payouts: []*storage.Payout{
+				newPayout(1, storage.RegistrationPayoutPurpose, 100),
+				newPayout(2, storage.RegistrationPayoutPurpose, 100),
+				newPayout(3, storage.RegistrationPayoutPurpose, 100),
+			},
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetSpending(gomock.Any(), storage.RegistrationPayoutPurpose).Return(&storage.Spending{
+					Day:   sdk.NewInt(100),
+					Month: sdk.NewInt(100),
+				}, nil)
+			},
+			allowed:  []int64{1, 2},
+			exceeded: []int64{3},
+		},
+		{
+			name: "monthly cap",
+			payouts: []*storage.Payout{
+				newPayout(1, storage.RegistrationPayoutPurpose, 100),
+				newPayout(2, storage.TestnetPayoutPurpose, 100),
+				newPayout(3, storage.TestnetPayoutPurpose, 1),
+				approved,
+			},
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetSpending(gomock.Any(), storage.RegistrationPayoutPurpose).Return(&storage.Spending{
+					Day:   sdk.NewInt(0),
+					Month: sdk.NewInt(950),
+				}, nil)
+				s.EXPECT().GetSpending(gomock.Any(), storage.TestnetPayoutPurpose).Return(&storage.Spending{
+					Day:   sdk.NewInt(0),
+					Month: sdk.NewInt(0),
+				}, nil)
+			},
+			allowed:  []int64{2, 5},
+			exceeded: []int64{1, 3},
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			s := storagemock.NewMockStorage(ctrl)
+			tc.mockSetupFunc(s)
+
+			allowed, exceeded, err := b.Split(context.Background(), s, tc.payouts)
+			require.NoError(t, err)
+
+			var allowedIDs, exceededIDs []int64
+			for _, v := range allowed {
+				allowedIDs = append(allowedIDs, v.ID)
+			}
+			for _, v := range exceeded {
+				exceededIDs = append(exceededIDs, v.Payout.ID)
+				assert.NotEmpty(t, v.Reason)
+			}
+
+			assert.Equal(t, tc.allowed, allowedIDs)
+			assert.Equal(t, tc.exceeded, exceededIDs)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package captcha contains verifiers of captcha responses.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen -destination=./mock/captcha.go -package=mock -source=captcha.go
+
+// ErrNotPassed is returned when captcha response isn't accepted.
+var ErrNotPassed = errors.New("captcha is not passed")
+
+// Provider is a captcha service.
+type Provider string
+
+const (
+	// RecaptchaV3 is Google reCAPTCHA v3, its responses are scored and bound to an action.
+	RecaptchaV3 Provider = "recaptcha_v3"
+	// RecaptchaV2 is Google reCAPTCHA v2 checkbox or invisible challenge.
+	RecaptchaV2 Provider = "recaptcha_v2"
+	// HCaptcha is hCaptcha challenge.
+	HCaptcha Provider = "hcaptcha"
+	// Turnstile is Cloudflare Turnstile challenge.
+	Turnstile Provider = "turnstile"
+	// Local accepts only fixed test tokens without calling any external service.
+	Local Provider = "local"
+)
+
+// nolint:gochecknoglobals
+var defaultURLs = map[Provider]string{
+	RecaptchaV3: "https://www.google.com/recaptcha/api/siteverify",
+	RecaptchaV2: "https://www.google.com/recaptcha/api/siteverify",
+	HCaptcha:    "https://hcaptcha.com/siteverify",
+	Turnstile:   "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Verifier verifies captcha responses.
+type Verifier interface {
+	// Verify checks the captcha response passed by the client for the action.
+	// ErrNotPassed is returned if the response isn't accepted.
+	Verify(ctx context.Context, action, response string) error
+}
+
+// Config contains captcha settings.
+type Config struct {
+	Provider Provider
+	// URL is a verify endpoint, provider's one is used if it's empty.
+	URL     string
+	Secret  string
+	Timeout time.Duration
+
+	// MinScore is a minimal score of reCAPTCHA v3 responses for actions missing in MinScores.
+	MinScore  float64
+	MinScores map[string]float64
+	// Hostnames are sites allowed to solve captcha, empty list means any.
+	Hostnames []string
+
+	// TestTokens are responses accepted by Local provider.
+	TestTokens []string
+}
+
+// New returns new instance of Verifier for the configured provider.
+func New(cfg Config) (Verifier, error) {
+	if cfg.Provider == Local {
+		if len(cfg.TestTokens) == 0 {
+			return nil, errors.New("test tokens are required for local captcha")
+		}
+		return newLocal(cfg.TestTokens), nil
+	}
+
+	u := cfg.URL
+	if u == "" {
+		var ok bool
+		if u, ok = defaultURLs[cfg.Provider]; !ok {
+			return nil, fmt.Errorf("unknown captcha provider %q", cfg.Provider)
+		}
+	}
+
+	if cfg.Secret == "" {
+		return nil, errors.New("captcha secret is required")
+	}
+
+	hostnames := make(map[string]struct{}, len(cfg.Hostnames))
+	for _, v := range cfg.Hostnames {
+		hostnames[strings.ToLower(v)] = struct{}{}
+	}
+
+	return &siteVerifier{
+		provider:  cfg.Provider,
+		url:       u,
+		secret:    cfg.Secret,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		minScore:  cfg.MinScore,
+		minScores: cfg.MinScores,
+		hostnames: hostnames,
+	}, nil
+}
+
+// siteVerifier verifies responses using siteverify protocol shared by reCAPTCHA, hCaptcha and Turnstile.
+type siteVerifier struct {
+	provider Provider
+	url      string
+	secret   string
+	client   *http.Client
+
+	minScore  float64
+	minScores map[string]float64
+	hostnames map[string]struct{}
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      float64  `json:"score"`
+	Action     string   `json:"action"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify ...
+func (v *siteVerifier) Verify(ctx context.Context, action, response string) error {
+	if response == "" {
+		return fmt.Errorf("%w: empty response", ErrNotPassed)
+	}
+
+	form := url.Values{}
+	form.Add("secret", v.secret)
+	form.Add("response", response)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to make request: unexpected status %d", resp.StatusCode)
+	}
+
+	var body siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to unmarshal %s response: %w", v.provider, err)
+	}
+
+	return v.check(action, body)
+}
+
+func (v *siteVerifier) check(action string, body siteVerifyResponse) error {
+	if !body.Success {
+		return fmt.Errorf("%w: unsuccessful verify request: %s", ErrNotPassed, strings.Join(body.ErrorCodes, ","))
+	}
+
+	if len(v.hostnames) > 0 {
+		if _, ok := v.hostnames[strings.ToLower(body.Hostname)]; !ok {
+			return fmt.Errorf("%w: hostname %q is not allowed", ErrNotPassed, body.Hostname)
+		}
+	}
+
+	switch v.provider {
+	case RecaptchaV3:
+		if body.Action != action {
+			return fmt.Errorf("%w: mismatched action", ErrNotPassed)
+		}
+
+		minScore, ok := v.minScores[action]
+		if !ok {
+			minScore = v.minScore
+		}
+		if body.Score < minScore {
+			return fmt.Errorf("%w: score %.1f is lower than %.1f", ErrNotPassed, body.Score, minScore)
+		}
+	case Turnstile:
+		// action is returned only if the widget is rendered with it
+		if body.Action != "" && body.Action != action {
+			return fmt.Errorf("%w: mismatched action", ErrNotPassed)
+		}
+	}
+
+	return nil
+}
+
+// local accepts fixed test tokens for any action.
+type local struct {
+	tokens map[string]struct{}
+}
+
+func newLocal(tokens []string) *local {
+	l := &local{
+		tokens: make(map[string]struct{}, len(tokens)),
+	}
+	for _, v := range tokens {
+		l.tokens[v] = struct{}{}
+	}
+
+	return l
+}
+
+// Verify ...
+func (l *local) Verify(_ context.Context, _, response string) error {
+	if _, ok := l.tokens[response]; !ok {
+		return fmt.Errorf("%w: unknown test token", ErrNotPassed)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSiteVerifyServer(t *testing.T, resp siteVerifyResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "token", r.PostForm.Get("response"))
+
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Provider: "unknown", Secret: "secret"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Provider: RecaptchaV3})
+	assert.Error(t, err)
+
+	_, err = New(Config{Provider: Local})
+	assert.Error(t, err)
+
+	v, err := New(Config{Provider: Turnstile, Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultURLs[Turnstile], v.(*siteVerifier).url)
+}
+
+func TestSiteVerifier_Verify(t *testing.T) {
+	tt := []struct {
+		name     string
+		provider Provider
+		resp     siteVerifyResponse
+		passed   bool
+	}{
+		{
+			name:     "recaptcha v3",
+			provider: RecaptchaV3,
+			resp:     siteVerifyResponse{Success: true, Score: 0.9, Action: "register", Hostname: "decentr.xyz"},
+			passed:   true,
+		},
+		{
+			name:     "recaptcha v3 action min score",
+			provider: RecaptchaV3,
+			resp:     siteVerifyResponse{Success: true, Score: 0.6, Action: "confirm", Hostname: "decentr.xyz"},
+			passed:   true,
+		},
+		{
+			name:     "recaptcha v3 low score",
+			provider: RecaptchaV3,
+			resp:     siteVerifyResponse{Success: true, Score: 0.7, Action: "register", Hostname: "decentr.xyz"},
+		},
+		{
+			name:     "recaptcha v3 mismatched action",
+			provider: RecaptchaV3,
+			resp:     siteVerifyResponse{Success: true, Score: 0.9, Action: "login", Hostname: "decentr.xyz"},
+		},
+		{
+			name:     "recaptcha v2",
+			provider: RecaptchaV2,
+			resp:     siteVerifyResponse{Success: true, Hostname: "DECENTR.xyz"},
+			passed:   true,
+		},
+		{
+			name:     "unsuccessful",
+			provider: RecaptchaV2,
+			resp:     siteVerifyResponse{ErrorCodes: []string{"invalid-input-response"}},
+		},
+		{
+			name:     "hcaptcha hostname",
+			provider: HCaptcha,
+			resp:     siteVerifyResponse{Success: true, Hostname: "evil.xyz"},
+		},
+		{
+			name:     "turnstile without action",
+			provider: Turnstile,
+			resp:     siteVerifyResponse{Success: true, Hostname: "decentr.xyz"},
+			passed:   true,
+		},
+		{
+			name:     "turnstile mismatched action",
+			provider: Turnstile,
+			resp:     siteVerifyResponse{Success: true, Action: "login", Hostname: "decentr.xyz"},
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newSiteVerifyServer(t, tc.resp)
+			defer srv.Close()
+
+			v, err := New(Config{
+				Provider:  tc.provider,
+				URL:       srv.URL,
+				Secret:    "secret",
+				MinScore:  0.8,
+				MinScores: map[string]float64{"confirm": 0.5},
+				Hostnames: []string{"decentr.xyz"},
+			})
+			require.NoError(t, err)
+
+			action := "register"
+			if tc.resp.Action == "confirm" {
+				action = "confirm"
+			}
+
+			err = v.Verify(context.Background(), action, "token")
+			if tc.passed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrNotPassed)
+			}
+		})
+	}
+}
+
+func TestSiteVerifier_Verify_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	v, err := New(Config{Provider: RecaptchaV2, URL: srv.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	err = v.Verify(context.Background(), "register", "token")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotPassed)
+
+	assert.ErrorIs(t, v.Verify(context.Background(), "register", ""), ErrNotPassed)
+}
+
+func TestLocal_Verify(t *testing.T) {
+	v, err := New(Config{Provider: Local, TestTokens: []string{"pass"}})
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Verify(context.Background(), "register", "pass"))
+	assert.ErrorIs(t, v.Verify(context.Background(), "register", "fail"), ErrNotPassed)
+}
@@ -0,0 +1,100 @@
+package captcha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tracker counts recent signals, e.g. requests or failures, by keys like IP, email owner or email domain.
+// A key becomes suspicious when it got threshold signals within the window.
+// Signals are kept in memory, so every replica counts them separately.
+type Tracker struct {
+	window    time.Duration
+	threshold int
+
+	mu      sync.Mutex
+	signals map[string][]time.Time
+}
+
+// NewTracker returns new instance of Tracker.
+func NewTracker(window time.Duration, threshold int) *Tracker {
+	return &Tracker{
+		window:    window,
+		threshold: threshold,
+		signals:   make(map[string][]time.Time),
+	}
+}
+
+// Run removes expired signals periodically.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.cleanup(time.Now())
+			}
+		}
+	}(ticker)
+}
+
+// Add adds a signal for every key.
+func (t *Tracker) Add(keys ...string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, k := range keys {
+		s := append(t.actual(k, now), now)
+		// only the last threshold signals matter, so memory is bounded per key
+		if len(s) > t.threshold {
+			s = s[len(s)-t.threshold:]
+		}
+		t.signals[k] = s
+	}
+}
+
+// Suspicious returns true if any key got threshold signals within the window.
+func (t *Tracker) Suspicious(keys ...string) bool {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, k := range keys {
+		if len(t.actual(k, now)) >= t.threshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// actual returns signals of the key got within the window. It should be called under the lock.
+func (t *Tracker) actual(key string, now time.Time) []time.Time {
+	s := t.signals[key]
+
+	i := 0
+	for i < len(s) && now.Sub(s[i]) >= t.window {
+		i++
+	}
+
+	return s[i:]
+}
+
+func (t *Tracker) cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k := range t.signals {
+		if s := t.actual(k, now); len(s) == 0 {
+			delete(t.signals, k)
+		} else {
+			t.signals[k] = s
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package captcha
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker(time.Hour, 3)
+
+	tr.Add("ip:1", "domain:decentr.xyz")
+	tr.Add("ip:1")
+	assert.False(t, tr.Suspicious("ip:1", "domain:decentr.xyz"))
+
+	tr.Add("ip:2", "domain:decentr.xyz")
+	tr.Add("ip:3", "domain:decentr.xyz")
+	assert.True(t, tr.Suspicious("ip:4", "domain:decentr.xyz"))
+	assert.False(t, tr.Suspicious("ip:1"))
+
+	tr.Add("ip:1", "ip:1")
+	assert.True(t, tr.Suspicious("ip:1"))
+	assert.Len(t, tr.signals["ip:1"], 3)
+
+	tr.cleanup(time.Now().Add(time.Hour))
+	assert.Empty(t, tr.signals)
+}
+
+func TestTracker_Window(t *testing.T) {
+	tr := NewTracker(time.Hour, 2)
+
+	tr.signals["ip:1"] = []time.Time{time.Now().Add(-2 * time.Hour)}
+	tr.Add("ip:1")
+	assert.False(t, tr.Suspicious("ip:1"))
+	assert.Len(t, tr.signals["ip:1"], 1)
+
+	tr.cleanup(time.Now())
+	assert.Len(t, tr.signals, 1)
+}
@@ -0,0 +1,284 @@
+// Package envelope contains envelope encryption of sensitive values stored in postgres.
+// Every value is encrypted with its own data key, the data key is encrypted (wrapped) with a master key.
+package envelope
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	prefix  = "enc:"
+	keySize = 32
+)
+
+// ErrUnknownKey is returned when a value is encrypted with a key which isn't in the keyring.
+var ErrUnknownKey = fmt.Errorf("unknown key")
+
+// ErrMalformed is returned when an encrypted value can't be parsed.
+var ErrMalformed = fmt.Errorf("malformed encrypted value")
+
+// Key is a master key data keys are wrapped with.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// ParseKey parses key in id:base64(secret) format, the secret is 32 bytes long.
+func ParseKey(s string) (Key, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Key{}, errors.New("id:secret format is expected")
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to decode secret of key %s: %w", parts[0], err)
+	}
+
+	return Key{ID: parts[0], Secret: secret}, nil
+}
+
+// LoadKeys reads keys from r, one key per line. Empty lines and lines starting with # are skipped.
+func LoadKeys(r io.Reader) ([]Key, error) {
+	var keys []Key
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		k, err := ParseKey(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// OpenKeyring returns keyring of keys in id:base64(secret) format or of keys read from the file,
+// the sources can't be used together.
+func OpenKeyring(keys []string, file string) (*Keyring, error) {
+	parsed := make([]Key, 0, len(keys))
+	switch {
+	case file != "" && len(keys) > 0:
+		return nil, errors.New("keys and keys file can't be used together")
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open keys file: %w", err)
+		}
+		defer f.Close() // nolint:errcheck
+
+		if parsed, err = LoadKeys(f); err != nil {
+			return nil, err
+		}
+	default:
+		for _, v := range keys {
+			k, err := ParseKey(v)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, k)
+		}
+	}
+
+	return NewKeyring(parsed)
+}
+
+// Keyring encrypts values with the current key and decrypts values encrypted with any of its keys.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring returns new instance of Keyring. The first key is current, the others are previous ones.
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	k := &Keyring{
+		current: keys[0].ID,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for _, v := range keys {
+		if v.ID == "" || strings.Contains(v.ID, ":") {
+			return nil, fmt.Errorf("invalid key id %q", v.ID)
+		}
+		if _, ok := k.keys[v.ID]; ok {
+			return nil, fmt.Errorf("key id %s is duplicated", v.ID)
+		}
+		if len(v.Secret) != keySize {
+			return nil, fmt.Errorf("secret of key %s should be %d bytes long", v.ID, keySize)
+		}
+
+		aead, err := newAEAD(v.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher of key %s: %w", v.ID, err)
+		}
+		k.keys[v.ID] = aead
+	}
+
+	return k, nil
+}
+
+// IsEncrypted returns true if s is encrypted value.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// Encrypt encrypts s with a new data key wrapped with the current key.
+func (k *Keyring) Encrypt(s string) (string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := seal(aead, []byte(s))
+	if err != nil {
+		return "", err
+	}
+
+	return k.wrap(dek, payload)
+}
+
+// Decrypt decrypts s. Values stored before the encryption was enabled are returned as is.
+func (k *Keyring) Decrypt(s string) (string, error) {
+	if !IsEncrypted(s) {
+		return s, nil
+	}
+
+	_, dek, payload, err := k.unwrap(s)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := open(aead, payload)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Rewrap wraps data key of s with the current key, the value itself isn't re-encrypted.
+// Not encrypted values are encrypted. It returns false if s is already wrapped with the current key.
+func (k *Keyring) Rewrap(s string) (string, bool, error) {
+	if !IsEncrypted(s) {
+		v, err := k.Encrypt(s)
+		return v, err == nil, err
+	}
+
+	id, dek, payload, err := k.unwrap(s)
+	if err != nil {
+		return "", false, err
+	}
+
+	if id == k.current {
+		return s, false, nil
+	}
+
+	v, err := k.wrap(dek, payload)
+	return v, err == nil, err
+}
+
+// wrap returns enc:<key id>:<wrapped data key>:<payload>.
+func (k *Keyring) wrap(dek, payload []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.current], dek)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + k.current + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(payload), nil
+}
+
+func (k *Keyring) unwrap(s string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(s, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	aead, ok := k.keys[parts[0]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	payload, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	dek, err := open(aead, wrapped)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return parts[0], dek, payload, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(b)
+}
+
+// seal returns nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, b []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, b, nil), nil
+}
+
+func open(aead cipher.AEAD, b []byte) ([]byte, error) {
+	if len(b) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	res, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return res, nil
+}
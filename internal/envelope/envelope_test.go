@@ -0,0 +1,142 @@
+package envelope
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nolint:gochecknoglobals
+var (
+	testKey1 = Key{ID: "k1", Secret: bytes.Repeat([]byte{1}, 32)}
+	testKey2 = Key{ID: "k2", Secret: bytes.Repeat([]byte{2}, 32)}
+)
+
+func newTestKeyring(t *testing.T, keys ...Key) *Keyring {
+	k, err := NewKeyring(keys)
+	require.NoError(t, err)
+
+	return k
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	k := newTestKeyring(t, testKey1)
+
+	v1, err := k.Encrypt("decentr@decentr.xyz")
+	require.NoError(t, err)
+	v2, err := k.Encrypt("decentr@decentr.xyz")
+	require.NoError(t, err)
+
+	assert.True(t, IsEncrypted(v1))
+	assert.True(t, strings.HasPrefix(v1, "enc:k1:"))
+	assert.NotContains(t, v1, "decentr")
+	assert.NotEqual(t, v1, v2)
+
+	s, err := k.Decrypt(v1)
+	require.NoError(t, err)
+	assert.Equal(t, "decentr@decentr.xyz", s)
+
+	s, err = k.Decrypt("plain@decentr.xyz")
+	require.NoError(t, err)
+	assert.Equal(t, "plain@decentr.xyz", s)
+
+	_, err = newTestKeyring(t, testKey2).Decrypt(v1)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = k.Decrypt("enc:k1:AAAA")
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	_, err = newTestKeyring(t, Key{ID: "k1", Secret: testKey2.Secret}).Decrypt(v1)
+	assert.Error(t, err)
+}
+
+func TestKeyring_Rewrap(t *testing.T) {
+	old := newTestKeyring(t, testKey1)
+	k := newTestKeyring(t, testKey2, testKey1)
+
+	v, err := old.Encrypt("decentr@decentr.xyz")
+	require.NoError(t, err)
+
+	rewrapped, changed, err := k.Rewrap(v)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rewrapped, "enc:k2:"))
+	// payload is kept
+	assert.Equal(t, v[strings.LastIndex(v, ":"):], rewrapped[strings.LastIndex(rewrapped, ":"):])
+
+	s, err := newTestKeyring(t, testKey2).Decrypt(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "decentr@decentr.xyz", s)
+
+	same, changed, err := k.Rewrap(rewrapped)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, rewrapped, same)
+
+	encrypted, changed, err := k.Rewrap("plain@decentr.xyz")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	s, err = k.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "plain@decentr.xyz", s)
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := NewKeyring(nil)
+	assert.Error(t, err)
+	_, err = NewKeyring([]Key{testKey1, testKey1})
+	assert.EqualError(t, err, "key id k1 is duplicated")
+	_, err = NewKeyring([]Key{{ID: "k1", Secret: []byte("short")}})
+	assert.EqualError(t, err, "secret of key k1 should be 32 bytes long")
+	_, err = NewKeyring([]Key{{ID: "k:1", Secret: testKey1.Secret}})
+	assert.Error(t, err)
+}
+
+func TestLoadKeys(t *testing.T) {
+	keys, err := LoadKeys(strings.NewReader(`
+# current
+k2:AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI=
+
+k1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Key{testKey2, testKey1}, keys)
+
+	_, err = LoadKeys(strings.NewReader("k1:not base64"))
+	assert.Error(t, err)
+	_, err = LoadKeys(strings.NewReader("secret"))
+	assert.Error(t, err)
+}
+
+func TestOpenKeyring(t *testing.T) {
+	value, err := newTestKeyring(t, testKey1).Encrypt("decentr@decentr.xyz")
+	require.NoError(t, err)
+
+	k, err := OpenKeyring([]string{"k2:AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI=", "k1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="}, "")
+	require.NoError(t, err)
+	email, err := k.Decrypt(value)
+	require.NoError(t, err)
+	assert.Equal(t, "decentr@decentr.xyz", email)
+
+	file := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(file, []byte("k1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=\n"), 0600))
+	k, err = OpenKeyring(nil, file)
+	require.NoError(t, err)
+	email, err = k.Decrypt(value)
+	require.NoError(t, err)
+	assert.Equal(t, "decentr@decentr.xyz", email)
+
+	_, err = OpenKeyring([]string{"k1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="}, file)
+	assert.EqualError(t, err, "keys and keys file can't be used together")
+	_, err = OpenKeyring(nil, "")
+	assert.Error(t, err)
+	_, err = OpenKeyring([]string{"secret"}, "")
+	assert.Error(t, err)
+	_, err = OpenKeyring(nil, filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
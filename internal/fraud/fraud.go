@@ -0,0 +1,242 @@
+// Package fraud contains rules engine rejecting disposable and fraud email domains.
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+//go:generate mockgen -destination=./mock/fraud.go -package=mock -source=fraud.go
+
+// Verdict is a reason the email is rejected for.
+type Verdict struct {
+	// Rule is nil if the email is rejected by a check without rule, e.g. missing MX records.
+	Rule   *storage.FraudRule
+	Reason string
+}
+
+// Checker checks emails against fraud rules.
+type Checker interface {
+	// Check returns verdict if the email is rejected, otherwise nil.
+	Check(ctx context.Context, email string) (*Verdict, error)
+}
+
+// Resolver looks up MX records. net.Resolver implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// MXConfig contains MX records check settings.
+type MXConfig struct {
+	// Resolver is used for lookups, MX checks are disabled if it's nil.
+	Resolver Resolver
+	// RequireMX rejects domains without MX records.
+	RequireMX bool
+	Timeout   time.Duration
+}
+
+// Engine checks emails against rules loaded from the storage.
+type Engine struct {
+	s  storage.Storage
+	mx MXConfig
+
+	mu    sync.RWMutex
+	rules *ruleSet
+}
+
+type regexRule struct {
+	rule *storage.FraudRule
+	re   *regexp.Regexp
+}
+
+type ruleSet struct {
+	exact    map[string]*storage.FraudRule
+	wildcard map[string]*storage.FraudRule
+	mx       map[string]*storage.FraudRule
+	regex    []regexRule
+}
+
+// NewEngine returns new instance of Engine. Refresh should be called before the engine is used.
+func NewEngine(s storage.Storage, mx MXConfig) *Engine {
+	return &Engine{
+		s:     s,
+		mx:    mx,
+		rules: newRuleSet(nil),
+	}
+}
+
+// Run refreshes rules periodically.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.Refresh(ctx); err != nil {
+					log.WithError(err).Error("failed to refresh fraud rules")
+				}
+			}
+		}
+	}(ticker)
+}
+
+// Refresh loads rules from the storage.
+func (e *Engine) Refresh(ctx context.Context) error {
+	rules, err := e.s.GetFraudRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get fraud rules: %w", err)
+	}
+
+	rs := newRuleSet(rules)
+
+	e.mu.Lock()
+	e.rules = rs
+	e.mu.Unlock()
+
+	return nil
+}
+
+// Check ...
+func (e *Engine) Check(ctx context.Context, email string) (*Verdict, error) {
+	domain := Domain(email)
+	if domain == "" {
+		return nil, fmt.Errorf("invalid email %q", email)
+	}
+
+	e.mu.RLock()
+	rs := e.rules
+	e.mu.RUnlock()
+
+	if v := rs.matchDomain(domain); v != nil {
+		return v, nil
+	}
+
+	if e.mx.Resolver == nil || (!e.mx.RequireMX && len(rs.mx) == 0) {
+		return nil, nil
+	}
+
+	return e.checkMX(ctx, domain, rs)
+}
+
+func (e *Engine) checkMX(ctx context.Context, domain string, rs *ruleSet) (*Verdict, error) {
+	if e.mx.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.mx.Timeout)
+		defer cancel()
+	}
+
+	records, err := e.mx.Resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			// dns failures shouldn't block registrations
+			log.WithError(err).WithField("domain", domain).Warn("failed to lookup mx records")
+			return nil, nil
+		}
+	}
+
+	if len(records) == 0 {
+		if e.mx.RequireMX {
+			return &Verdict{Reason: "domain has no mx records"}, nil
+		}
+		return nil, nil
+	}
+
+	for _, v := range records {
+		if r := matchSuffix(rs.mx, normalize(v.Host)); r != nil {
+			return &Verdict{Rule: r, Reason: fmt.Sprintf("mx host %s matches %s", normalize(v.Host), r.Pattern)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newRuleSet(rules []*storage.FraudRule) *ruleSet {
+	rs := &ruleSet{
+		exact:    make(map[string]*storage.FraudRule),
+		wildcard: make(map[string]*storage.FraudRule),
+		mx:       make(map[string]*storage.FraudRule),
+	}
+
+	for _, v := range rules {
+		switch v.Kind {
+		case storage.ExactFraudRuleKind:
+			rs.exact[normalize(v.Pattern)] = v
+		case storage.WildcardFraudRuleKind:
+			rs.wildcard[normalize(v.Pattern)] = v
+		case storage.MXFraudRuleKind:
+			rs.mx[normalize(v.Pattern)] = v
+		case storage.RegexFraudRuleKind:
+			re, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				log.WithError(err).WithField("id", v.ID).Error("invalid fraud rule regex")
+				continue
+			}
+			rs.regex = append(rs.regex, regexRule{rule: v, re: re})
+		}
+	}
+
+	return rs
+}
+
+func (rs *ruleSet) matchDomain(domain string) *Verdict {
+	if r, ok := rs.exact[domain]; ok {
+		return &Verdict{Rule: r, Reason: fmt.Sprintf("domain is %s", r.Pattern)}
+	}
+
+	// only subdomains are matched by wildcard rules
+	if i := strings.Index(domain, "."); i != -1 {
+		if r := matchSuffix(rs.wildcard, domain[i+1:]); r != nil {
+			return &Verdict{Rule: r, Reason: fmt.Sprintf("domain is subdomain of %s", r.Pattern)}
+		}
+	}
+
+	for _, v := range rs.regex {
+		if v.re.MatchString(domain) {
+			return &Verdict{Rule: v.rule, Reason: fmt.Sprintf("domain matches %s", v.rule.Pattern)}
+		}
+	}
+
+	return nil
+}
+
+// matchSuffix returns rule for the domain or any of its parent domains.
+func matchSuffix(rules map[string]*storage.FraudRule, domain string) *storage.FraudRule {
+	for {
+		if r, ok := rules[domain]; ok {
+			return r
+		}
+
+		i := strings.Index(domain, ".")
+		if i == -1 {
+			return nil
+		}
+		domain = domain[i+1:]
+	}
+}
+
+// Domain returns normalized domain of the email.
+func Domain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i == -1 {
+		return ""
+	}
+
+	return normalize(email[i+1:])
+}
+
+func normalize(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
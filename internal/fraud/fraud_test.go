@@ -0,0 +1,149 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+type fakeResolver map[string][]*net.MX
+
+func (f fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if name == "broken.com" {
+		return nil, errors.New("timeout")
+	}
+
+	v, ok := f[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return v, nil
+}
+
+// nolint:gochecknoglobals
+var testRules = []*storage.FraudRule{
+	{ID: 1, Kind: storage.ExactFraudRuleKind, Pattern: "aircase.tk"},
+	{ID: 2, Kind: storage.WildcardFraudRuleKind, Pattern: "mailinator.com"},
+	{ID: 3, Kind: storage.RegexFraudRuleKind, Pattern: `^temp-\d+\.com$`},
+	{ID: 4, Kind: storage.RegexFraudRuleKind, Pattern: `(`},
+	{ID: 5, Kind: storage.MXFraudRuleKind, Pattern: "mx.disposable.net"},
+}
+
+func TestEngine_Check(t *testing.T) {
+	tt := []struct {
+		name      string
+		email     string
+		requireMX bool
+		rule      int64
+		rejected  bool
+	}{
+		{name: "clean", email: "valid@gmail.com"},
+		{name: "exact", email: "forbidden@AirCase.tk", rule: 1, rejected: true},
+		{name: "suffix isn't exact", email: "valid@notaircase.tk"},
+		{name: "subdomain isn't exact", email: "valid@sub.aircase.tk"},
+		{name: "wildcard", email: "forbidden@a.b.mailinator.com", rule: 2, rejected: true},
+		{name: "wildcard doesn't match domain itself", email: "valid@mailinator.com"},
+		{name: "regex", email: "forbidden@temp-123.com", rule: 3, rejected: true},
+		{name: "mx rule", email: "forbidden@hidden.org", rule: 5, rejected: true},
+		{name: "mx rule subdomain", email: "forbidden@hidden2.org", rule: 5, rejected: true},
+		{name: "no mx", email: "valid@nomx.org"},
+		{name: "no mx required", email: "forbidden@nomx.org", requireMX: true, rejected: true},
+		{name: "dns failure", email: "valid@broken.com", requireMX: true},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			s := storagemock.NewMockStorage(ctrl)
+			s.EXPECT().GetFraudRules(gomock.Any()).Return(testRules, nil)
+
+			e := NewEngine(s, MXConfig{
+				Resolver: fakeResolver{
+					"gmail.com":   {{Host: "gmail-smtp-in.l.google.com."}},
+					"hidden.org":  {{Host: "mx.disposable.net."}},
+					"hidden2.org": {{Host: "eu.MX.disposable.net."}},
+				},
+				RequireMX: tc.requireMX,
+			})
+			require.NoError(t, e.Refresh(context.Background()))
+
+			v, err := e.Check(context.Background(), tc.email)
+			require.NoError(t, err)
+
+			if !tc.rejected {
+				assert.Nil(t, v)
+				return
+			}
+
+			require.NotNil(t, v)
+			assert.NotEmpty(t, v.Reason)
+			if tc.rule == 0 {
+				assert.Nil(t, v.Rule)
+			} else {
+				require.NotNil(t, v.Rule)
+				assert.Equal(t, tc.rule, v.Rule.ID)
+			}
+		})
+	}
+}
+
+func TestEngine_Check_WithoutMX(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := storagemock.NewMockStorage(ctrl)
+	s.EXPECT().GetFraudRules(gomock.Any()).Return(testRules, nil)
+
+	e := NewEngine(s, MXConfig{})
+	require.NoError(t, e.Refresh(context.Background()))
+
+	v, err := e.Check(context.Background(), "valid@hidden.org")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = e.Check(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+func TestParseList(t *testing.T) {
+	rules, err := ParseList(strings.NewReader(`
+# disposable domains
+Mailinator.com
+*.aircase.tk
+
+temp-mail.org
+`), "list.txt", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*storage.FraudRule{
+		{Kind: storage.ExactFraudRuleKind, Pattern: "mailinator.com", Source: "list.txt"},
+		{Kind: storage.WildcardFraudRuleKind, Pattern: "mailinator.com", Source: "list.txt"},
+		{Kind: storage.WildcardFraudRuleKind, Pattern: "aircase.tk", Source: "list.txt"},
+		{Kind: storage.ExactFraudRuleKind, Pattern: "temp-mail.org", Source: "list.txt"},
+		{Kind: storage.WildcardFraudRuleKind, Pattern: "temp-mail.org", Source: "list.txt"},
+	}, rules)
+
+	_, err = ParseList(strings.NewReader("valid.com\ninvalid domain.com"), "list.txt", false)
+	assert.EqualError(t, err, `line 2: invalid domain "invalid domain.com"`)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&storage.FraudRule{Kind: storage.RegexFraudRuleKind, Pattern: `^a\.com$`}))
+	assert.Error(t, Validate(&storage.FraudRule{Kind: storage.RegexFraudRuleKind, Pattern: `(`}))
+	assert.Error(t, Validate(&storage.FraudRule{Kind: "unknown", Pattern: "a.com"}))
+	assert.Error(t, Validate(&storage.FraudRule{Kind: storage.ExactFraudRuleKind, Pattern: "*.a.com"}))
+
+	r := &storage.FraudRule{Kind: storage.MXFraudRuleKind, Pattern: "MX.a.com."}
+	require.NoError(t, Validate(r))
+	assert.Equal(t, "mx.a.com", r.Pattern)
+}
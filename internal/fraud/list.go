@@ -0,0 +1,77 @@
+package fraud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+// nolint:gochecknoglobals
+var domainRegExp = regexp.MustCompile(`^([a-z0-9_]([a-z0-9_-]*[a-z0-9_])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// Validate checks the rule pattern and normalizes domain patterns.
+func Validate(r *storage.FraudRule) error {
+	switch r.Kind {
+	case storage.ExactFraudRuleKind, storage.WildcardFraudRuleKind, storage.MXFraudRuleKind:
+		r.Pattern = normalize(r.Pattern)
+		if !domainRegExp.MatchString(r.Pattern) {
+			return fmt.Errorf("invalid domain %q", r.Pattern)
+		}
+	case storage.RegexFraudRuleKind:
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", r.Pattern, err)
+		}
+	default:
+		return fmt.Errorf("unknown rule kind %q", r.Kind)
+	}
+
+	return nil
+}
+
+// ParseList parses a disposable domains list: one domain per line, lines starting with # are comments.
+// A domain is imported as exact rule, *.domain is imported as wildcard rule.
+// If subdomains is true, every exact domain is followed by wildcard rule for its subdomains.
+func ParseList(r io.Reader, source string, subdomains bool) ([]*storage.FraudRule, error) {
+	var (
+		rules   []*storage.FraudRule
+		scanner = bufio.NewScanner(r)
+		line    = 0
+	)
+
+	for scanner.Scan() {
+		line++
+
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+
+		rule := &storage.FraudRule{Kind: storage.ExactFraudRuleKind, Pattern: s, Source: source}
+		if strings.HasPrefix(s, "*.") {
+			rule.Kind, rule.Pattern = storage.WildcardFraudRuleKind, s[2:]
+		}
+
+		if err := Validate(rule); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules = append(rules, rule)
+
+		if subdomains && rule.Kind == storage.ExactFraudRuleKind {
+			rules = append(rules, &storage.FraudRule{
+				Kind:    storage.WildcardFraudRuleKind,
+				Pattern: rule.Pattern,
+				Source:  source,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list: %w", err)
+	}
+
+	return rules, nil
+}
@@ -4,8 +4,10 @@ package health
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -24,6 +26,10 @@ func GetVersion() string {
 	return fmt.Sprintf("%s-%s", version, commit)
 }
 
+// ErrDegraded is returned by pinger when the subject works but needs attention.
+// Degraded subjects are listed in the response, but they don't fail the health check.
+var ErrDegraded = errors.New("degraded")
+
 // VersionResponse ...
 type VersionResponse struct {
 	Version string `json:"version"`
@@ -61,13 +67,27 @@ func SubjectPinger(s string, f func(ctx context.Context) error) Pinger {
 // SetupRouter setups all pingers to /health.
 func SetupRouter(r chi.Router, p ...Pinger) {
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		ctx, _ := context.WithTimeout(r.Context(), time.Second*5) // nolint:govet
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+		defer cancel()
+
 		gr, ctx := errgroup.WithContext(ctx)
 
+		var (
+			mu       sync.Mutex
+			degraded []string
+		)
+
 		for i := range p {
 			v := p[i]
 			gr.Go(func() error {
 				if err := v.Ping(ctx); err != nil {
+					if errors.Is(err, ErrDegraded) {
+						mu.Lock()
+						degraded = append(degraded, err.Error())
+						mu.Unlock()
+						return nil
+					}
+
 					logrus.WithError(err).Error("health check failed")
 					return err
 				}
@@ -89,7 +109,13 @@ func SetupRouter(r chi.Router, p ...Pinger) {
 			return
 		}
 
-		data, _ := json.Marshal(VersionResponse{Version: version, Commit: commit})
+		data, _ := json.Marshal(struct {
+			VersionResponse
+			Degraded []string `json:"degraded,omitempty"`
+		}{
+			VersionResponse: VersionResponse{Version: version, Commit: commit},
+			Degraded:        degraded,
+		})
 		w.Write(data) // nolint
 	})
 }
@@ -0,0 +1,90 @@
+// Package file is implementation of transport interface writing emails to maildir as .eml files.
+// It's intended for development and tests.
+package file
+
+import (
+	"context"
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Decentr-net/vulcan/internal/mail"
+)
+
+// Config ...
+type Config struct {
+	Dir string
+
+	VerificationSubject string
+	WelcomeSubject      string
+
+	FromName  string
+	FromEmail string
+}
+
+type sender struct {
+	config *Config
+	seq    uint64
+}
+
+// New returns new instance of file transport. Maildir tmp and new subdirectories are created if they don't exist.
+func New(config *Config) (mail.Transport, error) {
+	for _, v := range []string{"tmp", "new"} {
+		if err := os.MkdirAll(filepath.Join(config.Dir, v), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	return &sender{
+		config: config,
+	}, nil
+}
+
+// SendVerificationEmail writes an email with confirmation code to maildir.
+func (s *sender) SendVerificationEmail(_ context.Context, email, code, link string) error {
+	body, err := mail.RenderVerificationEmail(s.config.VerificationSubject, code, link)
+	if err != nil {
+		return err
+	}
+
+	return s.write(s.config.VerificationSubject, email, body)
+}
+
+// SendWelcomeEmail writes a welcome email to maildir.
+func (s *sender) SendWelcomeEmail(_ context.Context, email string) error {
+	body, err := mail.RenderWelcomeEmail(s.config.WelcomeSubject)
+	if err != nil {
+		return err
+	}
+
+	return s.write(s.config.WelcomeSubject, email, body)
+}
+
+// write puts the email to tmp subdirectory and moves it to new one, so readers never see partially written emails.
+func (s *sender) write(subj, to string, body mail.Body) error {
+	msg, err := (&mail.Message{
+		From:    netmail.Address{Name: s.config.FromName, Address: s.config.FromEmail},
+		To:      to,
+		Subject: subj,
+		Body:    body,
+	}).Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	name := fmt.Sprintf("%d.%d_%d.eml", time.Now().UnixNano(), os.Getpid(), atomic.AddUint64(&s.seq, 1))
+	tmp := filepath.Join(s.config.Dir, "tmp", name)
+
+	if err := os.WriteFile(tmp, msg, 0600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(s.config.Dir, "new", name)); err != nil {
+		return fmt.Errorf("failed to move email: %w", err)
+	}
+
+	return nil
+}
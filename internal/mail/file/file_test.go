@@ -0,0 +1,58 @@
+package file
+
+import (
+	"context"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEmails(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "new", "*.eml"))
+	require.NoError(t, err)
+
+	res := make([]string, len(files))
+	for i, v := range files {
+		b, err := os.ReadFile(v)
+		require.NoError(t, err)
+		res[i] = string(b)
+	}
+
+	return res
+}
+
+func TestSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "maildir")
+
+	s, err := New(&Config{
+		Dir:                 dir,
+		VerificationSubject: "Verification",
+		WelcomeSubject:      "Welcome",
+		FromName:            "Decentr",
+		FromEmail:           "no-reply@decentr.xyz",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.SendVerificationEmail(context.Background(), "decentr@decentr.xyz", "1234", "https://decentr.xyz/link"))
+
+	emails := readEmails(t, dir)
+	require.Len(t, emails, 1)
+	msg, err := netmail.ReadMessage(strings.NewReader(emails[0]))
+	require.NoError(t, err)
+	assert.Equal(t, `"Decentr" <no-reply@decentr.xyz>`, msg.Header.Get("From"))
+	assert.Equal(t, "<decentr@decentr.xyz>", msg.Header.Get("To"))
+	assert.Equal(t, "Verification", msg.Header.Get("Subject"))
+	assert.True(t, strings.Contains(emails[0], "https://decentr.xyz/link"))
+
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), "decentr@decentr.xyz"))
+	assert.Len(t, readEmails(t, dir), 2)
+
+	tmp, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+}
@@ -0,0 +1,36 @@
+// Package logger is implementation of transport interface which only logs emails.
+// Confirmation codes are logged as is, so it's intended for development only.
+package logger
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/mail"
+)
+
+type sender struct{}
+
+// New returns new instance of log transport.
+func New() mail.Transport {
+	return sender{}
+}
+
+// SendVerificationEmail logs the confirmation code.
+func (sender) SendVerificationEmail(_ context.Context, email, code, link string) error {
+	log.WithFields(log.Fields{
+		"email": email,
+		"code":  code,
+		"link":  link,
+	}).Info("verification email")
+
+	return nil
+}
+
+// SendWelcomeEmail logs the welcome email.
+func (sender) SendWelcomeEmail(_ context.Context, email string) error {
+	log.WithField("email", email).Info("welcome email")
+
+	return nil
+}
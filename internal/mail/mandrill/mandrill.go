@@ -3,9 +3,7 @@ package mandrill
 
 import (
 	"context"
-	"errors"
-
-	log "github.com/sirupsen/logrus"
+	"fmt"
 
 	"github.com/Decentr-net/vulcan/internal/mail"
 
@@ -31,8 +29,8 @@ type Config struct {
 	FromEmail string
 }
 
-// New returns new instance of mandrill sender.
-func New(client *mandrill.Client, config *Config) mail.Sender {
+// New returns new instance of mandrill transport.
+func New(client *mandrill.Client, config *Config) mail.Transport {
 	s := &sender{
 		client: client,
 		config: config,
@@ -40,68 +38,45 @@ func New(client *mandrill.Client, config *Config) mail.Sender {
 	return s
 }
 
-// SendVerificationEmailAsync sends an email to account owner.
-func (s *sender) SendVerificationEmailAsync(_ context.Context, email, code string) {
+// SendVerificationEmail sends an email with confirmation code to account owner.
+func (s *sender) SendVerificationEmail(_ context.Context, email, code, link string) error {
 	message := mandrill.Message{
 		Subject:   s.config.VerificationSubject,
 		FromEmail: s.config.FromEmail,
 		FromName:  s.config.FromName,
 		GlobalMergeVars: mandrill.ConvertMapToVariables(map[string]interface{}{
 			"CODE": code,
+			"LINK": link,
 		}),
 	}
-
 	message.AddRecipient(email, "", "to")
 
-	go func() {
-		responses, err := s.client.MessagesSendTemplate(&message, s.config.VerificationTemplateName, nil)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"email": email,
-			}).WithError(err).Error("failed to send email")
-		}
-
-		for _, v := range responses {
-			if v.Status != mandrillSentStatus && v.Status != mandrillQueuedStatus {
-				log.WithFields(log.Fields{
-					"email":  email,
-					"reason": v.RejectionReason,
-					"id":     v.Id,
-					"status": v.Status,
-				}).WithError(mail.ErrMailRejected).Error("failed to send email")
-				return
-			}
-		}
-	}()
+	return s.send(&message, s.config.VerificationTemplateName)
 }
 
-// SendWelcomeEmailAsync sends an welcome email in async mode.
-func (s *sender) SendWelcomeEmailAsync(_ context.Context, email string) {
+// SendWelcomeEmail sends a welcome email.
+func (s *sender) SendWelcomeEmail(_ context.Context, email string) error {
 	message := mandrill.Message{
 		Subject:   s.config.WelcomeSubject,
 		FromEmail: s.config.FromEmail,
 		FromName:  s.config.FromName,
 	}
-
 	message.AddRecipient(email, "", "to")
 
-	go func() {
-		responses, err := s.client.MessagesSendTemplate(&message, s.config.WelcomeTemplateName, nil)
-		if err != nil {
-			log.WithError(err).WithField("email", email).Error("failed to send welcome email")
-			return
-		}
+	return s.send(&message, s.config.WelcomeTemplateName)
+}
+
+func (s *sender) send(message *mandrill.Message, template string) error {
+	responses, err := s.client.MessagesSendTemplate(message, template, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
 
-		for _, v := range responses {
-			if v.Status != mandrillSentStatus && v.Status != mandrillQueuedStatus {
-				log.WithError(errors.New(v.RejectionReason)).WithFields(map[string]interface{}{ // nolint: goerr113
-					"email":            email,
-					"id":               v.Id,
-					"status":           v.Status,
-					"rejection_reason": v.RejectionReason,
-				}).Errorf("failed to send welcome email")
-				return
-			}
+	for _, v := range responses {
+		if v.Status != mandrillSentStatus && v.Status != mandrillQueuedStatus {
+			return fmt.Errorf("%w: %s %s", mail.ErrMailRejected, v.Status, v.RejectionReason)
 		}
-	}()
+	}
+
+	return nil
 }
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email with text and html body.
+type Message struct {
+	From    netmail.Address
+	To      string
+	Subject string
+	Body
+}
+
+// Bytes returns RFC 5322 message with multipart/alternative body.
+// Date and Message-ID headers are generated on every call.
+func (m *Message) Bytes() ([]byte, error) {
+	id, err := m.messageID()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	header := []struct{ key, value string }{
+		{"From", m.From.String()},
+		{"To", (&netmail.Address{Address: m.To}).String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", id},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": w.Boundary()})},
+	}
+	for _, v := range header {
+		fmt.Fprintf(&b, "%s: %s\r\n", v.key, v.value)
+	}
+	b.WriteString("\r\n")
+
+	for _, v := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		p, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {v.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create part: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(p)
+		if _, err := qp.Write([]byte(v.content)); err != nil {
+			return nil, fmt.Errorf("failed to write part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write part: %w", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// messageID returns unique id in the sender's domain.
+func (m *Message) messageID() (string, error) {
+	r := make([]byte, 16)
+	if _, err := rand.Read(r); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+
+	domain := "localhost"
+	if i := strings.LastIndex(m.From.Address, "@"); i >= 0 {
+		domain = m.From.Address[i+1:]
+	}
+
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(r), domain), nil
+}
@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_Bytes(t *testing.T) {
+	body, err := RenderVerificationEmail("Подтверждение", "1234", "https://decentr.xyz/link?token=a=b")
+	require.NoError(t, err)
+
+	m := &Message{
+		From:    netmail.Address{Name: "Decentr", Address: "no-reply@decentr.xyz"},
+		To:      "decentr@decentr.xyz",
+		Subject: "Подтверждение",
+		Body:    body,
+	}
+
+	b, err := m.Bytes()
+	require.NoError(t, err)
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	assert.Equal(t, `"Decentr" <no-reply@decentr.xyz>`, msg.Header.Get("From"))
+	assert.Equal(t, "<decentr@decentr.xyz>", msg.Header.Get("To"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+	assert.True(t, strings.HasSuffix(msg.Header.Get("Message-ID"), "@decentr.xyz>"))
+	_, err = msg.Header.Date()
+	assert.NoError(t, err)
+
+	// subject isn't ascii, so it should be encoded
+	assert.NotContains(t, msg.Header.Get("Subject"), "Подтверждение")
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Подтверждение", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	parts := make(map[string]string)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		// quoted-printable is decoded by multipart reader, line breaks are CRLF on the wire
+		content, err := io.ReadAll(p)
+		require.NoError(t, err)
+		parts[p.Header.Get("Content-Type")] = strings.ReplaceAll(string(content), "\r\n", "\n")
+	}
+
+	require.Len(t, parts, 2)
+	assert.Equal(t, body.Text, parts["text/plain; charset=UTF-8"])
+	assert.Equal(t, body.HTML, parts["text/html; charset=UTF-8"])
+	assert.Contains(t, body.Text, "1234")
+	assert.Contains(t, body.Text, "https://decentr.xyz/link?token=a=b")
+	assert.Contains(t, body.HTML, "<b>1234</b>")
+
+	another, err := m.Bytes()
+	require.NoError(t, err)
+	anotherMsg, err := netmail.ReadMessage(bytes.NewReader(another))
+	require.NoError(t, err)
+	assert.NotEqual(t, msg.Header.Get("Message-ID"), anotherMsg.Header.Get("Message-ID"))
+}
+
+func TestRenderWelcomeEmail(t *testing.T) {
+	body, err := RenderWelcomeEmail("Welcome")
+	require.NoError(t, err)
+
+	assert.Contains(t, body.Text, "Your Decentr account has been created successfully!")
+	assert.Contains(t, body.HTML, "<title>Welcome</title>")
+}
@@ -34,25 +34,76 @@ func (m *MockSender) EXPECT() *MockSenderMockRecorder {
 }
 
 // SendVerificationEmailAsync mocks base method
-func (m *MockSender) SendVerificationEmailAsync(ctx context.Context, email, code string) {
+func (m *MockSender) SendVerificationEmailAsync(ctx context.Context, owner, email, code, link string) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SendVerificationEmailAsync", ctx, email, code)
+	m.ctrl.Call(m, "SendVerificationEmailAsync", ctx, owner, email, code, link)
 }
 
 // SendVerificationEmailAsync indicates an expected call of SendVerificationEmailAsync
-func (mr *MockSenderMockRecorder) SendVerificationEmailAsync(ctx, email, code interface{}) *gomock.Call {
+func (mr *MockSenderMockRecorder) SendVerificationEmailAsync(ctx, owner, email, code, link interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmailAsync", reflect.TypeOf((*MockSender)(nil).SendVerificationEmailAsync), ctx, email, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmailAsync", reflect.TypeOf((*MockSender)(nil).SendVerificationEmailAsync), ctx, owner, email, code, link)
 }
 
 // SendWelcomeEmailAsync mocks base method
-func (m *MockSender) SendWelcomeEmailAsync(ctx context.Context, email string) {
+func (m *MockSender) SendWelcomeEmailAsync(ctx context.Context, owner, email string) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SendWelcomeEmailAsync", ctx, email)
+	m.ctrl.Call(m, "SendWelcomeEmailAsync", ctx, owner, email)
 }
 
 // SendWelcomeEmailAsync indicates an expected call of SendWelcomeEmailAsync
-func (mr *MockSenderMockRecorder) SendWelcomeEmailAsync(ctx, email interface{}) *gomock.Call {
+func (mr *MockSenderMockRecorder) SendWelcomeEmailAsync(ctx, owner, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWelcomeEmailAsync", reflect.TypeOf((*MockSender)(nil).SendWelcomeEmailAsync), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWelcomeEmailAsync", reflect.TypeOf((*MockSender)(nil).SendWelcomeEmailAsync), ctx, owner, email)
+}
+
+// MockTransport is a mock of Transport interface
+type MockTransport struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransportMockRecorder
+}
+
+// MockTransportMockRecorder is the mock recorder for MockTransport
+type MockTransportMockRecorder struct {
+	mock *MockTransport
+}
+
+// NewMockTransport creates a new mock instance
+func NewMockTransport(ctrl *gomock.Controller) *MockTransport {
+	mock := &MockTransport{ctrl: ctrl}
+	mock.recorder = &MockTransportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTransport) EXPECT() *MockTransportMockRecorder {
+	return m.recorder
+}
+
+// SendVerificationEmail mocks base method
+func (m *MockTransport) SendVerificationEmail(ctx context.Context, email, code, link string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", ctx, email, code, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail
+func (mr *MockTransportMockRecorder) SendVerificationEmail(ctx, email, code, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockTransport)(nil).SendVerificationEmail), ctx, email, code, link)
+}
+
+// SendWelcomeEmail mocks base method
+func (m *MockTransport) SendWelcomeEmail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendWelcomeEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendWelcomeEmail indicates an expected call of SendWelcomeEmail
+func (mr *MockTransportMockRecorder) SendWelcomeEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWelcomeEmail", reflect.TypeOf((*MockTransport)(nil).SendWelcomeEmail), ctx, email)
 }
@@ -0,0 +1,221 @@
+// Package outbox contains the persistent mail.Sender: emails are stored to postgres and delivered by the worker.
+// Failed deliveries are retried with exponential backoff, so emails aren't lost on provider outages or restarts.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/mail"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+var errNoQueuedEmails = errors.New("no queued emails")
+
+// Config ...
+type Config struct {
+	BatchSize   int
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// Lease is how long claimed emails are hidden from other workers, it should cover sending of a batch.
+	Lease time.Duration
+}
+
+// payload is template params of the email.
+type payload struct {
+	Code string `json:"code,omitempty"`
+	Link string `json:"link,omitempty"`
+}
+
+// Outbox is mail.Sender putting emails to the storage and worker delivering them via transport.
+type Outbox struct {
+	storage   storage.Storage
+	keyring   *envelope.Keyring
+	transport mail.Transport
+
+	config Config
+}
+
+// New creates a new instance of Outbox.
+func New(s storage.Storage, keyring *envelope.Keyring, transport mail.Transport, config Config) *Outbox {
+	return &Outbox{
+		storage:   s,
+		keyring:   keyring,
+		transport: transport,
+
+		config: config,
+	}
+}
+
+// SendVerificationEmailAsync puts the verification email to the outbox.
+func (o *Outbox) SendVerificationEmailAsync(ctx context.Context, owner, email, code, link string) {
+	o.enqueue(ctx, owner, storage.VerificationEmailKind, email, payload{Code: code, Link: link})
+}
+
+// SendWelcomeEmailAsync puts the welcome email to the outbox.
+func (o *Outbox) SendWelcomeEmailAsync(ctx context.Context, owner, email string) {
+	o.enqueue(ctx, owner, storage.WelcomeEmailKind, email, payload{})
+}
+
+func (o *Outbox) enqueue(ctx context.Context, owner string, kind storage.EmailKind, email string, p payload) {
+	logger := log.WithFields(log.Fields{
+		"owner": owner,
+		"kind":  kind,
+	})
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		logger.WithError(err).Error("failed to marshal email payload")
+		return
+	}
+
+	encrypted, err := o.keyring.Encrypt(string(data))
+	if err != nil {
+		logger.WithError(err).Error("failed to encrypt email payload")
+		return
+	}
+
+	if err := o.storage.CreateEmail(ctx, &storage.Email{
+		Owner:     owner,
+		Kind:      kind,
+		Recipient: email,
+		Payload:   encrypted,
+	}); err != nil {
+		logger.WithError(err).Error("failed to put email to outbox")
+		return
+	}
+
+	logger.Debug("email is queued")
+}
+
+// Run runs the worker loop.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	o.do(ctx)
+
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				o.do(ctx)
+			}
+		}
+	}(ticker)
+}
+
+func (o *Outbox) do(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := o.processNext(ctx); err != nil {
+			if !errors.Is(err, errNoQueuedEmails) {
+				log.WithError(err).Error("failed to process outbox")
+			}
+			return
+		}
+	}
+}
+
+// processNext delivers the next batch of queued emails. Emails are claimed for the lease till their statuses are updated,
+// so concurrent workers don't send the same email while no transaction is kept open during sending.
+func (o *Outbox) processNext(ctx context.Context) error {
+	emails, err := o.storage.ClaimQueuedEmails(ctx, o.config.BatchSize, o.config.Lease)
+	if err != nil {
+		return fmt.Errorf("failed to claim queued emails: %w", err)
+	}
+
+	if len(emails) == 0 {
+		return errNoQueuedEmails
+	}
+
+	for _, e := range emails {
+		if err := o.process(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Outbox) process(ctx context.Context, e *storage.Email) error {
+	logger := log.WithFields(log.Fields{
+		"id":       e.ID,
+		"owner":    e.Owner,
+		"kind":     e.Kind,
+		"attempts": e.Attempts + 1,
+	})
+
+	err := o.send(ctx, e)
+
+	switch {
+	case err == nil:
+		if err := o.storage.SetEmailSent(ctx, e.ID); err != nil {
+			return fmt.Errorf("failed to mark email sent: %w", err)
+		}
+		logger.Info("email is sent")
+	case errors.Is(err, mail.ErrMailRejected):
+		if err := o.storage.SetEmailFailed(ctx, e.ID, storage.RejectedEmailStatus, err.Error()); err != nil {
+			return fmt.Errorf("failed to mark email rejected: %w", err)
+		}
+		logger.WithError(err).Warn("email is rejected")
+	case e.Attempts+1 >= o.config.MaxAttempts:
+		if err := o.storage.SetEmailFailed(ctx, e.ID, storage.FailedEmailStatus, err.Error()); err != nil {
+			return fmt.Errorf("failed to mark email failed: %w", err)
+		}
+		logger.WithError(err).Error("email is failed")
+	default:
+		if err := o.storage.RetryEmail(ctx, e.ID, o.backoff(e.Attempts), err.Error()); err != nil {
+			return fmt.Errorf("failed to retry email: %w", err)
+		}
+		logger.WithError(err).Warn("failed to send email, retrying")
+	}
+
+	return nil
+}
+
+func (o *Outbox) send(ctx context.Context, e *storage.Email) error {
+	email, err := o.keyring.Decrypt(e.Recipient)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decrypt recipient: %s", mail.ErrMailRejected, err)
+	}
+
+	data, err := o.keyring.Decrypt(e.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decrypt payload: %s", mail.ErrMailRejected, err)
+	}
+
+	var p payload
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal payload: %s", mail.ErrMailRejected, err)
+	}
+
+	switch e.Kind {
+	case storage.VerificationEmailKind:
+		return o.transport.SendVerificationEmail(ctx, email, p.Code, p.Link)
+	case storage.WelcomeEmailKind:
+		return o.transport.SendWelcomeEmail(ctx, email)
+	default:
+		return fmt.Errorf("%w: unknown kind %s", mail.ErrMailRejected, e.Kind)
+	}
+}
+
+// backoff returns delay before the next attempt: it's doubled on every attempt and limited by max backoff.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.config.MinBackoff
+	for i := 0; i < attempts && d < o.config.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > o.config.MaxBackoff {
+		return o.config.MaxBackoff
+	}
+
+	return d
+}
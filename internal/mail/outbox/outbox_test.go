@@ -0,0 +1,209 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/mail"
+	mailmock "github.com/Decentr-net/vulcan/internal/mail/mock"
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+var (
+	errTest   = assert.AnError
+	testOwner = "e8b43fe4f4a5e0b3a7e9b0b8a5c2b1d0e8b43fe4"
+	testEmail = "decentr@decentr.xyz"
+	testCode  = "1234"
+	testLink  = "https://vulcan.decentr.xyz/v1/confirm/link?token=token"
+
+	testConfig = Config{
+		BatchSize:   2,
+		MaxAttempts: 3,
+		MinBackoff:  time.Minute,
+		MaxBackoff:  time.Hour,
+		Lease:       time.Minute,
+	}
+)
+
+func newTestKeyring(t *testing.T) *envelope.Keyring {
+	k, err := envelope.NewKeyring([]envelope.Key{{ID: "k1", Secret: bytes.Repeat([]byte{1}, 32)}})
+	require.NoError(t, err)
+
+	return k
+}
+
+func encrypt(t *testing.T, k *envelope.Keyring, s string) string {
+	v, err := k.Encrypt(s)
+	require.NoError(t, err)
+
+	return v
+}
+
+func TestOutbox_SendVerificationEmailAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+	k := newTestKeyring(t)
+	recipient := encrypt(t, k, testEmail)
+
+	st.EXPECT().CreateEmail(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *storage.Email) error {
+		assert.Equal(t, testOwner, e.Owner)
+		assert.Equal(t, storage.VerificationEmailKind, e.Kind)
+		assert.Equal(t, recipient, e.Recipient)
+		assert.NotContains(t, e.Payload, testCode)
+
+		data, err := k.Decrypt(e.Payload)
+		require.NoError(t, err)
+		assert.JSONEq(t, fmt.Sprintf(`{"code":%q,"link":%q}`, testCode, testLink), data)
+
+		return nil
+	})
+
+	New(st, k, nil, testConfig).SendVerificationEmailAsync(context.Background(), testOwner, recipient, testCode, testLink)
+}
+
+func TestOutbox_SendWelcomeEmailAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+
+	st.EXPECT().CreateEmail(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *storage.Email) error {
+		assert.Equal(t, testOwner, e.Owner)
+		assert.Equal(t, storage.WelcomeEmailKind, e.Kind)
+		assert.Equal(t, testEmail, e.Recipient)
+		return errTest
+	})
+
+	New(st, newTestKeyring(t), nil, testConfig).SendWelcomeEmailAsync(context.Background(), testOwner, testEmail)
+}
+
+func TestOutbox_processNext(t *testing.T) {
+	k := newTestKeyring(t)
+
+	verification := func(id int64, attempts int) *storage.Email {
+		return &storage.Email{
+			ID:        id,
+			Owner:     testOwner,
+			Kind:      storage.VerificationEmailKind,
+			Recipient: encrypt(t, k, testEmail),
+			Payload:   encrypt(t, k, fmt.Sprintf(`{"code":%q,"link":%q}`, testCode, testLink)),
+			Attempts:  attempts,
+		}
+	}
+	welcome := &storage.Email{
+		ID:        3,
+		Owner:     testOwner,
+		Kind:      storage.WelcomeEmailKind,
+		Recipient: testEmail,
+		Payload:   encrypt(t, k, "{}"),
+	}
+	rejected := fmt.Errorf("%w: 550 mailbox unavailable", mail.ErrMailRejected)
+
+	tt := []struct {
+		name          string
+		mockSetupFunc func(s *storagemock.MockStorage, tr *mailmock.MockTransport)
+		err           error
+	}{
+		{
+			name: "sent",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{verification(1, 0), welcome}, nil)
+				tr.EXPECT().SendVerificationEmail(gomock.Any(), testEmail, testCode, testLink).Return(nil)
+				s.EXPECT().SetEmailSent(gomock.Any(), int64(1)).Return(nil)
+				tr.EXPECT().SendWelcomeEmail(gomock.Any(), testEmail).Return(nil)
+				s.EXPECT().SetEmailSent(gomock.Any(), int64(3)).Return(nil)
+			},
+		},
+		{
+			name: "retry",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{verification(1, 1)}, nil)
+				tr.EXPECT().SendVerificationEmail(gomock.Any(), testEmail, testCode, testLink).Return(errTest)
+				s.EXPECT().RetryEmail(gomock.Any(), int64(1), 2*time.Minute, errTest.Error()).Return(nil)
+			},
+		},
+		{
+			name: "rejected",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{verification(1, 0)}, nil)
+				tr.EXPECT().SendVerificationEmail(gomock.Any(), testEmail, testCode, testLink).Return(rejected)
+				s.EXPECT().SetEmailFailed(gomock.Any(), int64(1), storage.RejectedEmailStatus, rejected.Error()).Return(nil)
+			},
+		},
+		{
+			name: "max attempts",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{verification(1, 2)}, nil)
+				tr.EXPECT().SendVerificationEmail(gomock.Any(), testEmail, testCode, testLink).Return(errTest)
+				s.EXPECT().SetEmailFailed(gomock.Any(), int64(1), storage.FailedEmailStatus, errTest.Error()).Return(nil)
+			},
+		},
+		{
+			name: "malformed payload",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				e := verification(1, 0)
+				e.Payload = "enc:k2:AAAA"
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{e}, nil)
+				s.EXPECT().SetEmailFailed(gomock.Any(), int64(1), storage.RejectedEmailStatus, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "no emails",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return(nil, nil)
+			},
+			err: errNoQueuedEmails,
+		},
+		{
+			name: "storage error",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return(nil, errTest)
+			},
+			err: errTest,
+		},
+		{
+			name: "update error",
+			mockSetupFunc: func(s *storagemock.MockStorage, tr *mailmock.MockTransport) {
+				s.EXPECT().ClaimQueuedEmails(gomock.Any(), 2, time.Minute).Return([]*storage.Email{welcome}, nil)
+				tr.EXPECT().SendWelcomeEmail(gomock.Any(), testEmail).Return(nil)
+				s.EXPECT().SetEmailSent(gomock.Any(), int64(3)).Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			tr := mailmock.NewMockTransport(ctrl)
+
+			tc.mockSetupFunc(st, tr)
+
+			assert.ErrorIs(t, New(st, k, tr, testConfig).processNext(context.Background()), tc.err)
+		})
+	}
+}
+
+func TestOutbox_backoff(t *testing.T) {
+	o := New(nil, nil, nil, testConfig)
+
+	assert.Equal(t, time.Minute, o.backoff(0))
+	assert.Equal(t, 2*time.Minute, o.backoff(1))
+	assert.Equal(t, 32*time.Minute, o.backoff(5))
+	assert.Equal(t, time.Hour, o.backoff(6))
+	assert.Equal(t, time.Hour, o.backoff(100))
+}
@@ -12,7 +12,17 @@ import (
 var ErrMailRejected = errors.New("email is rejected")
 
 // Sender is interface for sending the emails.
+// Emails are passed encrypted as they're stored, owner is the registration the email belongs to.
 type Sender interface {
-	SendVerificationEmailAsync(ctx context.Context, email, code string)
-	SendWelcomeEmailAsync(ctx context.Context, email string)
+	// SendVerificationEmailAsync sends the confirmation code. The link confirms the request without the code,
+	// it's omitted from the email if it's empty.
+	SendVerificationEmailAsync(ctx context.Context, owner, email, code, link string)
+	SendWelcomeEmailAsync(ctx context.Context, owner, email string)
+}
+
+// Transport delivers emails via mail provider. Emails are passed decrypted.
+// ErrMailRejected is returned when the provider rejects the email, such emails aren't retried.
+type Transport interface {
+	SendVerificationEmail(ctx context.Context, email, code, link string) error
+	SendWelcomeEmail(ctx context.Context, email string) error
 }
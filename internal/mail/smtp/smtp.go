@@ -0,0 +1,327 @@
+// Package smtp is implementation of transport interface sending emails via SMTP server.
+// Connections are kept open and reused till they're idle longer than idle timeout.
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/mail"
+)
+
+// TLSMode defines how the connection to SMTP server is secured.
+type TLSMode string
+
+const (
+	// StartTLSMode upgrades plain connection with STARTTLS command, it fails if server doesn't support it.
+	StartTLSMode TLSMode = "starttls"
+	// ImplicitTLSMode connects with TLS from the start, it's usually served on 465 port.
+	ImplicitTLSMode TLSMode = "tls"
+	// InsecureMode doesn't use TLS at all, it's intended for development SMTP servers only.
+	InsecureMode TLSMode = "insecure"
+)
+
+const (
+	defaultIdleTimeout = time.Minute
+	defaultTimeout     = 30 * time.Second
+)
+
+// Config ...
+type Config struct {
+	Host string
+	Port int
+	// Username and Password are used for PLAIN authentication, it's skipped if username is empty.
+	Username string
+	Password string
+
+	// TLSMode is StartTLSMode if it's empty.
+	TLSMode TLSMode
+	// RootCAs verifies server certificate, system pool is used if it's nil.
+	RootCAs *x509.CertPool
+
+	// PoolSize is maximal count of idle connections, connections aren't reused if it's zero.
+	PoolSize int
+	// IdleTimeout is how long a connection can be idle before it's closed, 1m is used if it's zero.
+	IdleTimeout time.Duration
+	// Timeout limits dialing and every message sending, 30s is used if it's zero.
+	Timeout time.Duration
+
+	VerificationSubject string
+	WelcomeSubject      string
+
+	FromName  string
+	FromEmail string
+}
+
+type conn struct {
+	net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// Sender ...
+type Sender struct {
+	config *Config
+	idle   chan *conn
+}
+
+// New returns new instance of SMTP sender.
+func New(config *Config) *Sender {
+	if config.TLSMode == "" {
+		config.TLSMode = StartTLSMode
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	return &Sender{
+		config: config,
+		idle:   make(chan *conn, config.PoolSize),
+	}
+}
+
+// SendVerificationEmail sends an email with confirmation code to account owner.
+func (s *Sender) SendVerificationEmail(ctx context.Context, email, code, link string) error {
+	body, err := mail.RenderVerificationEmail(s.config.VerificationSubject, code, link)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, s.config.VerificationSubject, email, body)
+}
+
+// SendWelcomeEmail sends a welcome email.
+func (s *Sender) SendWelcomeEmail(ctx context.Context, email string) error {
+	body, err := mail.RenderWelcomeEmail(s.config.WelcomeSubject)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, s.config.WelcomeSubject, email, body)
+}
+
+// Close closes idle connections.
+func (s *Sender) Close() {
+	for {
+		select {
+		case c := <-s.idle:
+			s.quit(c)
+		default:
+			return
+		}
+	}
+}
+
+func (s *Sender) send(ctx context.Context, subj, to string, body mail.Body) error {
+	msg, err := (&mail.Message{
+		From:    netmail.Address{Name: s.config.FromName, Address: s.config.FromEmail},
+		To:      to,
+		Subject: subj,
+		Body:    body,
+	}).Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	c, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		s.quit(c)
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	err = s.transmit(c, to, msg)
+	s.put(c, err)
+
+	if err != nil {
+		// permanent SMTP errors aren't retried
+		var perr *textproto.Error
+		if errors.As(err, &perr) && perr.Code >= 500 {
+			return fmt.Errorf("%w: %s", mail.ErrMailRejected, perr)
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Sender) transmit(c *conn, to string, msg []byte) error {
+	if err := c.client.Mail(s.config.FromEmail); err != nil {
+		return err
+	}
+	if err := c.client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// get returns an idle connection if it's alive or dials a new one.
+func (s *Sender) get(ctx context.Context) (*conn, error) {
+	for {
+		select {
+		case c := <-s.idle:
+			if time.Since(c.lastUsed) > s.config.IdleTimeout {
+				s.quit(c)
+				continue
+			}
+
+			if err := c.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil || c.client.Noop() != nil {
+				c.client.Close() // nolint:errcheck
+				continue
+			}
+
+			return c, nil
+		default:
+			return s.dial(ctx)
+		}
+	}
+}
+
+// put returns the connection to the pool. Connection is closed if the pool is full
+// or the error isn't an SMTP reply, since the connection state is unknown then.
+func (s *Sender) put(c *conn, err error) {
+	var perr *textproto.Error
+	if err != nil && !errors.As(err, &perr) {
+		c.client.Close() // nolint:errcheck
+		return
+	}
+
+	if err != nil {
+		if err := c.client.Reset(); err != nil {
+			c.client.Close() // nolint:errcheck
+			return
+		}
+	}
+
+	c.lastUsed = time.Now()
+
+	select {
+	case s.idle <- c:
+	default:
+		s.quit(c)
+	}
+}
+
+func (s *Sender) quit(c *conn) {
+	if err := c.SetDeadline(time.Now().Add(s.config.Timeout)); err == nil {
+		if err := c.client.Quit(); err == nil {
+			return
+		}
+	}
+
+	c.client.Close() // nolint:errcheck
+}
+
+func (s *Sender) dial(ctx context.Context) (*conn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := &tls.Config{
+		ServerName: s.config.Host,
+		RootCAs:    s.config.RootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	d := &net.Dialer{Timeout: s.config.Timeout}
+
+	var (
+		nc  net.Conn
+		err error
+	)
+	if s.config.TLSMode == ImplicitTLSMode {
+		nc, err = (&tls.Dialer{NetDialer: d, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+
+	if err := nc.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		nc.Close() // nolint:errcheck
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(nc, s.config.Host)
+	if err != nil {
+		nc.Close() // nolint:errcheck
+		return nil, fmt.Errorf("failed to create smtp client: %w", err)
+	}
+
+	if err := s.handshake(client, tlsConfig); err != nil {
+		client.Close() // nolint:errcheck
+		return nil, err
+	}
+
+	log.WithField("addr", addr).Debug("smtp connection is established")
+
+	return &conn{Conn: nc, client: client}, nil
+}
+
+func (s *Sender) handshake(client *smtp.Client, tlsConfig *tls.Config) error {
+	if s.config.TLSMode == StartTLSMode {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server doesn't support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if s.config.Username == "" {
+		return nil
+	}
+
+	var auth smtp.Auth = plainAuth{username: s.config.Username, password: s.config.Password}
+	if s.config.TLSMode != InsecureMode {
+		// smtp.PlainAuth additionally checks the connection is encrypted
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	return nil
+}
+
+// plainAuth is PLAIN authentication allowed over unencrypted connection, it's used in insecure mode only.
+type plainAuth struct {
+	username string
+	password string
+}
+
+func (a plainAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a plainAuth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, fmt.Errorf("unexpected server challenge")
+	}
+	return nil, nil
+}
@@ -0,0 +1,380 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/mail"
+)
+
+const (
+	testUsername = "user"
+	testPassword = "password"
+	testEmail    = "decentr@decentr.xyz"
+)
+
+type testMessage struct {
+	from string
+	to   string
+	tls  bool
+	data []byte
+}
+
+// testServer is a minimal in-process SMTP server.
+type testServer struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+
+	implicitTLS bool
+	startTLS    bool
+	// dropAfterMessage closes the connection after every message without QUIT.
+	dropAfterMessage bool
+
+	mu       sync.Mutex
+	conns    int
+	messages []testMessage
+}
+
+func newTestServer(t *testing.T, cert tls.Certificate, implicitTLS, startTLS bool) *testServer {
+	s := &testServer{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		implicitTLS: implicitTLS,
+		startTLS:    startTLS,
+	}
+
+	var err error
+	if implicitTLS {
+		s.ln, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	require.NoError(t, err)
+	t.Cleanup(func() { s.ln.Close() }) // nolint:errcheck
+
+	go func() {
+		for {
+			c, err := s.ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testServer) stats() (int, []testMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.conns, append([]testMessage(nil), s.messages...)
+}
+
+// nolint:gocyclo
+func (s *testServer) serve(c net.Conn) {
+	defer c.Close() // nolint:errcheck
+
+	s.mu.Lock()
+	s.conns++
+	s.mu.Unlock()
+
+	secure := s.implicitTLS
+	tp := textproto.NewConn(c)
+	reply := func(format string, args ...interface{}) {
+		_ = tp.PrintfLine(format, args...)
+	}
+
+	var (
+		authenticated bool
+		msg           testMessage
+	)
+
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			cmd, arg = line[:i], line[i+1:]
+		}
+
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			ext := []string{"localhost", "AUTH PLAIN"}
+			if s.startTLS && !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			for i, v := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				reply("250%s%s", sep, v)
+			}
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tc := tls.Server(c, s.tlsConfig)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			c, tp, secure = tc, textproto.NewConn(tc), true
+		case "AUTH":
+			if arg != "PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00"+testUsername+"\x00"+testPassword)) {
+				reply("535 authentication failed")
+				continue
+			}
+			authenticated = true
+			reply("235 authenticated")
+		case "MAIL":
+			if !authenticated {
+				reply("530 authentication required")
+				continue
+			}
+			msg = testMessage{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>"), tls: secure}
+			reply("250 ok")
+		case "RCPT":
+			msg.to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if strings.HasPrefix(msg.to, "rejected") {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			if msg.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+
+			reply("250 queued")
+			if s.dropAfterMessage {
+				return
+			}
+		case "RSET":
+			msg = testMessage{}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func newTestCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func newTestSender(port int, mode TLSMode, pool *x509.CertPool) *Sender {
+	return New(&Config{
+		Host:     "127.0.0.1",
+		Port:     port,
+		Username: testUsername,
+		Password: testPassword,
+
+		TLSMode: mode,
+		RootCAs: pool,
+
+		PoolSize:    1,
+		IdleTimeout: time.Minute,
+		Timeout:     5 * time.Second,
+
+		VerificationSubject: "Verification",
+		WelcomeSubject:      "Welcome",
+		FromName:            "Decentr",
+		FromEmail:           "no-reply@decentr.xyz",
+	})
+}
+
+func TestSender(t *testing.T) {
+	cert, pool := newTestCert(t)
+
+	tt := []struct {
+		name        string
+		mode        TLSMode
+		implicitTLS bool
+		startTLS    bool
+		tls         bool
+	}{
+		{
+			name: "insecure",
+			mode: InsecureMode,
+		},
+		{
+			name:     "starttls",
+			mode:     StartTLSMode,
+			startTLS: true,
+			tls:      true,
+		},
+		{
+			name:        "implicit tls",
+			mode:        ImplicitTLSMode,
+			implicitTLS: true,
+			tls:         true,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newTestServer(t, cert, tc.implicitTLS, tc.startTLS)
+			s := newTestSender(srv.port(), tc.mode, pool)
+			defer s.Close()
+
+			require.NoError(t, s.SendVerificationEmail(context.Background(), testEmail, "1234", "https://decentr.xyz/link"))
+			require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+
+			conns, messages := srv.stats()
+			assert.Equal(t, 1, conns, "connection should be reused")
+			require.Len(t, messages, 2)
+
+			for _, v := range messages {
+				assert.Equal(t, "no-reply@decentr.xyz", v.from)
+				assert.Equal(t, testEmail, v.to)
+				assert.Equal(t, tc.tls, v.tls)
+			}
+
+			msg, err := netmail.ReadMessage(bytes.NewReader(messages[0].data))
+			require.NoError(t, err)
+			assert.Equal(t, `"Decentr" <no-reply@decentr.xyz>`, msg.Header.Get("From"))
+			assert.Equal(t, "<"+testEmail+">", msg.Header.Get("To"))
+			assert.Equal(t, "Verification", msg.Header.Get("Subject"))
+			assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+			assert.NotEmpty(t, msg.Header.Get("Date"))
+			assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/alternative; boundary="))
+		})
+	}
+}
+
+func TestSender_Rejected(t *testing.T) {
+	cert, pool := newTestCert(t)
+	srv := newTestServer(t, cert, false, true)
+	s := newTestSender(srv.port(), StartTLSMode, pool)
+	defer s.Close()
+
+	err := s.SendWelcomeEmail(context.Background(), "rejected@decentr.xyz")
+	assert.ErrorIs(t, err, mail.ErrMailRejected)
+
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+
+	conns, messages := srv.stats()
+	assert.Equal(t, 1, conns, "connection should be reused after rejection")
+	require.Len(t, messages, 1)
+	assert.Equal(t, testEmail, messages[0].to)
+}
+
+func TestSender_Reconnect(t *testing.T) {
+	cert, pool := newTestCert(t)
+	srv := newTestServer(t, cert, false, false)
+	srv.dropAfterMessage = true
+
+	s := newTestSender(srv.port(), InsecureMode, pool)
+	defer s.Close()
+
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+
+	conns, messages := srv.stats()
+	assert.Equal(t, 2, conns)
+	assert.Len(t, messages, 2)
+}
+
+func TestSender_IdleTimeout(t *testing.T) {
+	cert, pool := newTestCert(t)
+	srv := newTestServer(t, cert, false, false)
+
+	s := newTestSender(srv.port(), InsecureMode, pool)
+	s.config.IdleTimeout = time.Nanosecond
+	defer s.Close()
+
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, s.SendWelcomeEmail(context.Background(), testEmail))
+
+	conns, _ := srv.stats()
+	assert.Equal(t, 2, conns)
+}
+
+func TestSender_TLSErrors(t *testing.T) {
+	cert, pool := newTestCert(t)
+
+	// server doesn't offer STARTTLS
+	srv := newTestServer(t, cert, false, false)
+	err := newTestSender(srv.port(), StartTLSMode, pool).SendWelcomeEmail(context.Background(), testEmail)
+	assert.EqualError(t, err, "smtp server doesn't support STARTTLS")
+	assert.NotErrorIs(t, err, mail.ErrMailRejected)
+
+	// server certificate isn't trusted
+	srv = newTestServer(t, cert, true, false)
+	err = newTestSender(srv.port(), ImplicitTLSMode, nil).SendWelcomeEmail(context.Background(), testEmail)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, mail.ErrMailRejected)
+}
+
+func TestPlainAuth(t *testing.T) {
+	proto, resp, err := plainAuth{username: testUsername, password: testPassword}.Start(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "PLAIN", proto)
+	assert.Equal(t, "\x00user\x00password", string(resp))
+
+	_, err = plainAuth{}.Next([]byte("challenge"), true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"text/template"
+)
+
+// nolint:gochecknoglobals
+var (
+	//go:embed tmpl/*
+	templatesFS embed.FS
+
+	templates = template.Must(template.ParseFS(templatesFS, "tmpl/*"))
+)
+
+// Body is an email content, both text and html versions are rendered from templates.
+type Body struct {
+	Text string
+	HTML string
+}
+
+// RenderVerificationEmail returns body of the email with confirmation code.
+// The link is omitted if it's empty.
+func RenderVerificationEmail(subject, code, link string) (Body, error) {
+	return render("confirm", struct {
+		Code    string
+		Link    string
+		Subject string
+	}{
+		Code:    code,
+		Link:    link,
+		Subject: subject,
+	})
+}
+
+// RenderWelcomeEmail returns body of the welcome email.
+func RenderWelcomeEmail(subject string) (Body, error) {
+	return render("welcome", struct {
+		Subject string
+	}{
+		Subject: subject,
+	})
+}
+
+func render(name string, data interface{}) (Body, error) {
+	var text, html bytes.Buffer
+	if err := templates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Body{}, fmt.Errorf("failed to execute %s text template: %w", name, err)
+	}
+	if err := templates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Body{}, fmt.Errorf("failed to execute %s html template: %w", name, err)
+	}
+
+	return Body{
+		Text: text.String(),
+		HTML: html.String(),
+	}, nil
+}
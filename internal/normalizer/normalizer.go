@@ -0,0 +1,141 @@
+// Package normalizer reduces email aliases to a canonical address, so one mailbox can't be registered twice.
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Rule is a normalization rule of a mail provider.
+type Rule struct {
+	// Domains are domains the rule applies to.
+	Domains []string `json:"domains"`
+	// Canonical replaces the domain if it's set, e.g. googlemail.com is gmail.com.
+	Canonical string `json:"canonical"`
+	// Separators are subaddress separators, the local part is truncated at the first of them.
+	Separators string `json:"separators"`
+	// IgnoreDots removes dots from the local part.
+	IgnoreDots bool `json:"ignoreDots"`
+}
+
+// Config contains normalization rules.
+type Config struct {
+	// DefaultSeparators are subaddress separators of domains without rule.
+	DefaultSeparators string `json:"defaultSeparators"`
+	Rules             []Rule `json:"rules"`
+}
+
+// DefaultConfig returns rules of well-known providers.
+func DefaultConfig() Config {
+	return Config{
+		DefaultSeparators: "+",
+		Rules: []Rule{
+			{
+				Domains:    []string{"gmail.com", "googlemail.com"},
+				Canonical:  "gmail.com",
+				Separators: "+",
+				IgnoreDots: true,
+			},
+			{
+				Domains:    []string{"yahoo.com", "ymail.com", "rocketmail.com"},
+				Separators: "-",
+			},
+			{
+				Domains:    []string{"yandex.ru", "yandex.com", "yandex.by", "yandex.kz", "yandex.ua", "ya.ru"},
+				Canonical:  "yandex.ru",
+				Separators: "+",
+			},
+		},
+	}
+}
+
+// LoadConfig reads config in json format.
+func LoadConfig(r io.Reader) (Config, error) {
+	var cfg Config
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Open returns normalizer with rules read from the json file, built-in rules are used if path is empty.
+func Open(path string) (*Normalizer, error) {
+	if path == "" {
+		return New(DefaultConfig())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	cfg, err := LoadConfig(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(cfg)
+}
+
+// Normalizer normalizes emails according to the rules.
+type Normalizer struct {
+	defaultSeparators string
+	rules             map[string]Rule
+}
+
+// New returns new instance of Normalizer. An error is returned if a domain has several rules.
+func New(cfg Config) (*Normalizer, error) {
+	n := &Normalizer{
+		defaultSeparators: cfg.DefaultSeparators,
+		rules:             make(map[string]Rule),
+	}
+
+	for _, r := range cfg.Rules {
+		r.Canonical = strings.ToLower(r.Canonical)
+		for _, d := range r.Domains {
+			d = strings.ToLower(d)
+			if _, ok := n.rules[d]; ok {
+				return nil, fmt.Errorf("domain %s has several rules", d)
+			}
+			n.rules[d] = r
+		}
+	}
+
+	return n, nil
+}
+
+// Normalize returns canonical lowercase address of the email.
+func (n *Normalizer) Normalize(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	i := strings.LastIndex(email, "@")
+	if i == -1 {
+		return email
+	}
+	local, domain := email[:i], email[i+1:]
+
+	separators := n.defaultSeparators
+	rule, ok := n.rules[domain]
+	if ok {
+		separators = rule.Separators
+		if rule.Canonical != "" {
+			domain = rule.Canonical
+		}
+	}
+
+	// a local part starting with separator isn't a subaddress
+	if j := strings.IndexAny(local, separators); j > 0 {
+		local = local[:j]
+	}
+
+	if rule.IgnoreDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+
+	return local + "@" + domain
+}
@@ -0,0 +1,75 @@
+package normalizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	n, err := New(DefaultConfig())
+	require.NoError(t, err)
+
+	tt := []struct {
+		email string
+		exp   string
+	}{
+		{email: "john@decentr.xyz", exp: "john@decentr.xyz"},
+		{email: " John@Decentr.xyz ", exp: "john@decentr.xyz"},
+		{email: "john+acc1@decentr.xyz", exp: "john@decentr.xyz"},
+		{email: "j.o.h.n@decentr.xyz", exp: "j.o.h.n@decentr.xyz"},
+		{email: "john-acc1@decentr.xyz", exp: "john-acc1@decentr.xyz"},
+		{email: "+john@decentr.xyz", exp: "+john@decentr.xyz"},
+		{email: "j.o.h.n@gmail.com", exp: "john@gmail.com"},
+		{email: "J.o.h.n+acc1@GoogleMail.com", exp: "john@gmail.com"},
+		{email: "john-acc1@yahoo.com", exp: "john@yahoo.com"},
+		{email: "john+acc1@yahoo.com", exp: "john+acc1@yahoo.com"},
+		{email: "john.doe@ymail.com", exp: "john.doe@ymail.com"},
+		{email: "john+acc1@ya.ru", exp: "john@yandex.ru"},
+		{email: "invalid", exp: "invalid"},
+	}
+
+	for _, tc := range tt {
+		assert.Equal(t, tc.exp, n.Normalize(tc.email), tc.email)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{
+		"defaultSeparators": "+-",
+		"rules": [{"domains": ["Mail.example", "alias.example"], "canonical": "mail.example", "ignoreDots": true}]
+	}`))
+	require.NoError(t, err)
+
+	n, err := New(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "john@decentr.xyz", n.Normalize("john-acc1@decentr.xyz"))
+	assert.Equal(t, "john+acc1@mail.example", n.Normalize("j.ohn+acc1@alias.example"))
+
+	_, err = LoadConfig(strings.NewReader(`[`))
+	assert.Error(t, err)
+
+	_, err = New(Config{Rules: []Rule{{Domains: []string{"a.com"}}, {Domains: []string{"A.com"}}}})
+	assert.EqualError(t, err, "domain a.com has several rules")
+}
+
+func TestOpen(t *testing.T) {
+	n, err := Open("")
+	require.NoError(t, err)
+	assert.Equal(t, "john@gmail.com", n.Normalize("j.o.h.n+acc1@googlemail.com"))
+
+	file := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"rules": [{"domains": ["mail.example"], "ignoreDots": true}]}`), 0600))
+	n, err = Open(file)
+	require.NoError(t, err)
+	assert.Equal(t, "john@mail.example", n.Normalize("j.ohn@mail.example"))
+	assert.Equal(t, "j.o.h.n@googlemail.com", n.Normalize("j.o.h.n@googlemail.com"))
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
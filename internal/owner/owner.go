@@ -0,0 +1,136 @@
+// Package owner computes request owners, the hashes of normalized emails registrations are deduplicated by.
+package owner
+
+import (
+	"crypto/hmac"
+	"crypto/md5" // nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+)
+
+const minSecretLength = 32
+
+// legacyPlusPartRegexp matches the plus part the same way md5 owners were computed with.
+var legacyPlusPartRegexp = regexp.MustCompile(`\+.+\@`) // nolint
+
+// Key is a secret owners are computed with.
+type Key struct {
+	// ID is stored as owner prefix, so owners computed with different keys are distinguished.
+	ID     string
+	Secret []byte
+}
+
+// ParseKey parses key in id:secret format.
+func ParseKey(s string) (Key, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Key{}, errors.New("id:secret format is expected")
+	}
+
+	return Key{ID: parts[0], Secret: []byte(parts[1])}, nil
+}
+
+// ParseKeys parses keys in id:secret format.
+func ParseKeys(ss []string) ([]Key, error) {
+	keys := make([]Key, len(ss))
+	for i, v := range ss {
+		k, err := ParseKey(v)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+
+	return keys, nil
+}
+
+// Hasher computes owners of emails.
+type Hasher struct {
+	n         *normalizer.Normalizer
+	keys      []Key
+	legacyMD5 bool
+}
+
+// NewHasher returns new instance of Hasher. The first key is current, the others are previous ones.
+// If legacyMD5 is true, unsalted md5 owners of not rehashed requests are matched too.
+func NewHasher(n *normalizer.Normalizer, keys []Key, legacyMD5 bool) (*Hasher, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	ids := make(map[string]bool, len(keys))
+	for _, v := range keys {
+		if v.ID == "" || strings.Contains(v.ID, ":") {
+			return nil, fmt.Errorf("invalid key id %q", v.ID)
+		}
+		if ids[v.ID] {
+			return nil, fmt.Errorf("key id %s is duplicated", v.ID)
+		}
+		if len(v.Secret) < minSecretLength {
+			return nil, fmt.Errorf("secret of key %s should be at least %d bytes long", v.ID, minSecretLength)
+		}
+		ids[v.ID] = true
+	}
+
+	return &Hasher{
+		n:         n,
+		keys:      keys,
+		legacyMD5: legacyMD5,
+	}, nil
+}
+
+// Hash returns owner of the email computed with the current key. Aliases of the same mailbox have the same owner.
+func (h *Hasher) Hash(email string) string {
+	return hash(h.keys[0], h.n.Normalize(email))
+}
+
+// Owners returns all owners the email's request can be stored with, the current one goes first.
+func (h *Hasher) Owners(email string) []string {
+	normalized := h.n.Normalize(email)
+
+	owners := make([]string, 0, len(h.keys)+1)
+	for _, v := range h.keys {
+		owners = append(owners, hash(v, normalized))
+	}
+
+	if h.legacyMD5 {
+		owners = append(owners, legacyHash(email))
+	}
+
+	return owners
+}
+
+// Matches returns true if owners computed with the key are matched. Empty id means unsalted md5 owners.
+func (h *Hasher) Matches(keyID string) bool {
+	if keyID == "" {
+		return h.legacyMD5
+	}
+
+	for _, v := range h.keys {
+		if v.ID == keyID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// legacyHash returns md5 owner the way it was computed before normalization was introduced:
+// only the plus part is truncated, so dots and domain aliases are significant.
+func legacyHash(email string) string {
+	b := md5.Sum([]byte(strings.ToLower(legacyPlusPartRegexp.ReplaceAllString(email, "@")))) // nolint:gosec
+	return hex.EncodeToString(b[:])
+}
+
+func hash(k Key, email string) string {
+	m := hmac.New(sha256.New, k.Secret)
+	m.Write([]byte(email)) // nolint:errcheck
+
+	return k.ID + ":" + hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package owner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+)
+
+// nolint:gochecknoglobals
+var (
+	testKey1 = Key{ID: "k1", Secret: []byte(strings.Repeat("1", 32))}
+	testKey2 = Key{ID: "k2", Secret: []byte(strings.Repeat("2", 32))}
+)
+
+func newTestHasher(t *testing.T, keys []Key, legacyMD5 bool) *Hasher {
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	require.NoError(t, err)
+
+	h, err := NewHasher(n, keys, legacyMD5)
+	require.NoError(t, err)
+
+	return h
+}
+
+func TestHasher_Hash(t *testing.T) {
+	h := newTestHasher(t, []Key{testKey1}, false)
+
+	assert.Regexp(t, "^k1:[0-9a-f]{64}$", h.Hash("decentr@decentr.xyz"))
+	assert.Equal(t, h.Hash("decentr@decentr.xyz"), h.Hash("Decentr+acc1@decentr.xyz"))
+	assert.Equal(t, h.Hash("john@gmail.com"), h.Hash("j.o.h.n@googlemail.com"))
+	assert.NotEqual(t, h.Hash("john@gmail.com"), h.Hash("john@decentr.xyz"))
+
+	assert.NotEqual(t, h.Hash("decentr@decentr.xyz"), newTestHasher(t, []Key{testKey2}, false).Hash("decentr@decentr.xyz"))
+}
+
+func TestHasher_Owners(t *testing.T) {
+	current := newTestHasher(t, []Key{testKey2}, false)
+	previous := newTestHasher(t, []Key{testKey1}, false)
+	h := newTestHasher(t, []Key{testKey2, testKey1}, true)
+
+	assert.Equal(t, []string{
+		current.Hash("Decentr@decentr.xyz"),
+		previous.Hash("Decentr@decentr.xyz"),
+		"9790d13a4778f68308977117dd470bb4",
+	}, h.Owners("Decentr@decentr.xyz"))
+	assert.Equal(t, current.Hash("decentr@decentr.xyz"), h.Hash("decentr@decentr.xyz"))
+
+	// legacy owner is md5 of lowercased email with truncated plus part only, dots aren't removed
+	assert.Equal(t, []string{
+		current.Hash("john@gmail.com"),
+		previous.Hash("john@gmail.com"),
+		"0ab478863f679fd59249d07abaed05ac",
+	}, h.Owners("J.O.H.N+acc1@gmail.com"))
+}
+
+func TestHasher_Matches(t *testing.T) {
+	h := newTestHasher(t, []Key{testKey2, testKey1}, false)
+	assert.True(t, h.Matches(testKey1.ID))
+	assert.True(t, h.Matches(testKey2.ID))
+	assert.False(t, h.Matches("k3"))
+	assert.False(t, h.Matches(""))
+
+	assert.True(t, newTestHasher(t, []Key{testKey2}, true).Matches(""))
+}
+
+func TestNewHasher(t *testing.T) {
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	require.NoError(t, err)
+
+	_, err = NewHasher(n, nil, true)
+	assert.Error(t, err)
+	_, err = NewHasher(n, []Key{testKey1, testKey1}, false)
+	assert.EqualError(t, err, "key id k1 is duplicated")
+	_, err = NewHasher(n, []Key{{ID: "k1", Secret: []byte("short")}}, false)
+	assert.EqualError(t, err, "secret of key k1 should be at least 32 bytes long")
+	_, err = NewHasher(n, []Key{{ID: "k:1", Secret: testKey1.Secret}}, false)
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	k, err := ParseKey("k1:secret:with:colons")
+	require.NoError(t, err)
+	assert.Equal(t, Key{ID: "k1", Secret: []byte("secret:with:colons")}, k)
+
+	_, err = ParseKey("secret")
+	assert.Error(t, err)
+	_, err = ParseKey(":secret")
+	assert.Error(t, err)
+
+	keys, err := ParseKeys([]string{"k2:" + string(testKey2.Secret), "k1:" + string(testKey1.Secret)})
+	require.NoError(t, err)
+	assert.Equal(t, []Key{testKey2, testKey1}, keys)
+
+	_, err = ParseKeys([]string{"k1:secret", "secret"})
+	assert.Error(t, err)
+}
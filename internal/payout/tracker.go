@@ -0,0 +1,153 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/blockchain"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+const (
+	trackBatchSize = 100
+	// recheckPeriod is how long payouts failed because of timeout are checked for late inclusion in a block.
+	recheckPeriod = 24 * time.Hour
+)
+
+// Tracker polls the node till broadcast payouts are included in a block or timeout is exceeded.
+type Tracker struct {
+	storage storage.Storage
+	bc      blockchain.Blockchain
+	timeout time.Duration
+}
+
+// NewTracker creates a new instance of Tracker.
+func NewTracker(s storage.Storage, bc blockchain.Blockchain, timeout time.Duration) *Tracker {
+	return &Tracker{
+		storage: s,
+		bc:      bc,
+		timeout: timeout,
+	}
+}
+
+// Run runs the tracker loop.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	t.do(ctx)
+
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.do(ctx)
+			}
+		}
+	}(ticker)
+}
+
+func (t *Tracker) do(ctx context.Context) {
+	payouts, err := t.storage.GetBroadcastPayouts(ctx, trackBatchSize)
+	if err != nil {
+		log.WithError(err).Error("failed to get broadcast payouts")
+		return
+	}
+
+	timedOut, err := t.storage.GetTimedOutPayouts(ctx, time.Now().Add(-recheckPeriod), trackBatchSize)
+	if err != nil {
+		log.WithError(err).Error("failed to get timed out payouts")
+		return
+	}
+
+	for _, p := range append(payouts, timedOut...) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := t.track(ctx, p); err != nil {
+			getLogger(p).WithError(err).Error("failed to track payout")
+		}
+	}
+}
+
+func (t *Tracker) track(ctx context.Context, p *storage.Payout) error {
+	res, err := t.bc.GetTx(ctx, p.TxHash.String)
+	if err != nil {
+		if !errors.Is(err, blockchain.ErrTxNotFound) {
+			return err
+		}
+
+		// timed out payouts are only rechecked, they are already failed
+		if p.Status == storage.FailedPayoutStatus || time.Since(p.BroadcastAt.Time) < t.timeout {
+			return nil
+		}
+
+		// the tx can be still in the mempool, it's waited for till it's included or dropped
+		if _, err := t.bc.FindTx(ctx, txKey(p), p.Address); !errors.Is(err, blockchain.ErrTxNotFound) {
+			if err != nil {
+				return fmt.Errorf("failed to find tx: %w", err)
+			}
+			return nil
+		}
+
+		reason := fmt.Sprintf("tx is not included in a block for %s", t.timeout)
+		if err := t.storage.SetPayoutFailed(ctx, p.ID, reason); err != nil {
+			return fmt.Errorf("failed to mark payout failed: %w", err)
+		}
+
+		alert(p, reason)
+
+		return nil
+	}
+
+	status := storage.CommittedPayoutStatus
+	if res.Code != 0 {
+		status = storage.FailedPayoutStatus
+	}
+
+	if err := t.storage.SetPayoutResult(ctx, p.ID, status, res.Height, res.Code, res.RawLog); err != nil {
+		return fmt.Errorf("failed to set payout result: %w", err)
+	}
+
+	if status == storage.FailedPayoutStatus {
+		alert(p, res.RawLog)
+		return nil
+	}
+
+	if p.Status == storage.FailedPayoutStatus {
+		// the payout was reported as failed, so it must not be retried manually
+		getLogger(p).WithFields(log.Fields{
+			"sender": "slack",
+			"height": res.Height,
+		}).Info("failed payout is committed after timeout")
+		return nil
+	}
+
+	getLogger(p).WithField("height", res.Height).Info("payout committed")
+
+	return nil
+}
+
+func getLogger(p *storage.Payout) *log.Entry {
+	return log.WithFields(log.Fields{
+		"id":                p.ID,
+		"address":           p.Address,
+		"amount":            p.Amount,
+		"owner":             p.Owner.String,
+		"referral_receiver": p.ReferralReceiver.String,
+		"tx_hash":           p.TxHash.String,
+	})
+}
+
+// alert notifies about failed payout, so it can be checked and retried manually.
+func alert(p *storage.Payout, reason string) {
+	getLogger(p).WithFields(log.Fields{
+		"sender": "slack",
+		"reason": reason,
+	}).Error("payout failed")
+}
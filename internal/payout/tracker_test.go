@@ -0,0 +1,148 @@
+package payout
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Decentr-net/vulcan/internal/blockchain"
+	blockchainmock "github.com/Decentr-net/vulcan/internal/blockchain/mock"
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+func TestTracker_track(t *testing.T) {
+	broadcast := func(at time.Time) *storage.Payout {
+		p := *testPayout
+		p.Status = storage.BroadcastPayoutStatus
+		p.TxHash = sql.NullString{Valid: true, String: "hash"}
+		p.BroadcastAt = sql.NullTime{Valid: true, Time: at}
+		return &p
+	}
+	timedOut := func() *storage.Payout {
+		p := broadcast(time.Now().Add(-2 * time.Minute))
+		p.Status = storage.FailedPayoutStatus
+		return p
+	}
+
+	tt := []struct {
+		name          string
+		payout        *storage.Payout
+		mockSetupFunc func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain)
+		err           error
+	}{
+		{
+			name:   "committed",
+			payout: broadcast(time.Now()),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(&blockchain.TxResult{Height: 10, RawLog: "log"}, nil)
+				s.EXPECT().SetPayoutResult(gomock.Any(), int64(1), storage.CommittedPayoutStatus, int64(10), uint32(0), "log").Return(nil)
+			},
+		},
+		{
+			name:   "failed in block",
+			payout: broadcast(time.Now()),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(&blockchain.TxResult{Height: 10, Code: 5, RawLog: "insufficient funds"}, nil)
+				s.EXPECT().SetPayoutResult(gomock.Any(), int64(1), storage.FailedPayoutStatus, int64(10), uint32(5), "insufficient funds").Return(nil)
+			},
+		},
+		{
+			name:   "not included yet",
+			payout: broadcast(time.Now()),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+			},
+		},
+		{
+			name:   "dropped",
+			payout: broadcast(time.Now().Add(-2 * time.Minute)),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+				bc.EXPECT().FindTx(gomock.Any(), "payout-1", testAddress).Return(nil, blockchain.ErrTxNotFound)
+				s.EXPECT().SetPayoutFailed(gomock.Any(), int64(1), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "in mempool after timeout",
+			payout: broadcast(time.Now().Add(-2 * time.Minute)),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+				bc.EXPECT().FindTx(gomock.Any(), "payout-1", testAddress).Return(&blockchain.Tx{Hash: "hash"}, nil)
+			},
+		},
+		{
+			name: "batch in mempool after timeout",
+			payout: func() *storage.Payout {
+				p := broadcast(time.Now().Add(-2 * time.Minute))
+				p.BatchKey = sql.NullString{Valid: true, String: "payouts-key"}
+				return p
+			}(),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+				bc.EXPECT().FindTx(gomock.Any(), "payouts-key", testAddress).Return(&blockchain.Tx{Hash: "hash"}, nil)
+			},
+		},
+		{
+			name:   "truncated mempool after timeout",
+			payout: broadcast(time.Now().Add(-2 * time.Minute)),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+				bc.EXPECT().FindTx(gomock.Any(), "payout-1", testAddress).Return(nil, blockchain.ErrMempoolTruncated)
+			},
+			err: blockchain.ErrMempoolTruncated,
+		},
+		{
+			name:   "timed out is committed",
+			payout: timedOut(),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(&blockchain.TxResult{Height: 10, RawLog: "log"}, nil)
+				s.EXPECT().SetPayoutResult(gomock.Any(), int64(1), storage.CommittedPayoutStatus, int64(10), uint32(0), "log").Return(nil)
+			},
+		},
+		{
+			name:   "timed out is not included",
+			payout: timedOut(),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, blockchain.ErrTxNotFound)
+			},
+		},
+		{
+			name:   "node error",
+			payout: broadcast(time.Now().Add(-2 * time.Minute)),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(nil, errTest)
+			},
+			err: errTest,
+		},
+		{
+			name:   "storage error",
+			payout: broadcast(time.Now()),
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				bc.EXPECT().GetTx(gomock.Any(), "hash").Return(&blockchain.TxResult{Height: 10}, nil)
+				s.EXPECT().SetPayoutResult(gomock.Any(), int64(1), storage.CommittedPayoutStatus, int64(10), uint32(0), "").Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			bc := blockchainmock.NewMockBlockchain(ctrl)
+
+			tc.mockSetupFunc(st, bc)
+
+			assert.ErrorIs(t, NewTracker(st, bc, time.Minute).track(context.Background(), tc.payout), tc.err)
+		})
+	}
+}
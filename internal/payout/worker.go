@@ -0,0 +1,258 @@
+// Package payout contains the worker broadcasting payouts created by the service.
+package payout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/blockchain"
+	"github.com/Decentr-net/vulcan/internal/budget"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+var errNoPendingPayouts = errors.New("no pending payouts")
+
+// Key returns idempotency key of the payout.
+func Key(id int64) string {
+	return fmt.Sprintf("payout-%d", id)
+}
+
+// BatchKey returns idempotency key of the payouts sent in one tx.
+func BatchKey(payouts []*storage.Payout) string {
+	if len(payouts) == 1 {
+		return Key(payouts[0].ID)
+	}
+
+	h := sha256.New()
+	for _, p := range payouts {
+		_ = binary.Write(h, binary.BigEndian, p.ID)
+	}
+
+	return fmt.Sprintf("payouts-%x", h.Sum(nil)[:8])
+}
+
+// txKey returns idempotency key of the tx the payout is sent with.
+func txKey(p *storage.Payout) string {
+	// payouts broadcast before batching were sent with their own keys
+	if !p.BatchKey.Valid {
+		return Key(p.ID)
+	}
+	return p.BatchKey.String
+}
+
+// Worker broadcasts pending payouts in batches: every batch is sent as one multi-message tx.
+// A batch is sent when it's full or its oldest payout waits longer than the batch window.
+// Payouts exceeding spending caps are left for manual approval.
+//
+// Batch key is committed before the tx is broadcast, so a batch left pending because of a failure after
+// the broadcast is sent again with the same key and the tx already sent is found instead of paying twice.
+type Worker struct {
+	storage storage.Storage
+	bc      blockchain.Blockchain
+	budget  *budget.Budget
+
+	batchSize   int
+	batchWindow time.Duration
+}
+
+// NewWorker creates a new instance of Worker.
+func NewWorker(s storage.Storage, bc blockchain.Blockchain, b *budget.Budget, batchSize int, batchWindow time.Duration) *Worker {
+	return &Worker{
+		storage: s,
+		bc:      bc,
+		budget:  b,
+
+		batchSize:   batchSize,
+		batchWindow: batchWindow,
+	}
+}
+
+// Run runs the worker loop.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	w.do(ctx)
+
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.do(ctx)
+			}
+		}
+	}(ticker)
+}
+
+func (w *Worker) do(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := w.processNext(ctx); err != nil {
+			if !errors.Is(err, errNoPendingPayouts) {
+				log.WithError(err).Error("failed to process payouts")
+			}
+			return
+		}
+	}
+}
+
+// processNext forms the next batch of pending payouts and sends the oldest batch.
+func (w *Worker) processNext(ctx context.Context) error {
+	err := w.batchNext(ctx)
+	if err != nil && !errors.Is(err, errNoPendingPayouts) {
+		return err
+	}
+
+	if serr := w.sendNext(ctx); !errors.Is(serr, errNoPendingPayouts) {
+		return serr
+	}
+
+	return err
+}
+
+// batchNext assigns the next pending payouts allowed by spending caps to a batch.
+// Spending is locked till the batch is committed, so concurrent workers check caps one by one.
+func (w *Worker) batchNext(ctx context.Context) error {
+	return w.storage.InTx(ctx, func(s storage.Storage) error {
+		if err := s.LockSpending(ctx); err != nil {
+			return fmt.Errorf("failed to lock spending: %w", err)
+		}
+
+		payouts, err := s.GetPendingPayouts(ctx, w.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get pending payouts: %w", err)
+		}
+
+		if len(payouts) == 0 {
+			return errNoPendingPayouts
+		}
+
+		// wait for more payouts to come
+		if len(payouts) < w.batchSize && time.Since(payouts[0].CreatedAt) < w.batchWindow {
+			return errNoPendingPayouts
+		}
+
+		// memo is common for the whole tx, so payouts with other memos are left for the next batch
+		batch := make([]*storage.Payout, 0, len(payouts))
+		for _, p := range payouts {
+			if p.Memo == payouts[0].Memo {
+				batch = append(batch, p)
+			}
+		}
+
+		allowed, excesses, err := w.budget.Split(ctx, s, batch)
+		if err != nil {
+			return fmt.Errorf("failed to check spending caps: %w", err)
+		}
+
+		for _, v := range excesses {
+			if err := s.SetPayoutApproval(ctx, v.Payout.ID, v.Reason); err != nil {
+				return fmt.Errorf("failed to mark payout waiting for approval: %w", err)
+			}
+
+			getLogger(v.Payout).WithFields(log.Fields{
+				"sender": "slack",
+				"reason": v.Reason,
+			}).Warn("payout is waiting for approval")
+		}
+
+		if len(allowed) == 0 {
+			return nil
+		}
+
+		return setBatch(ctx, s, allowed)
+	})
+}
+
+// sendNext broadcasts the oldest batch. Payout rows are locked till their statuses are updated,
+// so concurrent workers never send the same batch.
+func (w *Worker) sendNext(ctx context.Context) error {
+	return w.storage.InTx(ctx, func(s storage.Storage) error {
+		payouts, err := s.GetBatchPayouts(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get batch payouts: %w", err)
+		}
+
+		if len(payouts) == 0 {
+			return errNoPendingPayouts
+		}
+
+		return w.send(ctx, s, payouts)
+	})
+}
+
+// send broadcasts the batch in one tx. If the tx is rejected, the batch is split in halves which are sent
+// as new batches, so the rest of payouts are sent anyway.
+func (w *Worker) send(ctx context.Context, s storage.Storage, payouts []*storage.Payout) error {
+	stakes := make([]blockchain.Stake, len(payouts))
+	for i, p := range payouts {
+		stakes[i] = blockchain.Stake{Address: p.Address, Amount: p.Amount}
+	}
+
+	tx, err := w.bc.SendStakes(ctx, payouts[0].BatchKey.String, stakes, payouts[0].Memo)
+	if err != nil {
+		// it's unknown whether the tx is already sent, so the batch is left to be sent on the next run
+		if errors.Is(err, blockchain.ErrMempoolTruncated) {
+			return fmt.Errorf("failed to send stakes: %w", err)
+		}
+
+		// the rejected tx isn't sent, so its payouts can be rebatched safely
+		if len(payouts) > 1 && isRejected(err) {
+			log.WithError(err).WithField("count", len(payouts)).Warn("payouts batch is rejected, splitting")
+
+			mid := len(payouts) / 2
+			if err := setBatch(ctx, s, payouts[:mid]); err != nil {
+				return err
+			}
+			return setBatch(ctx, s, payouts[mid:])
+		}
+
+		for _, p := range payouts {
+			if err := s.SetPayoutFailed(ctx, p.ID, err.Error()); err != nil {
+				return fmt.Errorf("failed to mark payout failed: %w", err)
+			}
+
+			alert(p, err.Error())
+		}
+
+		return nil
+	}
+
+	for _, p := range payouts {
+		if err := s.SetPayoutBroadcast(ctx, p.ID, tx.Hash, tx.Fee, tx.Gas); err != nil {
+			return fmt.Errorf("failed to mark payout broadcast: %w", err)
+		}
+
+		getLogger(p).WithFields(log.Fields{
+			"tx_hash": tx.Hash,
+			"tx_fee":  tx.Fee.String(),
+			"tx_gas":  tx.Gas,
+		}).Info("payout broadcast")
+	}
+
+	return nil
+}
+
+// setBatch assigns payouts to the batch. The key depends on payouts only, so a key always means the same payouts.
+func setBatch(ctx context.Context, s storage.Storage, payouts []*storage.Payout) error {
+	ids := make([]int64, len(payouts))
+	for i, p := range payouts {
+		ids[i] = p.ID
+	}
+
+	if err := s.SetPayoutsBatch(ctx, ids, BatchKey(payouts)); err != nil {
+		return fmt.Errorf("failed to set payouts batch: %w", err)
+	}
+
+	return nil
+}
+
+// isRejected returns true if it's guaranteed the tx wasn't sent, so its payouts can be sent in another tx.
+func isRejected(err error) bool {
+	return errors.Is(err, blockchain.ErrTxRejected) || errors.Is(err, blockchain.ErrInvalidAddress)
+}
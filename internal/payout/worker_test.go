@@ -0,0 +1,307 @@
+package payout
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/blockchain"
+	blockchainmock "github.com/Decentr-net/vulcan/internal/blockchain/mock"
+	"github.com/Decentr-net/vulcan/internal/budget"
+	"github.com/Decentr-net/vulcan/internal/slack"
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+var (
+	errTest     = assert.AnError
+	testAddress = "decentr1vg085ra5hw8mx5rrheqf8fruks0xv4urqkuqga"
+	testPayout  = &storage.Payout{
+		ID:      1,
+		Address: testAddress,
+		Amount:  sdk.NewInt(100),
+		Memo:    "memo",
+		Status:  storage.PendingPayoutStatus,
+	}
+)
+
+func TestWorker_processNext(t *testing.T) {
+	newPayout := func(id int64, memo string, createdAt time.Time) *storage.Payout {
+		p := *testPayout
+		p.ID = id
+		p.Memo = memo
+		p.CreatedAt = createdAt
+		return &p
+	}
+	batched := func(key string, payouts ...*storage.Payout) []*storage.Payout {
+		out := make([]*storage.Payout, len(payouts))
+		for i, p := range payouts {
+			v := *p
+			v.BatchKey = sql.NullString{Valid: true, String: key}
+			out[i] = &v
+		}
+		return out
+	}
+
+	p1, p2, p3 := newPayout(1, "memo", time.Time{}), newPayout(2, "memo", time.Time{}), newPayout(3, "other", time.Time{})
+	stake := blockchain.Stake{Address: testAddress, Amount: sdk.NewInt(100)}
+	testTx := &blockchain.Tx{Hash: "hash", Fee: sdk.NewCoins(sdk.NewInt64Coin("udec", 25)), Gas: 1000}
+	rejected := fmt.Errorf("%w: insufficient funds", blockchain.ErrTxRejected)
+	key := BatchKey([]*storage.Payout{p1, p2})
+
+	tt := []struct {
+		name          string
+		batchSize     int
+		mockSetupFunc func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain)
+		err           error
+	}{
+		{
+			name:      "broadcast",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				gomock.InOrder(
+					s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return([]*storage.Payout{p1}, nil),
+					s.EXPECT().SetPayoutsBatch(gomock.Any(), []int64{1}, "payout-1").Return(nil),
+					s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched("payout-1", p1), nil),
+					bc.EXPECT().SendStakes(gomock.Any(), "payout-1", []blockchain.Stake{stake}, "memo").Return(testTx, nil),
+					s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(1), "hash", testTx.Fee, testTx.Gas).Return(nil),
+				)
+			},
+		},
+		{
+			name:      "batch",
+			batchSize: 3,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 3).Return([]*storage.Payout{p1, p3, p2}, nil)
+				s.EXPECT().SetPayoutsBatch(gomock.Any(), []int64{1, 2}, key).Return(nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched(key, p1, p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), key, []blockchain.Stake{stake, stake}, "memo").Return(testTx, nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(1), "hash", testTx.Fee, testTx.Gas).Return(nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(2), "hash", testTx.Fee, testTx.Gas).Return(nil)
+			},
+		},
+		{
+			name:      "waiting for batch",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return([]*storage.Payout{newPayout(1, "memo", time.Now())}, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(nil, nil)
+			},
+			err: errNoPendingPayouts,
+		},
+		{
+			name:      "stored batch is sent with its key",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched("stored", p1, p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), "stored", []blockchain.Stake{stake, stake}, "memo").Return(testTx, nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(1), "hash", testTx.Fee, testTx.Gas).Return(nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(2), "hash", testTx.Fee, testTx.Gas).Return(nil)
+			},
+		},
+		{
+			name:      "rejected batch is split",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched(key, p1, p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), key, gomock.Any(), "memo").Return(nil, rejected)
+				s.EXPECT().SetPayoutsBatch(gomock.Any(), []int64{1}, "payout-1").Return(nil)
+				s.EXPECT().SetPayoutsBatch(gomock.Any(), []int64{2}, "payout-2").Return(nil)
+			},
+		},
+		{
+			name:      "rejected payout",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched("payout-2", p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), "payout-2", gomock.Any(), "memo").Return(nil, rejected)
+				s.EXPECT().SetPayoutFailed(gomock.Any(), int64(2), rejected.Error()).Return(nil)
+			},
+		},
+		{
+			name:      "truncated mempool",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched(key, p1, p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), key, gomock.Any(), "memo").Return(nil, blockchain.ErrMempoolTruncated)
+			},
+			err: blockchain.ErrMempoolTruncated,
+		},
+		{
+			name:      "failed",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched(key, p1, p2), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), key, gomock.Any(), "memo").Return(nil, errTest)
+				s.EXPECT().SetPayoutFailed(gomock.Any(), int64(1), errTest.Error()).Return(nil)
+				s.EXPECT().SetPayoutFailed(gomock.Any(), int64(2), errTest.Error()).Return(nil)
+			},
+		},
+		{
+			name:      "cap exceeded",
+			batchSize: 2,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				p4, p5 := newPayout(4, "memo", time.Time{}), newPayout(5, "memo", time.Time{})
+				p4.Purpose, p5.Purpose = storage.RegistrationPayoutPurpose, storage.RegistrationPayoutPurpose
+
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 2).Return([]*storage.Payout{p4, p5}, nil)
+				s.EXPECT().GetSpending(gomock.Any(), storage.RegistrationPayoutPurpose).Return(&storage.Spending{
+					Day:   sdk.ZeroInt(),
+					Month: sdk.ZeroInt(),
+				}, nil)
+				s.EXPECT().SetPayoutApproval(gomock.Any(), int64(5), gomock.Any()).Return(nil)
+				s.EXPECT().SetPayoutsBatch(gomock.Any(), []int64{4}, "payout-4").Return(nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched("payout-4", p4), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), "payout-4", []blockchain.Stake{stake}, "memo").Return(testTx, nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(4), "hash", testTx.Fee, testTx.Gas).Return(nil)
+			},
+		},
+		{
+			name:      "all payouts exceed caps",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				p4 := newPayout(4, "memo", time.Time{})
+				p4.Purpose, p4.Amount = storage.RegistrationPayoutPurpose, sdk.NewInt(200)
+
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return([]*storage.Payout{p4}, nil)
+				s.EXPECT().GetSpending(gomock.Any(), storage.RegistrationPayoutPurpose).Return(&storage.Spending{
+					Day:   sdk.ZeroInt(),
+					Month: sdk.ZeroInt(),
+				}, nil)
+				s.EXPECT().SetPayoutApproval(gomock.Any(), int64(4), gomock.Any()).Return(nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:      "no payouts",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(nil, nil)
+			},
+			err: errNoPendingPayouts,
+		},
+		{
+			name:      "storage error",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return(nil, errTest)
+			},
+			err: errTest,
+		},
+		{
+			name:      "batch storage error",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(nil, errTest)
+			},
+			err: errTest,
+		},
+		{
+			name:      "update error",
+			batchSize: 1,
+			mockSetupFunc: func(s *storagemock.MockStorage, bc *blockchainmock.MockBlockchain) {
+				s.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return(nil, nil)
+				s.EXPECT().GetBatchPayouts(gomock.Any()).Return(batched("payout-1", p1), nil)
+				bc.EXPECT().SendStakes(gomock.Any(), "payout-1", gomock.Any(), "memo").Return(testTx, nil)
+				s.EXPECT().SetPayoutBroadcast(gomock.Any(), int64(1), "hash", testTx.Fee, testTx.Gas).Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			bc := blockchainmock.NewMockBlockchain(ctrl)
+
+			st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+				return f(st)
+			}).MinTimes(1).MaxTimes(2)
+			st.EXPECT().LockSpending(gomock.Any()).Return(nil)
+			tc.mockSetupFunc(st, bc)
+
+			b := budget.New(map[storage.PayoutPurpose]budget.Cap{
+				storage.RegistrationPayoutPurpose: {Daily: sdk.NewInt(150)},
+			})
+
+			assert.ErrorIs(t, NewWorker(st, bc, b, tc.batchSize, time.Minute).processNext(context.Background()), tc.err)
+		})
+	}
+}
+
+func TestBatchKey(t *testing.T) {
+	p1, p2 := *testPayout, *testPayout
+	p2.ID = 2
+
+	assert.Equal(t, "payout-1", BatchKey([]*storage.Payout{&p1}))
+	assert.NotEqual(t, BatchKey([]*storage.Payout{&p1, &p2}), BatchKey([]*storage.Payout{&p2, &p1}))
+	assert.Equal(t, BatchKey([]*storage.Payout{&p1, &p2}), BatchKey([]*storage.Payout{&p1, &p2}))
+}
+
+func TestWorker_approvalAlert(t *testing.T) {
+	var alerts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Attachments []struct {
+				Fallback string `json:"fallback"`
+			} `json:"attachments"`
+		}
+		if assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg)) && assert.Len(t, msg.Attachments, 1) {
+			alerts = append(alerts, msg.Attachments[0].Fallback)
+		}
+	}))
+	defer srv.Close()
+
+	hooks := log.StandardLogger().ReplaceHooks(log.LevelHooks{})
+	defer log.StandardLogger().ReplaceHooks(hooks)
+	log.AddHook(slack.NewHook(srv.URL, "alerts"))
+
+	ctrl := gomock.NewController(t)
+	st := storagemock.NewMockStorage(ctrl)
+	bc := blockchainmock.NewMockBlockchain(ctrl)
+
+	p := *testPayout
+	p.Purpose, p.Amount = storage.RegistrationPayoutPurpose, sdk.NewInt(200)
+
+	st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+		return f(st)
+	}).Times(2)
+	st.EXPECT().LockSpending(gomock.Any()).Return(nil)
+	st.EXPECT().GetPendingPayouts(gomock.Any(), 1).Return([]*storage.Payout{&p}, nil)
+	st.EXPECT().GetSpending(gomock.Any(), storage.RegistrationPayoutPurpose).Return(&storage.Spending{
+		Day:   sdk.ZeroInt(),
+		Month: sdk.ZeroInt(),
+	}, nil)
+	st.EXPECT().SetPayoutApproval(gomock.Any(), int64(1), gomock.Any()).Return(nil)
+	st.EXPECT().GetBatchPayouts(gomock.Any()).Return(nil, nil)
+
+	b := budget.New(map[storage.PayoutPurpose]budget.Cap{
+		storage.RegistrationPayoutPurpose: {Daily: sdk.NewInt(150)},
+	})
+
+	require.NoError(t, NewWorker(st, bc, b, 1, time.Minute).processNext(context.Background()))
+	assert.Equal(t, []string{"payout is waiting for approval"}, alerts)
+}
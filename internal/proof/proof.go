@@ -0,0 +1,91 @@
+// Package proof issues and verifies proofs of email ownership.
+// A proof is given to the registrant only, so it lets to query the registration without exposing emails.
+package proof
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minSecretLength = 32
+
+// ErrInvalid is returned when proof is malformed or its signature doesn't match.
+var ErrInvalid = fmt.Errorf("invalid proof")
+
+// ErrExpired is returned when proof is expired.
+var ErrExpired = fmt.Errorf("proof is expired")
+
+// Signer issues and verifies proofs of subjects, e.g. request owners.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner returns new instance of Signer. Proofs are valid for ttl.
+func NewSigner(secret []byte, ttl time.Duration) (*Signer, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("secret should be at least %d bytes long", minSecretLength)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl should be positive")
+	}
+
+	return &Signer{
+		secret: secret,
+		ttl:    ttl,
+	}, nil
+}
+
+// Sign returns proof of the subject in <subject>.<expiration>.<signature> format, subject is base64 encoded.
+func (s *Signer) Sign(subject string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(subject)) + "." +
+		strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+
+	return payload + "." + s.signature(payload)
+}
+
+// Verify returns subject of the proof.
+func (s *Signer) Verify(proof string) (string, error) {
+	i := strings.LastIndex(proof, ".")
+	if i < 0 {
+		return "", ErrInvalid
+	}
+
+	payload, signature := proof[:i], proof[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.signature(payload))) {
+		return "", ErrInvalid
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 {
+		return "", ErrInvalid
+	}
+
+	subject, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	expiration, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	if time.Now().After(time.Unix(expiration, 0)) {
+		return "", ErrExpired
+	}
+
+	return string(subject), nil
+}
+
+func (s *Signer) signature(payload string) string {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(payload)) // nolint:errcheck
+
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
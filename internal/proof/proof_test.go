@@ -0,0 +1,51 @@
+package proof
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nolint:gochecknoglobals
+var testSecret = []byte(strings.Repeat("s", 32))
+
+func TestSigner(t *testing.T) {
+	s, err := NewSigner(testSecret, time.Hour)
+	require.NoError(t, err)
+
+	p := s.Sign("k1:owner")
+	owner, err := s.Verify(p)
+	require.NoError(t, err)
+	assert.Equal(t, "k1:owner", owner)
+
+	another, err := NewSigner([]byte(strings.Repeat("a", 32)), time.Hour)
+	require.NoError(t, err)
+	_, err = another.Verify(p)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	parts := strings.Split(p, ".")
+	_, err = s.Verify(parts[0] + ".4102444800." + parts[2])
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	for _, v := range []string{"", "owner", "a.b", "a.b.c.d"} {
+		_, err = s.Verify(v)
+		assert.ErrorIs(t, err, ErrInvalid, v)
+	}
+}
+
+func TestSigner_Expired(t *testing.T) {
+	s := &Signer{secret: testSecret, ttl: -time.Minute}
+
+	_, err := s.Verify(s.Sign("owner"))
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner([]byte("short"), time.Hour)
+	assert.Error(t, err)
+	_, err = NewSigner(testSecret, 0)
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package referral
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 	"time"
@@ -12,7 +13,6 @@ import (
 
 	tokentypes "github.com/Decentr-net/decentr/x/token/types"
 
-	"github.com/Decentr-net/vulcan/internal/blockchain"
 	"github.com/Decentr-net/vulcan/internal/storage"
 )
 
@@ -102,17 +102,14 @@ func (c Config) GetSenderReward(confirmedReferralsCount int) sdk.Int {
 // Rewarder ...
 type Rewarder struct {
 	storage storage.Storage
-	bmc     blockchain.Blockchain
 	brc     tokentypes.QueryClient
 	rc      Config
 }
 
-// NewRewarder creates a new instance of Rewarder.
-func NewRewarder(s storage.Storage, b blockchain.Blockchain, brc tokentypes.QueryClient,
-	rc Config) *Rewarder {
+// NewRewarder creates a new instance of Rewarder. Rewards are sent by payout worker.
+func NewRewarder(s storage.Storage, brc tokentypes.QueryClient, rc Config) *Rewarder {
 	return &Rewarder{
 		storage: s,
-		bmc:     b,
 		brc:     brc,
 		rc:      rc,
 	}
@@ -187,18 +184,33 @@ func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, co
 	}
 
 	if err := r.storage.InTx(ctx, func(s storage.Storage) error {
-		if err := r.storage.TransitionReferralTrackingToConfirmed(
+		if err := s.TransitionReferralTrackingToConfirmed(
 			ctx, ref.Receiver, totalSenderReward, r.rc.ReceiverReward); err != nil {
 			return fmt.Errorf("failed to transition referral to confirmed: %w", err)
 		}
 
-		stakes := []blockchain.Stake{
-			{Address: ref.Sender, Amount: totalSenderReward},
-			{Address: ref.Receiver, Amount: r.rc.ReceiverReward},
+		receiver := sql.NullString{Valid: true, String: ref.Receiver}
+		payouts := []*storage.Payout{
+			{
+				Address:          ref.Sender,
+				Amount:           totalSenderReward,
+				Memo:             memo,
+				Purpose:          storage.ReferralSenderPayoutPurpose,
+				ReferralReceiver: receiver,
+			},
+			{
+				Address:          ref.Receiver,
+				Amount:           r.rc.ReceiverReward,
+				Memo:             memo,
+				Purpose:          storage.ReferralReceiverPayoutPurpose,
+				ReferralReceiver: receiver,
+			},
 		}
 
-		if err := r.bmc.SendStakes(stakes, memo); err != nil {
-			return fmt.Errorf("failed to send stakes: %w", err)
+		for _, p := range payouts {
+			if err := s.CreatePayout(ctx, p); err != nil {
+				return fmt.Errorf("failed to create payout: %w", err)
+			}
 		}
 
 		return nil
@@ -207,7 +219,7 @@ func (r *Rewarder) reward(ctx context.Context, ref *storage.ReferralTracking, co
 		return
 	}
 
-	logger.Infof("rewards sent")
+	logger.Infof("rewards queued")
 }
 
 func (r *Rewarder) getLogger(ref *storage.ReferralTracking) *log.Entry {
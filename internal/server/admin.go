@@ -0,0 +1,624 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/Decentr-net/go-api"
+
+	"github.com/Decentr-net/vulcan/internal/service"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+const maxAdminLimit = 100
+
+// AdminConfig contains admin API settings. Admin API is disabled if there are no tokens.
+type AdminConfig struct {
+	Service service.Admin
+	// Tokens maps bearer tokens to actors recorded in the audit log.
+	Tokens map[string]string
+}
+
+type actorContextKey struct{}
+
+// adminAuthorized returns middleware which accepts only requests with a known bearer token.
+func adminAuthorized(tokens map[string]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			var actor string
+			for k, v := range tokens {
+				if subtle.ConstantTimeCompare([]byte(k), []byte(token)) == 1 {
+					actor = v
+				}
+			}
+
+			if token == "" || actor == "" {
+				api.WriteError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorContextKey{}, actor)))
+		})
+	}
+}
+
+func actor(r *http.Request) string {
+	v, _ := r.Context().Value(actorContextKey{}).(string)
+	return v
+}
+
+func adminLimit(r *http.Request) int {
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit <= 0 || limit > maxAdminLimit {
+		limit = maxAdminLimit
+	}
+
+	return limit
+}
+
+// listFraudRules returns all fraud rules.
+func (s *server) listFraudRules(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /admin/v1/fraud/rules Admin ListFraudRules
+	//
+	// Returns all fraud rules.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/FraudRule"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	rules, err := s.admin.GetFraudRules(r.Context())
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get fraud rules")
+		return
+	}
+
+	res := make([]FraudRule, len(rules))
+	for i, v := range rules {
+		res[i] = toFraudRule(v)
+	}
+
+	api.WriteOK(w, http.StatusOK, res)
+}
+
+// createFraudRule creates a fraud rule.
+func (s *server) createFraudRule(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /admin/v1/fraud/rules Admin CreateFraudRule
+	//
+	// Creates a fraud rule. Running instances pick up the rule within fraud refresh interval.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/CreateFraudRuleRequest'
+	// responses:
+	//   '201':
+	//     schema:
+	//       "$ref": "#/definitions/FraudRule"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: rule already exists.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var req CreateFraudRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := s.admin.CreateFraudRule(r.Context(), actor(r), storage.FraudRuleKind(req.Kind), req.Pattern, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidFraudRule):
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrFraudRuleExists):
+			api.WriteError(w, http.StatusConflict, "rule already exists")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to create fraud rule")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusCreated, toFraudRule(rule))
+}
+
+// deleteFraudRule deletes a fraud rule.
+func (s *server) deleteFraudRule(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation DELETE /admin/v1/fraud/rules/{id} Admin DeleteFraudRule
+	//
+	// Deletes a fraud rule. Running instances stop applying the rule within fraud refresh interval.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: id
+	//   in: path
+	//   required: true
+	//   type: integer
+	// - name: reason
+	//   in: query
+	//   type: string
+	//   description: reason recorded in the audit log.
+	// responses:
+	//   '200':
+	//     description: rule is deleted.
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: rule not found.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := s.admin.DeleteFraudRule(r.Context(), actor(r), id, r.FormValue("reason")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrFraudRuleNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to delete fraud rule")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// banReferral bans a referral sender.
+func (s *server) banReferral(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /admin/v1/referral/{address}/ban Admin BanReferral
+	//
+	// Bans a referral sender, rewards of banned senders aren't paid.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ReasonRequest'
+	// responses:
+	//   '200':
+	//     description: referral sender is banned.
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: request not found.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	s.setReferralBanned(w, r, s.admin.BanReferral)
+}
+
+// unbanReferral unbans a referral sender.
+func (s *server) unbanReferral(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /admin/v1/referral/{address}/unban Admin UnbanReferral
+	//
+	// Unbans a referral sender.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: address
+	//   in: path
+	//   required: true
+	//   type: string
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ReasonRequest'
+	// responses:
+	//   '200':
+	//     description: referral sender is unbanned.
+	//     schema:
+	//       "$ref": "#/definitions/EmptyResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: request not found.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	s.setReferralBanned(w, r, s.admin.UnbanReferral)
+}
+
+func (s *server) setReferralBanned(
+	w http.ResponseWriter, r *http.Request, f func(ctx context.Context, actor, address, reason string) error,
+) {
+	address := chi.URLParam(r, "address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	var req ReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		api.WriteError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	if err := f(r.Context(), actor(r), address, req.Reason); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to set referral banned")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+}
+
+// searchRequests returns registration requests matching the filter.
+func (s *server) searchRequests(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /admin/v1/requests Admin SearchRequests
+	//
+	// Searches registration requests by address, email or referral code. Filters are combined.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: address
+	//   in: query
+	//   type: string
+	// - name: email
+	//   in: query
+	//   type: string
+	// - name: referralCode
+	//   in: query
+	//   type: string
+	//   description: own or registration referral code.
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   default: 100
+	//   minimum: 1
+	//   maximum: 100
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/AdminRequest"
+	//   '400':
+	//      description: no filter is given.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	requests, err := s.admin.SearchRequests(r.Context(), service.RequestFilter{
+		Address:      r.FormValue("address"),
+		Email:        r.FormValue("email"),
+		ReferralCode: r.FormValue("referralCode"),
+	}, adminLimit(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptyFilter):
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to search requests")
+		}
+		return
+	}
+
+	res := make([]AdminRequest, len(requests))
+	for i, v := range requests {
+		res[i] = toAdminRequest(v)
+	}
+
+	api.WriteOK(w, http.StatusOK, res)
+}
+
+// listAuditRecords returns the latest administrative changes.
+func (s *server) listAuditRecords(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /admin/v1/audit Admin ListAuditRecords
+	//
+	// Returns the latest administrative changes.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: target
+	//   in: query
+	//   type: string
+	//   description: address or kind:pattern of fraud rule.
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   default: 100
+	//   minimum: 1
+	//   maximum: 100
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/AuditRecord"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	records, err := s.admin.GetAuditRecords(r.Context(), r.FormValue("target"), adminLimit(r))
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get audit records")
+		return
+	}
+
+	res := make([]AuditRecord, len(records))
+	for i, v := range records {
+		res[i] = AuditRecord{
+			ID:        v.ID,
+			Actor:     v.Actor,
+			Action:    string(v.Action),
+			Target:    v.Target,
+			Reason:    v.Reason,
+			CreatedAt: v.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	api.WriteOK(w, http.StatusOK, res)
+}
+
+// listEmails returns the latest emails of the registration request.
+func (s *server) listEmails(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /admin/v1/requests/{owner}/emails Admin ListEmails
+	//
+	// Returns the latest emails of the registration request with their delivery statuses.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: Authorization
+	//   in: header
+	//   type: string
+	//   required: true
+	//   description: admin bearer token.
+	// - name: owner
+	//   in: path
+	//   type: string
+	//   required: true
+	//   description: owner of the registration request.
+	// - name: limit
+	//   in: query
+	//   type: integer
+	//   default: 100
+	//   minimum: 1
+	//   maximum: 100
+	// responses:
+	//   '200':
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/AdminEmail"
+	//   '401':
+	//      description: token is invalid.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	emails, err := s.admin.GetEmails(r.Context(), chi.URLParam(r, "owner"), adminLimit(r))
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to get emails")
+		return
+	}
+
+	res := make([]AdminEmail, len(emails))
+	for i, v := range emails {
+		res[i] = toAdminEmail(v)
+	}
+
+	api.WriteOK(w, http.StatusOK, res)
+}
+
+func toFraudRule(r *storage.FraudRule) FraudRule {
+	return FraudRule{
+		ID:        r.ID,
+		Kind:      string(r.Kind),
+		Pattern:   r.Pattern,
+		Source:    r.Source,
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toAdminRequest(r *storage.Request) AdminRequest {
+	nullString := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		return &s.String
+	}
+
+	res := AdminRequest{
+		Owner:                    r.Owner,
+		Address:                  r.Address,
+		CreatedAt:                r.CreatedAt.Format(time.RFC3339),
+		OwnReferralCode:          r.OwnReferralCode,
+		RegistrationReferralCode: nullString(r.RegistrationReferralCode),
+		ReferralBanned:           r.ReferralBanned,
+		ReferralBanReason:        nullString(r.ReferralBanReason),
+		FailedAttempts:           r.FailedAttempts,
+	}
+
+	if r.ConfirmedAt.Valid {
+		v := r.ConfirmedAt.Time.Format(time.RFC3339)
+		res.ConfirmedAt = &v
+	}
+
+	return res
+}
+
+func toAdminEmail(e *storage.Email) AdminEmail {
+	res := AdminEmail{
+		ID:        e.ID,
+		Kind:      string(e.Kind),
+		Status:    string(e.Status),
+		Attempts:  e.Attempts,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		SentAt:    formatNullableTime(e.SentAt),
+	}
+
+	if e.Reason.Valid {
+		res.Reason = &e.Reason.String
+	}
+
+	if e.Status == storage.QueuedEmailStatus {
+		v := e.NextAttemptAt.Format(time.RFC3339)
+		res.NextAttemptAt = &v
+	}
+
+	return res
+}
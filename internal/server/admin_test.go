@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Decentr-net/vulcan/internal/service"
+	servicemock "github.com/Decentr-net/vulcan/internal/service/mock"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+const testAdminToken = "0123456789abcdef0123456789abcdef"
+
+func Test_Admin(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   []byte
+		mockFn func(a *servicemock.MockAdmin)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:   "no token",
+			method: http.MethodGet,
+			path:   "/admin/v1/fraud/rules",
+			rcode:  http.StatusUnauthorized,
+			rdata:  `{"error":"unauthorized"}`,
+		},
+		{
+			name:   "wrong token",
+			method: http.MethodGet,
+			path:   "/admin/v1/fraud/rules",
+			token:  "wrong",
+			rcode:  http.StatusUnauthorized,
+			rdata:  `{"error":"unauthorized"}`,
+		},
+		{
+			name:   "list fraud rules",
+			method: http.MethodGet,
+			path:   "/admin/v1/fraud/rules",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().GetFraudRules(gomock.Any()).Return([]*storage.FraudRule{
+					{ID: 1, Kind: storage.ExactFraudRuleKind, Pattern: "aircase.tk", Source: "migration", CreatedAt: createdAt},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[{"id":1,"kind":"exact","pattern":"aircase.tk","source":"migration","createdAt":"2026-10-17T00:00:00Z"}]`,
+		},
+		{
+			name:   "create fraud rule",
+			method: http.MethodPost,
+			path:   "/admin/v1/fraud/rules",
+			token:  testAdminToken,
+			body:   []byte(`{"kind":"wildcard","pattern":"aircase.tk","reason":"spam"}`),
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().CreateFraudRule(gomock.Any(), "root", storage.WildcardFraudRuleKind, "aircase.tk", "spam").Return(
+					&storage.FraudRule{ID: 2, Kind: storage.WildcardFraudRuleKind, Pattern: "aircase.tk", Source: "admin:root", CreatedAt: createdAt}, nil,
+				)
+			},
+			rcode: http.StatusCreated,
+			rdata: `{"id":2,"kind":"wildcard","pattern":"aircase.tk","source":"admin:root","createdAt":"2026-10-17T00:00:00Z"}`,
+		},
+		{
+			name:   "create existing fraud rule",
+			method: http.MethodPost,
+			path:   "/admin/v1/fraud/rules",
+			token:  testAdminToken,
+			body:   []byte(`{"kind":"wildcard","pattern":"aircase.tk"}`),
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().CreateFraudRule(gomock.Any(), "root", storage.WildcardFraudRuleKind, "aircase.tk", "").Return(nil, service.ErrFraudRuleExists)
+			},
+			rcode: http.StatusConflict,
+			rdata: `{"error":"rule already exists"}`,
+		},
+		{
+			name:   "delete fraud rule",
+			method: http.MethodDelete,
+			path:   "/admin/v1/fraud/rules/2?reason=mistake",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().DeleteFraudRule(gomock.Any(), "root", int64(2), "mistake")
+			},
+			rcode: http.StatusOK,
+			rdata: `{}`,
+		},
+		{
+			name:   "delete unknown fraud rule",
+			method: http.MethodDelete,
+			path:   "/admin/v1/fraud/rules/3",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().DeleteFraudRule(gomock.Any(), "root", int64(3), "").Return(service.ErrFraudRuleNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error":"not found"}`,
+		},
+		{
+			name:   "ban referral",
+			method: http.MethodPost,
+			path:   "/admin/v1/referral/" + testAddress + "/ban",
+			token:  testAdminToken,
+			body:   []byte(`{"reason":"fake installs"}`),
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().BanReferral(gomock.Any(), "root", testAddress, "fake installs")
+			},
+			rcode: http.StatusOK,
+			rdata: `{}`,
+		},
+		{
+			name:   "ban referral without reason",
+			method: http.MethodPost,
+			path:   "/admin/v1/referral/" + testAddress + "/ban",
+			token:  testAdminToken,
+			body:   []byte(`{"reason":" "}`),
+			rcode:  http.StatusBadRequest,
+			rdata:  `{"error":"reason is required"}`,
+		},
+		{
+			name:   "unban unknown referral",
+			method: http.MethodPost,
+			path:   "/admin/v1/referral/" + testAddress + "/unban",
+			token:  testAdminToken,
+			body:   []byte(`{"reason":"appealed"}`),
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().UnbanReferral(gomock.Any(), "root", testAddress, "appealed").Return(service.ErrRequestNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error":"not found"}`,
+		},
+		{
+			name:   "search requests",
+			method: http.MethodGet,
+			path:   "/admin/v1/requests?email=e@mail.com&limit=10",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().SearchRequests(gomock.Any(), service.RequestFilter{Email: "e@mail.com"}, 10).Return([]*storage.Request{
+					{
+						Owner:             "owner",
+						Email:             "enc:k1:e",
+						Address:           testAddress,
+						Code:              "secret",
+						CreatedAt:         createdAt,
+						OwnReferralCode:   "abcdef",
+						ReferralBanned:    true,
+						ReferralBanReason: sql.NullString{Valid: true, String: "fraud"},
+					},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[{
+				"owner":"owner",
+				"address":"` + testAddress + `",
+				"createdAt":"2026-10-17T00:00:00Z",
+				"confirmedAt":null,
+				"ownReferralCode":"abcdef",
+				"registrationReferralCode":null,
+				"referralBanned":true,
+				"referralBanReason":"fraud",
+				"failedAttempts":0
+			}]`,
+		},
+		{
+			name:   "search requests without filter",
+			method: http.MethodGet,
+			path:   "/admin/v1/requests",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().SearchRequests(gomock.Any(), service.RequestFilter{}, 100).Return(nil, service.ErrEmptyFilter)
+			},
+			rcode: http.StatusBadRequest,
+			rdata: `{"error":"at least one filter is required"}`,
+		},
+		{
+			name:   "list audit records",
+			method: http.MethodGet,
+			path:   "/admin/v1/audit?target=" + testAddress,
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().GetAuditRecords(gomock.Any(), testAddress, 100).Return([]*storage.AuditRecord{
+					{ID: 1, Actor: "root", Action: storage.BanReferralAuditAction, Target: testAddress, Reason: "fraud", CreatedAt: createdAt},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[{"id":1,"actor":"root","action":"ban_referral","target":"` + testAddress + `","reason":"fraud","createdAt":"2026-10-17T00:00:00Z"}]`,
+		},
+		{
+			name:   "list emails",
+			method: http.MethodGet,
+			path:   "/admin/v1/requests/owner/emails?limit=10",
+			token:  testAdminToken,
+			mockFn: func(a *servicemock.MockAdmin) {
+				a.EXPECT().GetEmails(gomock.Any(), "owner", 10).Return([]*storage.Email{
+					{
+						ID:            2,
+						Kind:          storage.VerificationEmailKind,
+						Status:        storage.QueuedEmailStatus,
+						Attempts:      1,
+						Reason:        sql.NullString{Valid: true, String: "timeout"},
+						NextAttemptAt: createdAt.Add(time.Minute),
+						CreatedAt:     createdAt,
+					},
+					{
+						ID:            1,
+						Kind:          storage.VerificationEmailKind,
+						Status:        storage.SentEmailStatus,
+						NextAttemptAt: createdAt,
+						CreatedAt:     createdAt,
+						SentAt:        sql.NullTime{Valid: true, Time: createdAt},
+					},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `[{
+				"id":2,
+				"kind":"verification",
+				"status":"queued",
+				"attempts":1,
+				"reason":"timeout",
+				"createdAt":"2026-10-17T00:00:00Z",
+				"sentAt":null,
+				"nextAttemptAt":"2026-10-17T00:01:00Z"
+			},{
+				"id":1,
+				"kind":"verification",
+				"status":"sent",
+				"attempts":0,
+				"reason":null,
+				"createdAt":"2026-10-17T00:00:00Z",
+				"sentAt":"2026-10-17T00:00:00Z",
+				"nextAttemptAt":null
+			}]`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			a := servicemock.NewMockAdmin(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(a)
+			}
+
+			router := chi.NewRouter()
+			SetupRouter(nil, nil, router, time.Second, false, AuthConfig{}, CaptchaConfig{}, RateLimitConfig{}, AdminConfig{
+				Service: a,
+				Tokens:  map[string]string{testAdminToken: "root"},
+			}, ConfirmLinkConfig{})
+
+			r := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(tc.body))
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_Admin_Disabled(t *testing.T) {
+	router := chi.NewRouter()
+	SetupRouter(nil, nil, router, time.Second, false, AuthConfig{}, CaptchaConfig{}, RateLimitConfig{}, AdminConfig{}, ConfirmLinkConfig{})
+
+	r := httptest.NewRequest(http.MethodGet, "/admin/v1/fraud/rules", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -7,8 +7,15 @@ import (
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/go-openapi/strfmt"
+
+	"github.com/Decentr-net/decentr/config"
 )
 
+// nolint: gochecknoinits
+func init() {
+	config.SetAddressPrefixes()
+}
+
 var (
 	emailRegExp       = regexp.MustCompile("(?:[a-z0-9!#$%&'*+\\/=?^_`{|}~-]+(?:\\.[a-z0-9!#$%&'*+\\/=?^_`{|}~-]+)*|\"(?:[\\x01-\\x08\\x0b\\x0c\\x0e-\\x1f\\x21\\x23-\\x5b\\x5d-\\x7f]|\\\\[\\x01-\\x09\\x0b\\x0c\\x0e-\\x7f])*\")@(?:(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\\.)+[a-z0-9](?:[a-z0-9-]*[a-z0-9])?|\\[(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?|[a-z0-9-]*[a-z0-9]:(?:[\\x01-\\x08\\x0b\\x0c\\x0e-\\x1f\\x21-\\x5a\\x53-\\x7f]|\\\\[\\x01-\\x09\\x0b\\x0c\\x0e-\\x7f])+)\\])") // nolint
 	errInvalidRequest = errors.New("invalid request")
@@ -22,10 +29,30 @@ type EmptyResponse struct{}
 // swagger:model
 type RegisterRequest struct {
 	// required: true
-	Email             strfmt.Email `json:"email"`
-	Address           string       `json:"address"`
-	ReferralCode      *string      `json:"referralCode"`
-	RecaptchaResponse string       `json:"recaptchaResponse"`
+	Email        strfmt.Email `json:"email"`
+	Address      string       `json:"address"`
+	ReferralCode *string      `json:"referralCode"`
+	// Challenge is a nonce returned by /v1/register/challenge, it's required when the request is signed.
+	Challenge string `json:"challenge"`
+	// RecaptchaResponse is a captcha response, it can be passed with X-Captcha-Response header as well.
+	RecaptchaResponse string `json:"recaptchaResponse"`
+}
+
+// RegisterResponse ...
+// swagger:model
+type RegisterResponse struct {
+	// StatusProof lets to query the registration status, see /v1/register/status.
+	// It's invalidated when the registration is replaced and it's empty if it couldn't be issued.
+	StatusProof string `json:"statusProof"`
+}
+
+// ChallengeResponse ...
+// swagger:model
+type ChallengeResponse struct {
+	// Challenge is a nonce to be passed with the signed registration request.
+	Challenge string `json:"challenge"`
+	// ExpiresAt is RFC3339 time the challenge is valid till.
+	ExpiresAt string `json:"expiresAt"`
 }
 
 // ConfirmRequest ...
@@ -35,6 +62,37 @@ type ConfirmRequest struct {
 	Code  string `json:"code"`
 }
 
+// ResendRequest ...
+// swagger:model
+type ResendRequest struct {
+	// required: true
+	Email string `json:"email"`
+	// RecaptchaResponse is a captcha response, it can be passed with X-Captcha-Response header as well.
+	RecaptchaResponse string `json:"recaptchaResponse"`
+}
+
+// ResendResponse ...
+// swagger:model
+type ResendResponse struct {
+	// NextResendAt is RFC3339 time the next resend is allowed at, it's null if no more resends are allowed.
+	NextResendAt *string `json:"nextResendAt"`
+}
+
+// RegistrationStatus ...
+// swagger:model
+type RegistrationStatus struct {
+	// enum: pending,confirmed,payout_pending,paid,failed
+	State       string  `json:"state"`
+	Address     string  `json:"address"`
+	CreatedAt   string  `json:"createdAt"`
+	ConfirmedAt *string `json:"confirmedAt"`
+	PaidAt      *string `json:"paidAt"`
+	// PayoutTxHash is a hash of the initial stakes transaction, it's set once the transaction is broadcast.
+	PayoutTxHash *string `json:"payoutTxHash"`
+	// NextResendAt is RFC3339 time the next code resend is allowed at, it's null if no more resends are allowed.
+	NextResendAt *string `json:"nextResendAt"`
+}
+
 // DLoanRequest ...
 // swagger:model
 type DLoanRequest struct {
@@ -77,6 +135,73 @@ type ReferralTrackingStatsResponse struct {
 	Last30Days ReferralTrackingStatsItem `json:"last30Days"`
 }
 
+// FraudRule ...
+// swagger:model
+type FraudRule struct {
+	ID int64 `json:"id"`
+	// Kind is one of exact, wildcard, regex or mx.
+	Kind      string `json:"kind"`
+	Pattern   string `json:"pattern"`
+	Source    string `json:"source"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// CreateFraudRuleRequest ...
+// swagger:model
+type CreateFraudRuleRequest struct {
+	// Kind is one of exact, wildcard, regex or mx.
+	// required: true
+	Kind string `json:"kind"`
+	// required: true
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+// ReasonRequest ...
+// swagger:model
+type ReasonRequest struct {
+	// required: true
+	Reason string `json:"reason"`
+}
+
+// AdminRequest is a registration request. Email isn't exposed since it's stored encrypted.
+// swagger:model
+type AdminRequest struct {
+	Owner                    string  `json:"owner"`
+	Address                  string  `json:"address"`
+	CreatedAt                string  `json:"createdAt"`
+	ConfirmedAt              *string `json:"confirmedAt"`
+	OwnReferralCode          string  `json:"ownReferralCode"`
+	RegistrationReferralCode *string `json:"registrationReferralCode"`
+	ReferralBanned           bool    `json:"referralBanned"`
+	ReferralBanReason        *string `json:"referralBanReason"`
+	FailedAttempts           int     `json:"failedAttempts"`
+}
+
+// AuditRecord ...
+// swagger:model
+type AuditRecord struct {
+	ID        int64  `json:"id"`
+	Actor     string `json:"actor"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// AdminEmail is an outbox email. Recipient and template params aren't exposed.
+// swagger:model
+type AdminEmail struct {
+	ID            int64   `json:"id"`
+	Kind          string  `json:"kind"`
+	Status        string  `json:"status"`
+	Attempts      int     `json:"attempts"`
+	Reason        *string `json:"reason"`
+	CreatedAt     string  `json:"createdAt"`
+	SentAt        *string `json:"sentAt"`
+	NextAttemptAt *string `json:"nextAttemptAt"`
+}
+
 // RegisterStats ...
 // swagger:model
 type RegisterStats struct {
@@ -99,8 +224,12 @@ func (r RegisterRequest) validate() error {
 		return fmt.Errorf("%w: invalid address", errInvalidRequest)
 	}
 
-	if r.ReferralCode != nil && len(r.RecaptchaResponse) == 0 {
-		return fmt.Errorf("%w: empty recaptcha response", errInvalidRequest)
+	return nil
+}
+
+func (r ResendRequest) validate() error {
+	if !isEmailValid(r.Email) {
+		return fmt.Errorf("%w: invalid email", errInvalidRequest)
 	}
 
 	return nil
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/go-api"
+
+	"github.com/Decentr-net/vulcan/internal/service"
+)
+
+//go:generate mockgen -destination=./mock/verifier.go -package=mock -source=auth.go
+
+// SignaturePolicy defines how an address-scoped route checks the request signature.
+type SignaturePolicy string
+
+const (
+	// SignatureDisabled means the route doesn't check signatures at all.
+	SignatureDisabled SignaturePolicy = "disabled"
+	// SignatureOptional means the signature is checked only if the request carries one.
+	SignatureOptional SignaturePolicy = "optional"
+	// SignatureRequired means unsigned requests are rejected.
+	SignatureRequired SignaturePolicy = "required"
+)
+
+// AuthConfig contains signature policies of address-scoped routes.
+type AuthConfig struct {
+	Verifier Verifier
+
+	// Register is a policy of wallet ownership proof at registration, signed requests should carry a challenge.
+	Register     SignaturePolicy
+	TrackInstall SignaturePolicy
+	DLoan        SignaturePolicy
+}
+
+// Verifier verifies request's signature.
+type Verifier interface {
+	// Verify checks request's signature and returns address of the signer.
+	Verify(r *http.Request) (sdk.AccAddress, error)
+}
+
+type verifier struct{}
+
+// NewVerifier returns Verifier based on go-api signature helpers.
+func NewVerifier() Verifier {
+	return verifier{}
+}
+
+// Verify ...
+func (verifier) Verify(r *http.Request) (sdk.AccAddress, error) {
+	if err := api.Verify(r); err != nil {
+		return nil, err
+	}
+
+	address, err := api.GetAddressFromPubKey(r.Header.Get(api.PublicKeyHeader))
+	if err != nil {
+		return nil, api.ErrInvalidPublicKey
+	}
+
+	return address, nil
+}
+
+// addressExtractor returns the address the request is acting for.
+type addressExtractor func(r *http.Request) (string, error)
+
+// pathAddress extracts address from the url param.
+func pathAddress(param string) addressExtractor {
+	return func(r *http.Request) (string, error) {
+		return chi.URLParam(r, param), nil
+	}
+}
+
+// bodyAddress extracts address from the json body field. The body is kept readable for the next handler.
+func bodyAddress(field string) addressExtractor {
+	return func(r *http.Request) (string, error) {
+		return bodyField(r, field)
+	}
+}
+
+// bodyField returns string field of the json body. The body is kept readable for the next handler.
+func bodyField(r *http.Request, field string) (string, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read body", errInvalidRequest)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return "", fmt.Errorf("%w: invalid body", errInvalidRequest)
+	}
+
+	v, _ := fields[field].(string)
+
+	return v, nil
+}
+
+// signedBy returns middleware which checks the request is signed by owner of the extracted address.
+func signedBy(policy SignaturePolicy, v Verifier, extract addressExtractor) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch policy {
+			case SignatureRequired:
+			case SignatureOptional:
+				if !isSigned(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			signer, err := v.Verify(r)
+			if err != nil {
+				api.WriteVerifyError(r.Context(), w, err)
+				return
+			}
+
+			address, err := extract(r)
+			if err != nil {
+				api.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			owner, err := sdk.AccAddressFromBech32(address)
+			if err != nil {
+				api.WriteError(w, http.StatusBadRequest, "invalid address")
+				return
+			}
+
+			if !owner.Equals(signer) {
+				api.WriteError(w, http.StatusForbidden, "request is not signed by address owner")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// challenged returns middleware which checks the challenge carried by the signed request body and
+// consumes it once the request is accepted, so the signature can't be replayed while a rejected request
+// doesn't burn the challenge. It should follow signedBy with the same policy.
+func challenged(policy SignaturePolicy, s service.Service, extract addressExtractor) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch policy {
+			case SignatureRequired:
+			case SignatureOptional:
+				if !isSigned(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			nonce, err := bodyField(r, "challenge")
+			if err != nil {
+				api.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if nonce == "" {
+				api.WriteError(w, http.StatusBadRequest, "challenge is required")
+				return
+			}
+
+			address, err := extract(r)
+			if err != nil {
+				api.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			if err := s.CheckChallenge(r.Context(), address, nonce); err != nil {
+				if errors.Is(err, service.ErrInvalidChallenge) {
+					api.WriteError(w, http.StatusForbidden, "challenge is invalid or expired")
+					return
+				}
+				api.WriteInternalErrorf(r.Context(), w, err, "failed to check challenge")
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			if ww.Status() < http.StatusOK || ww.Status() >= http.StatusMultipleChoices {
+				return
+			}
+
+			if err := s.ConsumeChallenge(r.Context(), address, nonce); err != nil {
+				logrus.WithError(err).WithField("address", address).Error("failed to consume challenge")
+			}
+		})
+	}
+}
+
+func isSigned(r *http.Request) bool {
+	return r.Header.Get(api.PublicKeyHeader) != "" || r.Header.Get(api.SignatureHeader) != ""
+}
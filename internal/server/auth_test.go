@@ -0,0 +1,349 @@
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+
+	"github.com/Decentr-net/go-api"
+	"github.com/Decentr-net/go-api/test"
+
+	servermock "github.com/Decentr-net/vulcan/internal/server/mock"
+	"github.com/Decentr-net/vulcan/internal/service"
+	servicemock "github.com/Decentr-net/vulcan/internal/service/mock"
+)
+
+const otherAddress = "decentr1vg085ra5hw8mx5rrheqf8fruks0xv4urqkuqga"
+
+func Test_signedBy(t *testing.T) {
+	owner, err := sdk.AccAddressFromBech32(testAddress)
+	require.NoError(t, err)
+	other, err := sdk.AccAddressFromBech32(otherAddress)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name    string
+		policy  SignaturePolicy
+		path    string
+		body    []byte
+		signed  bool
+		extract addressExtractor
+		mockFn  func(v *servermock.MockVerifier)
+		rcode   int
+	}{
+		{
+			name:    "disabled",
+			policy:  SignatureDisabled,
+			path:    testAddress,
+			signed:  true,
+			extract: pathAddress("address"),
+			rcode:   http.StatusOK,
+		},
+		{
+			name:    "optional without signature",
+			policy:  SignatureOptional,
+			path:    testAddress,
+			extract: pathAddress("address"),
+			rcode:   http.StatusOK,
+		},
+		{
+			name:    "optional with signature",
+			policy:  SignatureOptional,
+			path:    testAddress,
+			signed:  true,
+			extract: pathAddress("address"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(other, nil)
+			},
+			rcode: http.StatusForbidden,
+		},
+		{
+			name:    "required without signature",
+			policy:  SignatureRequired,
+			path:    testAddress,
+			extract: pathAddress("address"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(nil, api.ErrInvalidPublicKey)
+			},
+			rcode: http.StatusBadRequest,
+		},
+		{
+			name:    "not verified",
+			policy:  SignatureRequired,
+			path:    testAddress,
+			signed:  true,
+			extract: pathAddress("address"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(nil, api.ErrNotVerified)
+			},
+			rcode: http.StatusUnauthorized,
+		},
+		{
+			name:    "path address",
+			policy:  SignatureRequired,
+			path:    testAddress,
+			signed:  true,
+			extract: pathAddress("address"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(owner, nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:    "body address",
+			policy:  SignatureRequired,
+			path:    "any",
+			body:    []byte(`{"walletAddress":"` + testAddress + `"}`),
+			signed:  true,
+			extract: bodyAddress("walletAddress"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(owner, nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:    "body address mismatch",
+			policy:  SignatureRequired,
+			path:    "any",
+			body:    []byte(`{"walletAddress":"` + otherAddress + `"}`),
+			signed:  true,
+			extract: bodyAddress("walletAddress"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(owner, nil)
+			},
+			rcode: http.StatusForbidden,
+		},
+		{
+			name:    "invalid body",
+			policy:  SignatureRequired,
+			path:    "any",
+			body:    []byte(`{`),
+			signed:  true,
+			extract: bodyAddress("walletAddress"),
+			mockFn: func(v *servermock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any()).Return(owner, nil)
+			},
+			rcode: http.StatusBadRequest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, tc.path, tc.body)
+			if tc.signed {
+				r.Header.Set(api.PublicKeyHeader, "00")
+				r.Header.Set(api.SignatureHeader, "00")
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			v := servermock.NewMockVerifier(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(v)
+			}
+
+			var body []byte
+			router := chi.NewRouter()
+			router.With(signedBy(tc.policy, v, tc.extract)).Post("/{address}", func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			if tc.rcode == http.StatusOK {
+				assert.Equal(t, string(tc.body), string(body))
+			}
+		})
+	}
+}
+
+func Test_challenged(t *testing.T) {
+	tt := []struct {
+		name   string
+		policy SignaturePolicy
+		body   string
+		signed bool
+		hcode  int
+		mockFn func(s *servicemock.MockService)
+		rcode  int
+	}{
+		{
+			name:   "disabled",
+			policy: SignatureDisabled,
+			body:   `{"address":"` + testAddress + `"}`,
+			signed: true,
+			rcode:  http.StatusOK,
+		},
+		{
+			name:   "optional without signature",
+			policy: SignatureOptional,
+			body:   `{"address":"` + testAddress + `"}`,
+			rcode:  http.StatusOK,
+		},
+		{
+			name:   "optional with signature",
+			policy: SignatureOptional,
+			body:   `{"address":"` + testAddress + `","challenge":"nonce"}`,
+			signed: true,
+			mockFn: func(s *servicemock.MockService) {
+				s.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(nil)
+				s.EXPECT().ConsumeChallenge(gomock.Any(), testAddress, "nonce").Return(nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:   "rejected request",
+			policy: SignatureRequired,
+			body:   `{"address":"` + testAddress + `","challenge":"nonce"}`,
+			signed: true,
+			hcode:  http.StatusTooManyRequests,
+			mockFn: func(s *servicemock.MockService) {
+				s.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(nil)
+			},
+			rcode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "consume error",
+			policy: SignatureRequired,
+			body:   `{"address":"` + testAddress + `","challenge":"nonce"}`,
+			signed: true,
+			mockFn: func(s *servicemock.MockService) {
+				s.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(nil)
+				s.EXPECT().ConsumeChallenge(gomock.Any(), testAddress, "nonce").Return(errTest)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:   "without challenge",
+			policy: SignatureRequired,
+			body:   `{"address":"` + testAddress + `"}`,
+			signed: true,
+			rcode:  http.StatusBadRequest,
+		},
+		{
+			name:   "invalid challenge",
+			policy: SignatureRequired,
+			body:   `{"address":"` + testAddress + `","challenge":"nonce"}`,
+			signed: true,
+			mockFn: func(s *servicemock.MockService) {
+				s.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(service.ErrInvalidChallenge)
+			},
+			rcode: http.StatusForbidden,
+		},
+		{
+			name:   "internal error",
+			policy: SignatureRequired,
+			body:   `{"address":"` + testAddress + `","challenge":"nonce"}`,
+			signed: true,
+			mockFn: func(s *servicemock.MockService) {
+				s.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(errTest)
+			},
+			rcode: http.StatusInternalServerError,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "register", []byte(tc.body))
+			if tc.signed {
+				r.Header.Set(api.PublicKeyHeader, "00")
+				r.Header.Set(api.SignatureHeader, "00")
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(s)
+			}
+
+			router := chi.NewRouter()
+			router.With(challenged(tc.policy, s, bodyAddress("address"))).Post("/register", func(w http.ResponseWriter, r *http.Request) {
+				if tc.hcode != 0 {
+					w.WriteHeader(tc.hcode)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+		})
+	}
+}
+
+func Test_signedRegistration(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+	address := sdk.AccAddress(pk.PubKey().Address()).String()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := servicemock.NewMockService(ctrl)
+
+	router := chi.NewRouter()
+	router.With(
+		signedBy(SignatureRequired, NewVerifier(), bodyAddress("address")),
+		challenged(SignatureRequired, s, bodyAddress("address")),
+	).Post("/v1/register", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func(address string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewReader([]byte(`{"address":"`+address+`","challenge":"nonce"}`)))
+		require.NoError(t, api.Sign(r, pk))
+		return r
+	}
+
+	s.EXPECT().CheckChallenge(gomock.Any(), address, "nonce").Return(nil)
+	s.EXPECT().ConsumeChallenge(gomock.Any(), address, "nonce").Return(nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest(address))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// replay
+	s.EXPECT().CheckChallenge(gomock.Any(), address, "nonce").Return(service.ErrInvalidChallenge)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest(address))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// someone else's address
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest(testAddress))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	pk := secp256k1.GenPrivKey()
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/dloan", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, api.Sign(r, pk))
+
+	address, err := NewVerifier().Verify(r)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.AccAddress(pk.PubKey().Address()), address)
+
+	r.URL.Path = "/v1/dloan/other"
+	_, err = NewVerifier().Verify(r)
+	assert.ErrorIs(t, err, api.ErrNotVerified)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+
+	"github.com/Decentr-net/go-api"
+
+	"github.com/Decentr-net/vulcan/internal/captcha"
+	"github.com/Decentr-net/vulcan/internal/fraud"
+	"github.com/Decentr-net/vulcan/internal/owner"
+)
+
+// CaptchaResponseHeader is a header carrying captcha response.
+const CaptchaResponseHeader = "X-Captcha-Response"
+
+// CaptchaPolicy defines when a route demands captcha.
+type CaptchaPolicy string
+
+const (
+	// CaptchaDisabled means the route never demands captcha.
+	CaptchaDisabled CaptchaPolicy = "disabled"
+	// CaptchaAdaptive means captcha is demanded only from suspicious IPs, email owners and email domains.
+	CaptchaAdaptive CaptchaPolicy = "adaptive"
+	// CaptchaRequired means captcha is demanded on every request.
+	CaptchaRequired CaptchaPolicy = "required"
+)
+
+// CaptchaConfig contains captcha policies of routes.
+type CaptchaConfig struct {
+	Verifier captcha.Verifier
+	// Tracker finds out suspicious IPs and email owners for adaptive policy.
+	Tracker *captcha.Tracker
+	// DomainTracker finds out suspicious email domains for adaptive policy. Its threshold should be higher,
+	// since many users share domains of free mail providers.
+	DomainTracker *captcha.Tracker
+	// Hasher computes email owners the adaptive policy tracks, so aliases of the same mailbox are counted together.
+	Hasher *owner.Hasher
+
+	Register CaptchaPolicy
+	Resend   CaptchaPolicy
+	Confirm  CaptchaPolicy
+	DLoan    CaptchaPolicy
+	Hesoyam  CaptchaPolicy
+}
+
+// captchaProtected returns middleware which demands captcha according to the policy.
+// In adaptive mode every request is a signal for the client's IP, email owner and email domain,
+// a rejected request is one more.
+func captchaProtected(policy CaptchaPolicy, cfg CaptchaConfig, action string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch policy {
+			case CaptchaRequired:
+			case CaptchaAdaptive:
+				keys, domainKeys := riskKeys(r, cfg.Hasher, action)
+				suspicious := cfg.Tracker.Suspicious(keys...) || cfg.DomainTracker.Suspicious(domainKeys...)
+				signal := func() {
+					cfg.Tracker.Add(keys...)
+					cfg.DomainTracker.Add(domainKeys...)
+				}
+				signal()
+
+				if !suspicious {
+					ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+					next.ServeHTTP(ww, r)
+
+					if ww.Status() >= http.StatusBadRequest && ww.Status() < http.StatusInternalServerError {
+						signal()
+					}
+					return
+				}
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := cfg.Verifier.Verify(r.Context(), action, captchaResponse(r)); err != nil {
+				if !errors.Is(err, captcha.ErrNotPassed) {
+					api.WriteInternalErrorf(r.Context(), w, err, "failed to check captcha")
+					return
+				}
+				api.WriteError(w, http.StatusLocked, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// captchaResponse returns captcha response from the header or from recaptchaResponse body field.
+func captchaResponse(r *http.Request) string {
+	if v := r.Header.Get(CaptchaResponseHeader); v != "" {
+		return v
+	}
+
+	if r.Body == nil || r.Method == http.MethodGet {
+		return ""
+	}
+
+	v, _ := bodyField(r, "recaptchaResponse")
+
+	return v
+}
+
+// riskKeys returns keys of the request's IP and email owner and, separately, key of email domain
+// if the body contains email.
+func riskKeys(r *http.Request, hasher *owner.Hasher, action string) ([]string, []string) {
+	keys := []string{action + ":ip:" + remoteIP(r)}
+
+	if r.Body == nil || r.Method == http.MethodGet {
+		return keys, nil
+	}
+
+	email, _ := bodyField(r, "email")
+	if email == "" {
+		return keys, nil
+	}
+
+	keys = append(keys, action+":owner:"+hasher.Hash(email))
+
+	if domain := fraud.Domain(email); domain != "" {
+		return keys, []string{action + ":domain:" + domain}
+	}
+
+	return keys, nil
+}
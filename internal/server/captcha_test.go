@@ -0,0 +1,163 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Decentr-net/go-api/test"
+
+	"github.com/Decentr-net/vulcan/internal/captcha"
+	captchamock "github.com/Decentr-net/vulcan/internal/captcha/mock"
+)
+
+func Test_captchaProtected(t *testing.T) {
+	tt := []struct {
+		name   string
+		policy CaptchaPolicy
+		header string
+		body   []byte
+		mockFn func(v *captchamock.MockVerifier)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:   "disabled",
+			policy: CaptchaDisabled,
+			body:   []byte(`{}`),
+			rcode:  http.StatusOK,
+		},
+		{
+			name:   "header",
+			policy: CaptchaRequired,
+			header: "123",
+			body:   []byte(`{"recaptchaResponse":"456"}`),
+			mockFn: func(v *captchamock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any(), "register", "123").Return(nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:   "body",
+			policy: CaptchaRequired,
+			body:   []byte(`{"recaptchaResponse":"456"}`),
+			mockFn: func(v *captchamock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any(), "register", "456").Return(nil)
+			},
+			rcode: http.StatusOK,
+		},
+		{
+			name:   "not passed",
+			policy: CaptchaRequired,
+			body:   []byte(`{}`),
+			mockFn: func(v *captchamock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any(), "register", "").Return(captcha.ErrNotPassed)
+			},
+			rcode: http.StatusLocked,
+			rdata: `{"error":"captcha is not passed"}`,
+		},
+		{
+			name:   "error",
+			policy: CaptchaRequired,
+			header: "123",
+			body:   []byte(`{}`),
+			mockFn: func(v *captchamock.MockVerifier) {
+				v.EXPECT().Verify(gomock.Any(), "register", "123").Return(errTest)
+			},
+			rcode: http.StatusInternalServerError,
+			rdata: `{"error":"internal error"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/register", tc.body)
+			if tc.header != "" {
+				r.Header.Set(CaptchaResponseHeader, tc.header)
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			v := captchamock.NewMockVerifier(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(v)
+			}
+
+			var body []byte
+			router := chi.NewRouter()
+			router.With(captchaProtected(tc.policy, CaptchaConfig{Verifier: v}, "register")).
+				Post("/v1/register", func(w http.ResponseWriter, r *http.Request) {
+					body, _ = ioutil.ReadAll(r.Body)
+					w.WriteHeader(http.StatusOK)
+				})
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			if tc.rcode == http.StatusOK {
+				assert.Equal(t, string(tc.body), string(body))
+			} else {
+				assert.JSONEq(t, tc.rdata, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_captchaProtected_Adaptive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	v := captchamock.NewMockVerifier(ctrl)
+	cfg := CaptchaConfig{
+		Verifier:      v,
+		Tracker:       captcha.NewTracker(time.Hour, 3),
+		DomainTracker: captcha.NewTracker(time.Hour, 6),
+		Hasher:        newTestHasher(t),
+	}
+
+	rcode := http.StatusBadRequest
+	router := chi.NewRouter()
+	router.With(captchaProtected(CaptchaAdaptive, cfg, "register")).
+		Post("/v1/register", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(rcode)
+		})
+
+	do := func(ip, email string) int {
+		r := httptest.NewRequest(http.MethodPost, "/v1/register", strings.NewReader(`{"email":"`+email+`"}`))
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// rejected request counts twice
+	assert.Equal(t, http.StatusBadRequest, do("10.0.0.1", "john@gmail.com"))
+	rcode = http.StatusOK
+	assert.Equal(t, http.StatusOK, do("10.0.0.2", "John+acc1@gmail.com"))
+
+	// the owner is suspicious now, aliases included
+	v.EXPECT().Verify(gomock.Any(), "register", "").Return(captcha.ErrNotPassed)
+	assert.Equal(t, http.StatusLocked, do("10.0.0.3", "j.o.h.n@googlemail.com"))
+
+	// other mailboxes of the same domain aren't affected till the domain threshold
+	assert.Equal(t, http.StatusOK, do("10.0.0.3", "jane@gmail.com"))
+
+	// a burst spread across mailboxes of one domain makes the domain suspicious
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, http.StatusOK, do(fmt.Sprintf("10.0.1.%d", i), fmt.Sprintf("bot%d@mailinator.com", i)))
+	}
+	v.EXPECT().Verify(gomock.Any(), "register", "").Return(captcha.ErrNotPassed)
+	assert.Equal(t, http.StatusLocked, do("10.0.1.10", "bot10@mailinator.com"))
+}
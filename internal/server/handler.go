@@ -1,10 +1,12 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -26,6 +28,7 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation POST /v1/register Vulcan Register
 	//
 	// Sends confirmation link via email. After confirmation stakes will be sent.
+	// The request can be signed by the address owner to prove wallet ownership, then it should carry a challenge.
 	//
 	// ---
 	// produces:
@@ -38,11 +41,23 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 	//   required: true
 	//   schema:
 	//     '$ref': '#/definitions/RegisterRequest'
+	// - name: X-Captcha-Response
+	//   in: header
+	//   type: string
+	//   description: captcha response, it's demanded according to the captcha policy.
+	// - name: Public-Key
+	//   in: header
+	//   type: string
+	//   description: hex encoded secp256k1 public key of the address owner, it's demanded according to the signature policy.
+	// - name: Signature
+	//   in: header
+	//   type: string
+	//   description: hex encoded signature of the body followed by the url path.
 	// responses:
 	//   '200':
 	//     description: confirmation link was sent.
 	//     schema:
-	//       "$ref": "#/definitions/EmptyResponse"
+	//       "$ref": "#/definitions/RegisterResponse"
 	//   '400':
 	//      description: bad request.
 	//      schema:
@@ -51,8 +66,20 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 	//      description: referral code not found.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '423':
+	//      description: captcha is not passed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '429':
-	//      description: minute didn't pass after last try to send email
+	//      description: minute didn't pass after last try to send email or rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: signature is not verified.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: request is not signed by address owner or challenge is invalid.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
 	//   '409':
@@ -75,24 +102,13 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ReferralCode != nil {
-		if err := s.s.CheckRecaptcha(r.Context(), "register", req.RecaptchaResponse); err != nil {
-			if !errors.Is(err, service.ErrRecaptcha) {
-				api.WriteInternalErrorf(r.Context(), w, err, "failed to check recaptcha")
-				return
-			}
-			api.WriteError(w, http.StatusLocked, err.Error())
-			return
-		}
-	}
-
 	if err := s.s.Register(r.Context(), req.Email.String(), req.Address, req.ReferralCode); err != nil {
 		switch {
 		case errors.Is(err, service.ErrTooManyAttempts):
 			api.WriteError(w, http.StatusTooManyRequests, "too many attempts")
 		case errors.Is(err, service.ErrFraudEmail):
 			logrus.WithField("request", req).WithError(err).Warn("registration from fraud domain")
-			api.WriteError(w, http.StatusBadRequest, err.Error())
+			api.WriteError(w, http.StatusBadRequest, service.ErrFraudEmail.Error())
 		case errors.Is(err, service.ErrAlreadyExists):
 			api.WriteError(w, http.StatusConflict, "email or address is already taken")
 		case errors.Is(err, service.ErrReferralCodeNotFound):
@@ -107,7 +123,257 @@ func (s *server) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.WriteOK(w, http.StatusOK, EmptyResponse{})
+	// the registration is done, so the request isn't failed if the proof can't be issued
+	statusProof, err := s.s.IssueStatusProof(r.Context(), req.Email.String(), req.Address)
+	if err != nil {
+		logrus.WithField("address", req.Address).WithError(err).Warn("failed to issue status proof")
+	}
+
+	api.WriteOK(w, http.StatusOK, RegisterResponse{
+		StatusProof: statusProof,
+	})
+}
+
+// getChallenge returns nonce to be signed by the address owner at registration.
+func (s *server) getChallenge(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/register/challenge Vulcan GetChallenge
+	//
+	// Returns nonce to be passed with the registration request signed by the address owner.
+	// The latest challenges of the address are valid, each can be used once and only an accepted registration uses it.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: query
+	//   type: string
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: challenge.
+	//     schema:
+	//       "$ref": "#/definitions/ChallengeResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	address := r.URL.Query().Get("address")
+	if !isAddressValid(address) {
+		api.WriteError(w, http.StatusBadRequest, "invalid address")
+		return
+	}
+
+	nonce, expiresAt, err := s.s.IssueChallenge(r.Context(), address)
+	if err != nil {
+		api.WriteInternalErrorf(r.Context(), w, err, "failed to issue challenge")
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, ChallengeResponse{
+		Challenge: nonce,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// resend reissues confirmation code and sends it again.
+func (s *server) resend(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /v1/register/resend Vulcan Resend
+	//
+	// Sends a new confirmation code if the previous email is lost.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: request
+	//   in: body
+	//   required: true
+	//   schema:
+	//     '$ref': '#/definitions/ResendRequest'
+	// - name: X-Captcha-Response
+	//   in: header
+	//   type: string
+	//   description: captcha response, it's demanded according to the captcha policy.
+	// responses:
+	//   '200':
+	//     description: new code was sent.
+	//     schema:
+	//       "$ref": "#/definitions/ResendResponse"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: request not found.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '409':
+	//      description: request is already confirmed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '423':
+	//      description: captcha is not passed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: resend interval didn't pass, see Retry-After header, resend limit is reached or rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var req ResendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	next, err := s.s.Resend(r.Context(), req.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		case errors.Is(err, service.ErrAlreadyConfirmed):
+			api.WriteError(w, http.StatusConflict, "already confirmed")
+		case errors.Is(err, service.ErrTooManyAttempts):
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(time.Until(next))))
+			api.WriteError(w, http.StatusTooManyRequests, "too many attempts")
+		case errors.Is(err, service.ErrResendLimit):
+			api.WriteError(w, http.StatusTooManyRequests, "resend limit is reached, register again")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to resend code")
+		}
+		return
+	}
+
+	api.WriteOK(w, http.StatusOK, ResendResponse{
+		NextResendAt: formatNullableTime(sql.NullTime{Valid: !next.IsZero(), Time: next}),
+	})
+}
+
+// getRegistrationStatus returns state of the registration.
+func (s *server) getRegistrationStatus(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/register/status Vulcan GetRegistrationStatus
+	//
+	// Returns state of the registration found by address or by status proof returned on registration.
+	//
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: address
+	//   in: query
+	//   type: string
+	//   description: registration address, either address or proof is required.
+	// - name: proof
+	//   in: query
+	//   type: string
+	//   description: status proof returned by register method.
+	// responses:
+	//   '200':
+	//     description: registration status.
+	//     schema:
+	//       "$ref": "#/definitions/RegistrationStatus"
+	//   '400':
+	//      description: bad request.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: proof is invalid or expired.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '404':
+	//      description: request not found.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '500':
+	//      description: internal server error.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	var (
+		address = r.URL.Query().Get("address")
+		proof   = r.URL.Query().Get("proof")
+
+		status *service.RegistrationStatus
+		err    error
+	)
+
+	switch {
+	case address != "" && proof != "":
+		api.WriteError(w, http.StatusBadRequest, "either address or proof should be passed")
+		return
+	case address != "":
+		if !isAddressValid(address) {
+			api.WriteError(w, http.StatusBadRequest, "invalid address")
+			return
+		}
+		status, err = s.s.GetRegistrationStatusByAddress(r.Context(), address)
+	case proof != "":
+		status, err = s.s.GetRegistrationStatusByProof(r.Context(), proof)
+	default:
+		api.WriteError(w, http.StatusBadRequest, "address or proof is required")
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidProof):
+			api.WriteError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrRequestNotFound):
+			api.WriteError(w, http.StatusNotFound, "not found")
+		default:
+			api.WriteInternalErrorf(r.Context(), w, err, "failed to get registration status")
+		}
+		return
+	}
+
+	resp := RegistrationStatus{
+		State:        string(status.State),
+		Address:      status.Address,
+		CreatedAt:    status.CreatedAt.UTC().Format(time.RFC3339),
+		ConfirmedAt:  formatNullableTime(status.ConfirmedAt),
+		PaidAt:       formatNullableTime(status.PaidAt),
+		NextResendAt: formatNullableTime(sql.NullTime{Valid: !status.NextResendAt.IsZero(), Time: status.NextResendAt}),
+	}
+	if status.TxHash.Valid {
+		resp.PayoutTxHash = &status.TxHash.String
+	}
+
+	api.WriteOK(w, http.StatusOK, resp)
+}
+
+// formatNullableTime returns RFC3339 time or nil if t isn't valid.
+func formatNullableTime(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+
+	v := t.Time.UTC().Format(time.RFC3339)
+	return &v
 }
 
 // getRegisterStats ...
@@ -151,6 +417,73 @@ func (s *server) getRegisterStats(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ConfirmLinkConfig contains urls the confirmation link redirects to.
+type ConfirmLinkConfig struct {
+	SuccessURL string
+	// FailureURL gets reason query param: invalid, expired, replaced, confirmed, locked or error.
+	FailureURL string
+}
+
+// confirmLink confirms registration by the link from the verification email.
+func (s *server) confirmLink(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation GET /v1/confirm/link Vulcan ConfirmLink
+	//
+	// Confirms registration by the link sent with the verification email and redirects to the success or failure page.
+	//
+	// ---
+	// parameters:
+	// - name: token
+	//   in: query
+	//   type: string
+	//   required: true
+	// responses:
+	//   '302':
+	//     description: redirect to the success page or to the failure page with reason query param (invalid, expired, replaced, confirmed, locked or error).
+	//   '429':
+	//      description: rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+
+	err := s.s.ConfirmLink(r.Context(), r.URL.Query().Get("token"))
+	if err == nil {
+		http.Redirect(w, r, s.link.SuccessURL, http.StatusFound)
+		return
+	}
+
+	var reason string
+	switch {
+	case errors.Is(err, service.ErrInvalidLink), errors.Is(err, service.ErrRequestNotFound):
+		reason = "invalid"
+	case errors.Is(err, service.ErrCodeExpired):
+		reason = "expired"
+	case errors.Is(err, service.ErrLinkExpired):
+		reason = "replaced"
+	case errors.Is(err, service.ErrAlreadyConfirmed):
+		reason = "confirmed"
+	case errors.Is(err, service.ErrConfirmationLocked):
+		reason = "locked"
+	default:
+		logrus.WithError(err).Error("failed to confirm registration by link")
+		reason = "error"
+	}
+
+	http.Redirect(w, r, withQueryParam(s.link.FailureURL, "reason", reason), http.StatusFound)
+}
+
+// withQueryParam returns u with the query param set.
+func withQueryParam(u, key, value string) string {
+	v, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+
+	q := v.Query()
+	q.Set(key, value)
+	v.RawQuery = q.Encode()
+
+	return v.String()
+}
+
 // confirm confirms registration and creates wallet.
 func (s *server) confirm(w http.ResponseWriter, r *http.Request) {
 	// swagger:operation POST /v1/confirm Vulcan Confirm
@@ -168,6 +501,10 @@ func (s *server) confirm(w http.ResponseWriter, r *http.Request) {
 	//   required: true
 	//   schema:
 	//     '$ref': '#/definitions/ConfirmRequest'
+	// - name: X-Captcha-Response
+	//   in: header
+	//   type: string
+	//   description: captcha response, it's demanded according to the captcha policy.
 	// responses:
 	//   '200':
 	//     description: stakes were sent
@@ -179,6 +516,18 @@ func (s *server) confirm(w http.ResponseWriter, r *http.Request) {
 	//      description: request is already confirmed.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '410':
+	//      description: code is expired.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '423':
+	//      description: captcha is not passed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: too many wrong codes were sent, request is locked, or rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
@@ -197,6 +546,10 @@ func (s *server) confirm(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, service.ErrAlreadyConfirmed):
 			logrus.WithField("request", req).Warn("already confirmed")
 			api.WriteError(w, http.StatusConflict, "already confirmed")
+		case errors.Is(err, service.ErrCodeExpired):
+			api.WriteError(w, http.StatusGone, "code is expired")
+		case errors.Is(err, service.ErrConfirmationLocked):
+			api.WriteError(w, http.StatusTooManyRequests, "too many attempts")
 		default:
 			api.WriteInternalErrorf(r.Context(), w, err, "failed to confirm registration")
 		}
@@ -273,6 +626,14 @@ func (s *server) trackReferralBrowserInstallation(w http.ResponseWriter, r *http
 	// responses:
 	//   '200':
 	//     description: referral marked with installed status
+	//   '401':
+	//      description: request signature is not verified.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: request is not signed by address owner.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '404':
 	//      description: referral tracking not found
 	//      schema:
@@ -427,6 +788,10 @@ func (s *server) createDLoan(w http.ResponseWriter, r *http.Request) {
 	//   required: true
 	//   schema:
 	//     '$ref': '#/definitions/DLoanRequest'
+	// - name: X-Captcha-Response
+	//   in: header
+	//   type: string
+	//   description: captcha response, it's demanded according to the captcha policy.
 	// responses:
 	//   '200':
 	//     description: dloan created.
@@ -436,6 +801,22 @@ func (s *server) createDLoan(w http.ResponseWriter, r *http.Request) {
 	//      description: bad request.
 	//      schema:
 	//        "$ref": "#/definitions/Error"
+	//   '401':
+	//      description: request signature is not verified.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '403':
+	//      description: request is not signed by address owner.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '423':
+	//      description: captcha is not passed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
@@ -550,9 +931,21 @@ func (s *server) registerTestnetAccount(w http.ResponseWriter, r *http.Request)
 	//   in: path
 	//   required: true
 	//   type: string
+	// - name: X-Captcha-Response
+	//   in: header
+	//   type: string
+	//   description: captcha response, it's demanded according to the captcha policy.
 	// responses:
 	//   '200':
-	//     description: stakes were sent
+	//     description: stakes are queued to be sent
+	//   '423':
+	//      description: captcha is not passed.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
+	//   '429':
+	//      description: rate limit is exceeded.
+	//      schema:
+	//        "$ref": "#/definitions/Error"
 	//   '500':
 	//      description: internal server error.
 	//      schema:
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strings"
@@ -40,9 +41,10 @@ func Test_Register(t *testing.T) {
 			body: []byte(`{"email":"decentr@decentr.xyz", "address":"decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m"}`),
 			mockFn: func(srv *servicemock.MockService) {
 				srv.EXPECT().Register(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", nil).Return(nil)
+				srv.EXPECT().IssueStatusProof(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m").Return("proof", nil)
 			},
 			rcode: http.StatusOK,
-			rdata: `{}`,
+			rdata: `{"statusProof": "proof"}`,
 			rlog:  "",
 		},
 		{
@@ -67,23 +69,14 @@ func Test_Register(t *testing.T) {
 			rlog:  "",
 		},
 		{
-			name: "captcha isn't passed",
-			body: []byte(`{"email":"decentr@decentr.xyz", "address":"decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", "referralCode": "abcdef12", "recaptchaResponse": "213"}`),
-			mockFn: func(srv *servicemock.MockService) {
-				srv.EXPECT().CheckRecaptcha(gomock.Not(gomock.Nil()), "register", "213").Return(service.ErrRecaptcha)
-			},
-			rcode: http.StatusLocked,
-			rdata: `{"error": "recaptcha error"}`,
-			rlog:  "",
-		},
-		{
-			name: "captcha error",
-			body: []byte(`{"email":"decentr@decentr.xyz", "address":"decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", "referralCode": "abcdef12", "recaptchaResponse": "213"}`),
+			name: "status proof isn't issued",
+			body: []byte(`{"email":"decentr@decentr.xyz", "address":"decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m"}`),
 			mockFn: func(srv *servicemock.MockService) {
-				srv.EXPECT().CheckRecaptcha(gomock.Not(gomock.Nil()), "register", "213").Return(errTest)
+				srv.EXPECT().Register(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", nil).Return(nil)
+				srv.EXPECT().IssueStatusProof(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m").Return("", service.ErrRequestNotFound)
 			},
-			rcode: http.StatusInternalServerError,
-			rdata: `{"error": "internal error"}`,
+			rcode: http.StatusOK,
+			rdata: `{"statusProof": ""}`,
 			rlog:  "",
 		},
 		{
@@ -111,11 +104,11 @@ func Test_Register(t *testing.T) {
 			body: []byte(`{"email":"decentr@decentr.xyz", "address":"decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", "referralCode": "abcdef12", "recaptchaResponse": "213"}`),
 			mockFn: func(srv *servicemock.MockService) {
 				referralCode := "abcdef12"
-				srv.EXPECT().CheckRecaptcha(gomock.Not(gomock.Nil()), "register", "213").Return(nil)
 				srv.EXPECT().Register(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m", &referralCode).Return(nil)
+				srv.EXPECT().IssueStatusProof(gomock.Not(gomock.Nil()), "decentr@decentr.xyz", "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m").Return("proof", nil)
 			},
 			rcode: http.StatusOK,
-			rdata: `{}`,
+			rdata: `{"statusProof": "proof"}`,
 			rlog:  "",
 		},
 	}
@@ -242,6 +235,20 @@ func Test_Confirm(t *testing.T) {
 			rdata:      `{"error": "not found"}`,
 			rlog:       "",
 		},
+		{
+			name:       "expired",
+			serviceErr: service.ErrCodeExpired,
+			rcode:      http.StatusGone,
+			rdata:      `{"error": "code is expired"}`,
+			rlog:       "",
+		},
+		{
+			name:       "locked",
+			serviceErr: service.ErrConfirmationLocked,
+			rcode:      http.StatusTooManyRequests,
+			rdata:      `{"error": "too many attempts"}`,
+			rlog:       "",
+		},
 		{
 			name:       "internal error",
 			serviceErr: errTest,
@@ -281,6 +288,378 @@ func Test_Confirm(t *testing.T) {
 	}
 }
 
+func Test_Resend(t *testing.T) {
+	next := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name       string
+		body       string
+		next       time.Time
+		serviceErr error
+		rcode      int
+		rdata      string
+		retryAfter bool
+	}{
+		{
+			name:  "success",
+			body:  `{"email":"e@mail.com"}`,
+			next:  next,
+			rcode: http.StatusOK,
+			rdata: `{"nextResendAt":"2026-10-17T12:00:00Z"}`,
+		},
+		{
+			name:  "last resend",
+			body:  `{"email":"e@mail.com"}`,
+			rcode: http.StatusOK,
+			rdata: `{"nextResendAt":null}`,
+		},
+		{
+			name:       "invalid email",
+			body:       `{"email":"e"}`,
+			serviceErr: errSkip,
+			rcode:      http.StatusBadRequest,
+			rdata:      `{"error": "invalid request: invalid email"}`,
+		},
+		{
+			name:       "not found",
+			body:       `{"email":"e@mail.com"}`,
+			serviceErr: service.ErrRequestNotFound,
+			rcode:      http.StatusNotFound,
+			rdata:      `{"error": "not found"}`,
+		},
+		{
+			name:       "confirmed",
+			body:       `{"email":"e@mail.com"}`,
+			serviceErr: service.ErrAlreadyConfirmed,
+			rcode:      http.StatusConflict,
+			rdata:      `{"error": "already confirmed"}`,
+		},
+		{
+			name:       "throttled",
+			body:       `{"email":"e@mail.com"}`,
+			next:       time.Now().Add(time.Minute),
+			serviceErr: service.ErrTooManyAttempts,
+			rcode:      http.StatusTooManyRequests,
+			rdata:      `{"error": "too many attempts"}`,
+			retryAfter: true,
+		},
+		{
+			name:       "limit",
+			body:       `{"email":"e@mail.com"}`,
+			serviceErr: service.ErrResendLimit,
+			rcode:      http.StatusTooManyRequests,
+			rdata:      `{"error": "resend limit is reached, register again"}`,
+		},
+		{
+			name:       "internal error",
+			body:       `{"email":"e@mail.com"}`,
+			serviceErr: errTest,
+			rcode:      http.StatusInternalServerError,
+			rdata:      `{"error": "internal error"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodPost, "v1/register/resend", []byte(tc.body))
+
+			ctrl := gomock.NewController(t)
+
+			srv := servicemock.NewMockService(ctrl)
+
+			if tc.serviceErr != errSkip {
+				srv.EXPECT().Resend(gomock.Not(gomock.Nil()), "e@mail.com").Return(tc.next, tc.serviceErr)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Post("/v1/register/resend", s.resend)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+			assert.Equal(t, tc.retryAfter, w.Header().Get("Retry-After") != "")
+		})
+	}
+}
+
+func Test_ConfirmLink(t *testing.T) {
+	tt := []struct {
+		name       string
+		serviceErr error
+		location   string
+	}{
+		{
+			name:     "success",
+			location: "https://decentr.xyz/confirmed",
+		},
+		{
+			name:       "invalid",
+			serviceErr: service.ErrInvalidLink,
+			location:   "https://decentr.xyz/failed?lang=en&reason=invalid",
+		},
+		{
+			name:       "request not found",
+			serviceErr: service.ErrRequestNotFound,
+			location:   "https://decentr.xyz/failed?lang=en&reason=invalid",
+		},
+		{
+			name:       "code is reissued",
+			serviceErr: service.ErrLinkExpired,
+			location:   "https://decentr.xyz/failed?lang=en&reason=replaced",
+		},
+		{
+			name:       "expired",
+			serviceErr: service.ErrCodeExpired,
+			location:   "https://decentr.xyz/failed?lang=en&reason=expired",
+		},
+		{
+			name:       "confirmed",
+			serviceErr: service.ErrAlreadyConfirmed,
+			location:   "https://decentr.xyz/failed?lang=en&reason=confirmed",
+		},
+		{
+			name:       "locked",
+			serviceErr: service.ErrConfirmationLocked,
+			location:   "https://decentr.xyz/failed?lang=en&reason=locked",
+		},
+		{
+			name:       "internal error",
+			serviceErr: errTest,
+			location:   "https://decentr.xyz/failed?lang=en&reason=error",
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/confirm/link?token=t", nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			srv.EXPECT().ConfirmLink(gomock.Not(gomock.Nil()), "t").Return(tc.serviceErr)
+
+			router := chi.NewRouter()
+
+			s := server{s: srv, link: ConfirmLinkConfig{
+				SuccessURL: "https://decentr.xyz/confirmed",
+				FailureURL: "https://decentr.xyz/failed?lang=en",
+			}}
+			router.Get("/v1/confirm/link", s.confirmLink)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tc.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func Test_GetChallenge(t *testing.T) {
+	const address = "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m"
+
+	tt := []struct {
+		name   string
+		query  string
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:  "success",
+			query: "address=" + address,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().IssueChallenge(gomock.Not(gomock.Nil()), address).Return("nonce", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{"challenge": "nonce", "expiresAt": "2026-10-17T12:00:00Z"}`,
+		},
+		{
+			name:  "invalid address",
+			query: "address=decentr1",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid address"}`,
+		},
+		{
+			name:  "internal error",
+			query: "address=" + address,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().IssueChallenge(gomock.Not(gomock.Nil()), address).Return("", time.Time{}, errTest)
+			},
+			rcode: http.StatusInternalServerError,
+			rdata: `{"error": "internal error"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/register/challenge?"+tc.query, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Get("/v1/register/challenge", s.getChallenge)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
+func Test_GetRegistrationStatus(t *testing.T) {
+	const address = "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m"
+
+	createdAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name   string
+		query  string
+		mockFn func(srv *servicemock.MockService)
+		rcode  int
+		rdata  string
+	}{
+		{
+			name:  "pending by proof",
+			query: "proof=p",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetRegistrationStatusByProof(gomock.Not(gomock.Nil()), "p").Return(&service.RegistrationStatus{
+					State:        service.PendingRegistrationState,
+					Address:      address,
+					CreatedAt:    createdAt,
+					NextResendAt: createdAt.Add(time.Minute),
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{
+				"state": "pending",
+				"address": "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m",
+				"createdAt": "2026-10-17T12:00:00Z",
+				"confirmedAt": null,
+				"paidAt": null,
+				"payoutTxHash": null,
+				"nextResendAt": "2026-10-17T12:01:00Z"
+			}`,
+		},
+		{
+			name:  "paid by address",
+			query: "address=" + address,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetRegistrationStatusByAddress(gomock.Not(gomock.Nil()), address).Return(&service.RegistrationStatus{
+					State:       service.PaidRegistrationState,
+					Address:     address,
+					CreatedAt:   createdAt,
+					ConfirmedAt: sql.NullTime{Valid: true, Time: createdAt.Add(time.Hour)},
+					PaidAt:      sql.NullTime{Valid: true, Time: createdAt.Add(2 * time.Hour)},
+					TxHash:      sql.NullString{Valid: true, String: "hash"},
+				}, nil)
+			},
+			rcode: http.StatusOK,
+			rdata: `{
+				"state": "paid",
+				"address": "decentr18c2phdrfjkggr4afwf3rw4h4xsjvfhh2gl7t4m",
+				"createdAt": "2026-10-17T12:00:00Z",
+				"confirmedAt": "2026-10-17T13:00:00Z",
+				"paidAt": "2026-10-17T14:00:00Z",
+				"payoutTxHash": "hash",
+				"nextResendAt": null
+			}`,
+		},
+		{
+			name:  "no params",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "address or proof is required"}`,
+		},
+		{
+			name:  "both params",
+			query: "proof=p&address=" + address,
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "either address or proof should be passed"}`,
+		},
+		{
+			name:  "invalid address",
+			query: "address=decentr1",
+			rcode: http.StatusBadRequest,
+			rdata: `{"error": "invalid address"}`,
+		},
+		{
+			name:  "invalid proof",
+			query: "proof=p",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetRegistrationStatusByProof(gomock.Not(gomock.Nil()), "p").Return(nil, service.ErrInvalidProof)
+			},
+			rcode: http.StatusForbidden,
+			rdata: `{"error": "invalid proof"}`,
+		},
+		{
+			name:  "not found",
+			query: "address=" + address,
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetRegistrationStatusByAddress(gomock.Not(gomock.Nil()), address).Return(nil, service.ErrRequestNotFound)
+			},
+			rcode: http.StatusNotFound,
+			rdata: `{"error": "not found"}`,
+		},
+		{
+			name:  "internal error",
+			query: "proof=p",
+			mockFn: func(srv *servicemock.MockService) {
+				srv.EXPECT().GetRegistrationStatusByProof(gomock.Not(gomock.Nil()), "p").Return(nil, errTest)
+			},
+			rcode: http.StatusInternalServerError,
+			rdata: `{"error": "internal error"}`,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/register/status?"+tc.query, nil)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := servicemock.NewMockService(ctrl)
+			if tc.mockFn != nil {
+				tc.mockFn(srv)
+			}
+
+			router := chi.NewRouter()
+
+			s := server{s: srv}
+			router.Get("/v1/register/status", s.getRegistrationStatus)
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.rcode, w.Code)
+			assert.JSONEq(t, tc.rdata, w.Body.String())
+		})
+	}
+}
+
 func Test_GetReferralConfig(t *testing.T) {
 	_, w, r := test.NewAPITestParameters(http.MethodGet, "v1/referral/config", nil)
 
@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/go-api"
+
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+// RateLimit limits count of requests within a sliding window. Zero limit means unlimited.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRateLimit parses rate limit in limit/window format, e.g. 10/1h. Empty string or 0 means unlimited.
+func ParseRateLimit(s string) (RateLimit, error) {
+	if s == "" || s == "0" {
+		return RateLimit{}, nil
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: limit/window format is expected", s)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit < 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: invalid limit", s)
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window < time.Second {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: window should be at least 1s", s)
+	}
+
+	return RateLimit{Limit: limit, Window: window}, nil
+}
+
+// RouteRateLimit contains limits of a route by keys.
+type RouteRateLimit struct {
+	IP      RateLimit
+	Email   RateLimit
+	Address RateLimit
+}
+
+// RateLimitConfig contains rate limits of routes.
+type RateLimitConfig struct {
+	Store RateLimitStore
+	// Hasher computes email keys, so aliases of the same mailbox share the limit.
+	Hasher *owner.Hasher
+	// TrustedProxies are networks X-Forwarded-For and X-Real-IP headers are accepted from.
+	TrustedProxies []*net.IPNet
+
+	Register  RouteRateLimit
+	Resend    RouteRateLimit
+	Status    RouteRateLimit
+	Challenge RouteRateLimit
+	Confirm   RouteRateLimit
+	DLoan     RouteRateLimit
+	Hesoyam   RouteRateLimit
+}
+
+// RateLimitStore keeps hits of rate limit keys in fixed windows.
+type RateLimitStore interface {
+	// Hit increments hits of the key in the current window and returns hits of current and previous windows.
+	Hit(ctx context.Context, key string, window time.Duration) (*storage.RateLimitHits, error)
+	// Run deletes expired hits periodically.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// rateLimited returns middleware which limits requests of the route by client IP, email owner and address.
+// The limit is a sliding window approximated by weighted hits of the current and previous fixed windows.
+func rateLimited(cfg RateLimitConfig, route string, limits RouteRateLimit, address addressExtractor) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			type check struct {
+				key   string
+				limit RateLimit
+			}
+
+			checks := []check{{key: "ip:" + remoteIP(r), limit: limits.IP}}
+
+			if limits.Email.Limit > 0 && r.Body != nil && r.Method != http.MethodGet {
+				if email, _ := bodyField(r, "email"); email != "" {
+					checks = append(checks, check{key: "email:" + cfg.Hasher.Hash(email), limit: limits.Email})
+				}
+			}
+
+			if limits.Address.Limit > 0 && address != nil {
+				if v, _ := address(r); v != "" {
+					checks = append(checks, check{key: "address:" + v, limit: limits.Address})
+				}
+			}
+
+			var (
+				tightest *rateLimitStatus
+				exceeded *rateLimitStatus
+			)
+			for _, c := range checks {
+				if c.limit.Limit <= 0 {
+					continue
+				}
+
+				hits, err := cfg.Store.Hit(r.Context(), route+":"+c.key, c.limit.Window)
+				if err != nil {
+					api.WriteInternalErrorf(r.Context(), w, err, "failed to hit rate limit")
+					return
+				}
+
+				st := newRateLimitStatus(c.limit, hits)
+				if tightest == nil || st.remaining() < tightest.remaining() {
+					tightest = &st
+				}
+				if st.exceeded() && (exceeded == nil || st.retryAfter() > exceeded.retryAfter()) {
+					exceeded = &st
+				}
+			}
+
+			if tightest != nil {
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(tightest.limit.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(tightest.remaining()))
+				w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(tightest.reset())))
+			}
+
+			if exceeded != nil {
+				logrus.WithFields(logrus.Fields{
+					"route": route,
+					"ip":    remoteIP(r),
+				}).Debug("rate limit is exceeded")
+
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(exceeded.retryAfter())))
+				api.WriteError(w, http.StatusTooManyRequests, "rate limit is exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type rateLimitStatus struct {
+	limit RateLimit
+	hits  storage.RateLimitHits
+}
+
+func newRateLimitStatus(limit RateLimit, hits *storage.RateLimitHits) rateLimitStatus {
+	return rateLimitStatus{limit: limit, hits: *hits}
+}
+
+// count returns approximate count of hits within the sliding window including the current request.
+func (s rateLimitStatus) count() float64 {
+	weight := float64(s.limit.Window-s.hits.Elapsed) / float64(s.limit.Window)
+	if weight < 0 {
+		weight = 0
+	}
+
+	return float64(s.hits.Previous)*weight + float64(s.hits.Current)
+}
+
+func (s rateLimitStatus) exceeded() bool {
+	return s.count() > float64(s.limit.Limit)
+}
+
+func (s rateLimitStatus) remaining() int {
+	r := s.limit.Limit - int(math.Ceil(s.count()))
+	if r < 0 {
+		return 0
+	}
+
+	return r
+}
+
+// reset returns time till the current window end, when hits of the previous window stop counting.
+func (s rateLimitStatus) reset() time.Duration {
+	return s.limit.Window - s.hits.Elapsed
+}
+
+// retryAfter returns time till the next request fits into the limit.
+func (s rateLimitStatus) retryAfter() time.Duration {
+	w := float64(s.limit.Window)
+	free := float64(s.limit.Limit - 1)
+
+	// the current window has room, so wait till hits of the previous window fade enough
+	if float64(s.hits.Current) <= free {
+		if s.hits.Previous == 0 {
+			return 0
+		}
+		t := w - float64(s.hits.Elapsed) - (free-float64(s.hits.Current))*w/float64(s.hits.Previous)
+		return time.Duration(math.Max(t, 0))
+	}
+
+	// wait for the next window, then till hits of the current one fade enough
+	t := w - free*w/float64(s.hits.Current)
+
+	return s.reset() + time.Duration(math.Max(t, 0))
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// realIP returns middleware which replaces remote address with the client IP passed by a trusted proxy.
+// X-Forwarded-For is walked from the right, so addresses appended by trusted proxies are skipped
+// and values spoofed by the client are ignored.
+func realIP(trusted []*net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := clientIP(r, trusted); ip != "" {
+				r.RemoteAddr = ip
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	isTrusted := func(s string) bool {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return false
+		}
+		for _, n := range trusted {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ip := remoteIP(r)
+	if !isTrusted(ip) {
+		return ip
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			v := strings.TrimSpace(parts[i])
+			if net.ParseIP(v) == nil {
+				break
+			}
+			ip = v
+			if !isTrusted(v) {
+				break
+			}
+		}
+		return ip
+	}
+
+	if v := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(v) != nil {
+		return v
+	}
+
+	return ip
+}
+
+// remoteIP returns host of the remote address, it's the client IP after realIP middleware.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+type memoryRateLimitWindow struct {
+	index    int64
+	current  int
+	previous int
+	window   time.Duration
+}
+
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	windows map[string]*memoryRateLimitWindow
+}
+
+// NewMemoryRateLimitStore returns RateLimitStore keeping hits in memory. Hits aren't shared between replicas.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		windows: make(map[string]*memoryRateLimitWindow),
+	}
+}
+
+// Hit ...
+func (s *memoryRateLimitStore) Hit(_ context.Context, key string, window time.Duration) (*storage.RateLimitHits, error) {
+	return s.hit(key, window, time.Now()), nil
+}
+
+func (s *memoryRateLimitStore) hit(key string, window time.Duration, now time.Time) *storage.RateLimitHits {
+	index := now.UnixNano() / int64(window)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[key]
+	switch {
+	case !ok || w.index < index-1:
+		w = &memoryRateLimitWindow{index: index, window: window}
+		s.windows[key] = w
+	case w.index == index-1:
+		w.index, w.previous, w.current = index, w.current, 0
+	}
+	w.current++
+
+	return &storage.RateLimitHits{
+		Current:  w.current,
+		Previous: w.previous,
+		Elapsed:  time.Duration(now.UnixNano() - index*int64(window)),
+	}
+}
+
+// Run ...
+func (s *memoryRateLimitStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.cleanup(time.Now())
+			}
+		}
+	}(ticker)
+}
+
+func (s *memoryRateLimitStore) cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, w := range s.windows {
+		if w.index < now.UnixNano()/int64(w.window)-1 {
+			delete(s.windows, k)
+		}
+	}
+}
+
+type storageRateLimitStore struct {
+	s storage.Storage
+}
+
+// NewStorageRateLimitStore returns RateLimitStore keeping hits in the storage, so replicas share them.
+func NewStorageRateLimitStore(s storage.Storage) RateLimitStore {
+	return storageRateLimitStore{s: s}
+}
+
+// Hit ...
+func (s storageRateLimitStore) Hit(ctx context.Context, key string, window time.Duration) (*storage.RateLimitHits, error) {
+	return s.s.HitRateLimit(ctx, key, window)
+}
+
+// Run ...
+func (s storageRateLimitStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.s.DeleteExpiredRateLimits(ctx); err != nil {
+					logrus.WithError(err).Error("failed to delete expired rate limits")
+				}
+			}
+		}
+	}(ticker)
+}
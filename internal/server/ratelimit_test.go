@@ -0,0 +1,228 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	l, err := ParseRateLimit("10/1h")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{Limit: 10, Window: time.Hour}, l)
+
+	l, err = ParseRateLimit("0")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{}, l)
+
+	for _, v := range []string{"10", "a/1h", "-1/1h", "10/1ms", "10/1h/1"} {
+		_, err := ParseRateLimit(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	tt := []struct {
+		name       string
+		hits       storage.RateLimitHits
+		exceeded   bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{
+			name:      "first",
+			hits:      storage.RateLimitHits{Current: 1, Elapsed: 30 * time.Minute},
+			remaining: 9,
+		},
+		{
+			name:      "previous window is counted partially",
+			hits:      storage.RateLimitHits{Current: 5, Previous: 10, Elapsed: 30 * time.Minute},
+			remaining: 0,
+		},
+		{
+			name:       "previous window is fading",
+			hits:       storage.RateLimitHits{Current: 6, Previous: 10, Elapsed: 30 * time.Minute},
+			exceeded:   true,
+			retryAfter: 12 * time.Minute,
+		},
+		{
+			name:       "current window is full",
+			hits:       storage.RateLimitHits{Current: 11, Previous: 0, Elapsed: 45 * time.Minute},
+			exceeded:   true,
+			retryAfter: 15*time.Minute + time.Hour*2/11,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := newRateLimitStatus(RateLimit{Limit: 10, Window: time.Hour}, &tc.hits)
+			assert.Equal(t, tc.exceeded, st.exceeded())
+			assert.Equal(t, tc.remaining, st.remaining())
+			if tc.exceeded {
+				assert.InDelta(t, float64(tc.retryAfter), float64(st.retryAfter()), float64(time.Millisecond))
+			}
+		})
+	}
+}
+
+func newTestHasher(t *testing.T) *owner.Hasher {
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	require.NoError(t, err)
+
+	h, err := owner.NewHasher(n, []owner.Key{{ID: "k1", Secret: []byte(strings.Repeat("1", 32))}}, false)
+	require.NoError(t, err)
+
+	return h
+}
+
+func Test_rateLimited(t *testing.T) {
+	cfg := RateLimitConfig{
+		Store:  NewMemoryRateLimitStore(),
+		Hasher: newTestHasher(t),
+		Register: RouteRateLimit{
+			IP:      RateLimit{Limit: 3, Window: time.Hour},
+			Email:   RateLimit{Limit: 1, Window: time.Hour},
+			Address: RateLimit{Limit: 2, Window: time.Hour},
+		},
+	}
+
+	router := chi.NewRouter()
+	router.With(rateLimited(cfg, "register", cfg.Register, bodyAddress("address"))).
+		Post("/v1/register", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+	do := func(ip, email, address string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/register",
+			strings.NewReader(`{"email":"`+email+`","address":"`+address+`"}`))
+		r.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("10.0.0.1", "a@decentr.xyz", "a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	// email
+	w = do("10.0.0.2", "A@decentr.xyz", "b")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// aliases of the same mailbox share the email limit
+	assert.Equal(t, http.StatusOK, do("10.0.0.6", "john@gmail.com", "j1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.7", "j.o.h.n+acc1@googlemail.com", "j2").Code)
+
+	// address
+	assert.Equal(t, http.StatusOK, do("10.0.0.3", "c@decentr.xyz", "a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.4", "d@decentr.xyz", "a").Code)
+
+	// ip
+	assert.Equal(t, http.StatusOK, do("10.0.0.5", "e@decentr.xyz", "e").Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.5", "f@decentr.xyz", "f").Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.5", "g@decentr.xyz", "g").Code)
+	w = do("10.0.0.5", "h@decentr.xyz", "h")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	s := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+
+	hits := s.hit("key", time.Hour, start)
+	assert.Equal(t, storage.RateLimitHits{Current: 1}, *hits)
+
+	hits = s.hit("key", time.Hour, start.Add(10*time.Minute))
+	assert.Equal(t, storage.RateLimitHits{Current: 2, Elapsed: 10 * time.Minute}, *hits)
+
+	hits = s.hit("key", time.Hour, start.Add(70*time.Minute))
+	assert.Equal(t, storage.RateLimitHits{Current: 1, Previous: 2, Elapsed: 10 * time.Minute}, *hits)
+
+	hits = s.hit("key", time.Hour, start.Add(190*time.Minute))
+	assert.Equal(t, storage.RateLimitHits{Current: 1, Elapsed: 10 * time.Minute}, *hits)
+
+	s.cleanup(start.Add(5 * time.Hour))
+	assert.Empty(t, s.windows)
+}
+
+func Test_clientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trusted := []*net.IPNet{proxies}
+
+	tt := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		realIP     string
+		ip         string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "1.1.1.1:1234",
+			xff:        "2.2.2.2",
+			ip:         "1.1.1.1",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        "2.2.2.2",
+			ip:         "2.2.2.2",
+		},
+		{
+			name:       "spoofed",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        "3.3.3.3, 2.2.2.2, 10.0.0.2",
+			ip:         "2.2.2.2",
+		},
+		{
+			name:       "real ip",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "2.2.2.2",
+			ip:         "2.2.2.2",
+		},
+		{
+			name:       "invalid",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        "invalid",
+			ip:         "10.0.0.1",
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.xff != "" {
+				r.Header.Set("X-Forwarded-For", tc.xff)
+			}
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			assert.Equal(t, tc.ip, clientIP(r, trusted))
+		})
+	}
+}
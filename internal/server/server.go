@@ -31,13 +31,27 @@ import (
 const maxBodySize = 1024
 
 type server struct {
-	s   service.Service
-	sup supply.Supply
+	s     service.Service
+	sup   supply.Supply
+	admin service.Admin
+	link  ConfirmLinkConfig
 }
 
 // SetupRouter setups handlers to chi router.
-func SetupRouter(s service.Service, sup supply.Supply, r chi.Router, timeout time.Duration, testMode bool) {
+func SetupRouter(
+	s service.Service,
+	sup supply.Supply,
+	r chi.Router,
+	timeout time.Duration,
+	testMode bool,
+	auth AuthConfig,
+	cpt CaptchaConfig,
+	rl RateLimitConfig,
+	adm AdminConfig,
+	link ConfirmLinkConfig,
+) {
 	r.Use(
+		realIP(rl.TrustedProxies),
 		api.FileServerMiddleware("/docs", "static"),
 		api.LoggerMiddleware,
 		middleware.StripSlashes,
@@ -49,29 +63,75 @@ func SetupRouter(s service.Service, sup supply.Supply, r chi.Router, timeout tim
 	)
 
 	srv := server{
-		s:   s,
-		sup: sup,
+		s:     s,
+		sup:   sup,
+		admin: adm.Service,
+		link:  link,
 	}
 
 	r.Route("/v1", func(r chi.Router) {
-		r.Post("/register", srv.register)
+		r.With(
+			rateLimited(rl, "register", rl.Register, bodyAddress("address")),
+			captchaProtected(cpt.Register, cpt, "register"),
+			signedBy(auth.Register, auth.Verifier, bodyAddress("address")),
+			challenged(auth.Register, s, bodyAddress("address")),
+		).Post("/register", srv.register)
+		r.With(
+			rateLimited(rl, "challenge", rl.Challenge, nil),
+		).Get("/register/challenge", srv.getChallenge)
+		r.With(
+			rateLimited(rl, "resend", rl.Resend, nil),
+			captchaProtected(cpt.Resend, cpt, "resend"),
+		).Post("/register/resend", srv.resend)
+		r.With(
+			rateLimited(rl, "status", rl.Status, nil),
+		).Get("/register/status", srv.getRegistrationStatus)
 		r.Get("/register/stats", srv.getRegisterStats)
-		r.Post("/confirm", srv.confirm)
+		r.With(
+			rateLimited(rl, "confirm", rl.Confirm, nil),
+			captchaProtected(cpt.Confirm, cpt, "confirm"),
+		).Post("/confirm", srv.confirm)
+		r.With(
+			rateLimited(rl, "confirm", rl.Confirm, nil),
+		).Get("/confirm/link", srv.confirmLink)
 		r.Get("/supply", srv.supply)
 
 		if testMode {
-			r.Get("/hesoyam/{address}", srv.registerTestnetAccount)
+			r.With(
+				rateLimited(rl, "hesoyam", rl.Hesoyam, pathAddress("address")),
+				captchaProtected(cpt.Hesoyam, cpt, "hesoyam"),
+			).Get("/hesoyam/{address}", srv.registerTestnetAccount)
 		}
 
 		r.Route("/referral", func(r chi.Router) {
 			r.Get("/config", srv.getReferralConfig)
 			r.Get("/code/{address}", srv.getOwnReferralCode)
 			r.Get("/code/{address}/registration", srv.getRegistrationReferralCode)
-			r.Post("/track/install/{address}", srv.trackReferralBrowserInstallation)
+			r.With(signedBy(auth.TrackInstall, auth.Verifier, pathAddress("address"))).
+				Post("/track/install/{address}", srv.trackReferralBrowserInstallation)
 			r.Get("/track/stats/{address}", srv.getReferralTrackingStats)
 		})
 
-		r.Post("/dloan", srv.createDLoan)
+		r.With(
+			signedBy(auth.DLoan, auth.Verifier, bodyAddress("walletAddress")),
+			rateLimited(rl, "dloan", rl.DLoan, bodyAddress("walletAddress")),
+			captchaProtected(cpt.DLoan, cpt, "dloan"),
+		).Post("/dloan", srv.createDLoan)
 		r.Get("/dloan", srv.listDLoans)
 	})
+
+	if len(adm.Tokens) > 0 {
+		r.Route("/admin/v1", func(r chi.Router) {
+			r.Use(adminAuthorized(adm.Tokens))
+
+			r.Get("/fraud/rules", srv.listFraudRules)
+			r.Post("/fraud/rules", srv.createFraudRule)
+			r.Delete("/fraud/rules/{id}", srv.deleteFraudRule)
+			r.Post("/referral/{address}/ban", srv.banReferral)
+			r.Post("/referral/{address}/unban", srv.unbanReferral)
+			r.Get("/requests", srv.searchRequests)
+			r.Get("/requests/{owner}/emails", srv.listEmails)
+			r.Get("/audit", srv.listAuditRecords)
+		})
+	}
 }
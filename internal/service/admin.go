@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Decentr-net/vulcan/internal/fraud"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/storage"
+)
+
+//go:generate mockgen -destination=./mock/admin.go -package=mock -source=admin.go
+
+// ErrInvalidFraudRule is returned when fraud rule pattern doesn't fit its kind.
+var ErrInvalidFraudRule = fmt.Errorf("invalid fraud rule")
+
+// ErrFraudRuleExists is returned when the same fraud rule is already created.
+var ErrFraudRuleExists = fmt.Errorf("fraud rule already exists")
+
+// ErrFraudRuleNotFound ...
+var ErrFraudRuleNotFound = fmt.Errorf("fraud rule not found")
+
+// ErrEmptyFilter is returned when requests are searched without any filter.
+var ErrEmptyFilter = fmt.Errorf("at least one filter is required")
+
+// RequestFilter filters requests by address, email or referral code.
+type RequestFilter struct {
+	Address      string
+	Email        string
+	ReferralCode string
+}
+
+// Admin contains administrative operations. Every change is recorded to the audit log with the actor.
+type Admin interface {
+	GetFraudRules(ctx context.Context) ([]*storage.FraudRule, error)
+	CreateFraudRule(ctx context.Context, actor string, kind storage.FraudRuleKind, pattern, reason string) (*storage.FraudRule, error)
+	DeleteFraudRule(ctx context.Context, actor string, id int64, reason string) error
+	BanReferral(ctx context.Context, actor, address, reason string) error
+	UnbanReferral(ctx context.Context, actor, address, reason string) error
+	SearchRequests(ctx context.Context, filter RequestFilter, limit int) ([]*storage.Request, error)
+	GetAuditRecords(ctx context.Context, target string, limit int) ([]*storage.AuditRecord, error)
+	GetEmails(ctx context.Context, owner string, limit int) ([]*storage.Email, error)
+}
+
+type admin struct {
+	storage storage.Storage
+	hasher  *owner.Hasher
+}
+
+// NewAdmin creates new instance of admin service.
+func NewAdmin(storage storage.Storage, hasher *owner.Hasher) Admin {
+	return &admin{
+		storage: storage,
+		hasher:  hasher,
+	}
+}
+
+func (a *admin) GetFraudRules(ctx context.Context) ([]*storage.FraudRule, error) {
+	return a.storage.GetFraudRules(ctx)
+}
+
+func (a *admin) CreateFraudRule(
+	ctx context.Context, actor string, kind storage.FraudRuleKind, pattern, reason string,
+) (*storage.FraudRule, error) {
+	r := &storage.FraudRule{Kind: kind, Pattern: pattern, Source: "admin:" + actor}
+	if err := fraud.Validate(r); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFraudRule, err)
+	}
+
+	if err := a.storage.InTx(ctx, func(s storage.Storage) error {
+		if err := s.CreateFraudRule(ctx, r); err != nil {
+			if errors.Is(err, storage.ErrFraudRuleExists) {
+				return ErrFraudRuleExists
+			}
+			return fmt.Errorf("failed to create fraud rule: %w", err)
+		}
+
+		return audit(ctx, s, actor, storage.CreateFraudRuleAuditAction, fraudRuleTarget(r), reason)
+	}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (a *admin) DeleteFraudRule(ctx context.Context, actor string, id int64, reason string) error {
+	return a.storage.InTx(ctx, func(s storage.Storage) error {
+		r, err := s.GetFraudRule(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrFraudRuleNotFound
+			}
+			return fmt.Errorf("failed to get fraud rule: %w", err)
+		}
+
+		if err := s.DeleteFraudRule(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrFraudRuleNotFound
+			}
+			return fmt.Errorf("failed to delete fraud rule: %w", err)
+		}
+
+		return audit(ctx, s, actor, storage.DeleteFraudRuleAuditAction, fraudRuleTarget(r), reason)
+	})
+}
+
+func (a *admin) BanReferral(ctx context.Context, actor, address, reason string) error {
+	return a.setReferralBanned(ctx, actor, address, true, reason)
+}
+
+func (a *admin) UnbanReferral(ctx context.Context, actor, address, reason string) error {
+	return a.setReferralBanned(ctx, actor, address, false, reason)
+}
+
+func (a *admin) setReferralBanned(ctx context.Context, actor, address string, banned bool, reason string) error {
+	action, banReason := storage.UnbanReferralAuditAction, sql.NullString{}
+	if banned {
+		action, banReason = storage.BanReferralAuditAction, sql.NullString{Valid: true, String: reason}
+	}
+
+	return a.storage.InTx(ctx, func(s storage.Storage) error {
+		if err := s.SetReferralBanned(ctx, address, banned, banReason); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrRequestNotFound
+			}
+			return fmt.Errorf("failed to set referral banned: %w", err)
+		}
+
+		return audit(ctx, s, actor, action, address, reason)
+	})
+}
+
+func (a *admin) SearchRequests(ctx context.Context, filter RequestFilter, limit int) ([]*storage.Request, error) {
+	if filter.Address == "" && filter.Email == "" && filter.ReferralCode == "" {
+		return nil, ErrEmptyFilter
+	}
+
+	f := storage.RequestFilter{
+		Address:      filter.Address,
+		ReferralCode: filter.ReferralCode,
+	}
+	if filter.Email != "" {
+		f.Owners = a.hasher.Owners(filter.Email)
+	}
+
+	return a.storage.SearchRequests(ctx, f, limit)
+}
+
+func (a *admin) GetAuditRecords(ctx context.Context, target string, limit int) ([]*storage.AuditRecord, error) {
+	return a.storage.GetAuditRecords(ctx, target, limit)
+}
+
+func (a *admin) GetEmails(ctx context.Context, owner string, limit int) ([]*storage.Email, error) {
+	return a.storage.GetEmails(ctx, owner, limit)
+}
+
+func audit(ctx context.Context, s storage.Storage, actor string, action storage.AuditAction, target, reason string) error {
+	if err := s.CreateAuditRecord(ctx, &storage.AuditRecord{
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Reason: reason,
+	}); err != nil {
+		return fmt.Errorf("failed to create audit record: %w", err)
+	}
+
+	return nil
+}
+
+func fraudRuleTarget(r *storage.FraudRule) string {
+	return fmt.Sprintf("%s:%s", r.Kind, r.Pattern)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Decentr-net/vulcan/internal/storage"
+	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+)
+
+func inTx(s *storagemock.MockStorage) {
+	s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+		return f(s)
+	})
+}
+
+func TestAdmin_CreateFraudRule(t *testing.T) {
+	tt := []struct {
+		name          string
+		kind          storage.FraudRuleKind
+		pattern       string
+		mockSetupFunc func(s *storagemock.MockStorage)
+		err           error
+	}{
+		{
+			name:    "success",
+			kind:    storage.ExactFraudRuleKind,
+			pattern: "Mailinator.com",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				inTx(s)
+				s.EXPECT().CreateFraudRule(gomock.Any(), &storage.FraudRule{
+					Kind: storage.ExactFraudRuleKind, Pattern: "mailinator.com", Source: "admin:root",
+				}).DoAndReturn(func(_ context.Context, r *storage.FraudRule) error {
+					r.ID = 1
+					return nil
+				})
+				s.EXPECT().CreateAuditRecord(gomock.Any(), &storage.AuditRecord{
+					Actor: "root", Action: storage.CreateFraudRuleAuditAction, Target: "exact:mailinator.com", Reason: "spam",
+				})
+			},
+		},
+		{
+			name:          "invalid",
+			kind:          storage.RegexFraudRuleKind,
+			pattern:       "(",
+			mockSetupFunc: func(s *storagemock.MockStorage) {},
+			err:           ErrInvalidFraudRule,
+		},
+		{
+			name:    "exists",
+			kind:    storage.WildcardFraudRuleKind,
+			pattern: "mailinator.com",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				inTx(s)
+				s.EXPECT().CreateFraudRule(gomock.Any(), gomock.Any()).Return(storage.ErrFraudRuleExists)
+			},
+			err: ErrFraudRuleExists,
+		},
+		{
+			name:    "audit failed",
+			kind:    storage.WildcardFraudRuleKind,
+			pattern: "mailinator.com",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				inTx(s)
+				s.EXPECT().CreateFraudRule(gomock.Any(), gomock.Any())
+				s.EXPECT().CreateAuditRecord(gomock.Any(), gomock.Any()).Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			st := storagemock.NewMockStorage(ctrl)
+			tc.mockSetupFunc(st)
+
+			r, err := NewAdmin(st, testHasher).CreateFraudRule(context.Background(), "root", tc.kind, tc.pattern, "spam")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), r.ID)
+		})
+	}
+}
+
+func TestAdmin_DeleteFraudRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := storagemock.NewMockStorage(ctrl)
+
+	inTx(st)
+	st.EXPECT().GetFraudRule(gomock.Any(), int64(1)).Return(&storage.FraudRule{
+		ID: 1, Kind: storage.MXFraudRuleKind, Pattern: "mx.mailinator.com",
+	}, nil)
+	st.EXPECT().DeleteFraudRule(gomock.Any(), int64(1))
+	st.EXPECT().CreateAuditRecord(gomock.Any(), &storage.AuditRecord{
+		Actor: "root", Action: storage.DeleteFraudRuleAuditAction, Target: "mx:mx.mailinator.com",
+	})
+	require.NoError(t, NewAdmin(st, testHasher).DeleteFraudRule(context.Background(), "root", 1, ""))
+
+	inTx(st)
+	st.EXPECT().GetFraudRule(gomock.Any(), int64(2)).Return(nil, storage.ErrNotFound)
+	assert.ErrorIs(t, NewAdmin(st, testHasher).DeleteFraudRule(context.Background(), "root", 2, ""), ErrFraudRuleNotFound)
+}
+
+func TestAdmin_BanReferral(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := storagemock.NewMockStorage(ctrl)
+
+	inTx(st)
+	st.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true, sql.NullString{Valid: true, String: "fake installs"})
+	st.EXPECT().CreateAuditRecord(gomock.Any(), &storage.AuditRecord{
+		Actor: "root", Action: storage.BanReferralAuditAction, Target: testAddress, Reason: "fake installs",
+	})
+	require.NoError(t, NewAdmin(st, testHasher).BanReferral(context.Background(), "root", testAddress, "fake installs"))
+
+	inTx(st)
+	st.EXPECT().SetReferralBanned(gomock.Any(), testAddress, false, sql.NullString{})
+	st.EXPECT().CreateAuditRecord(gomock.Any(), &storage.AuditRecord{
+		Actor: "root", Action: storage.UnbanReferralAuditAction, Target: testAddress, Reason: "appealed",
+	})
+	require.NoError(t, NewAdmin(st, testHasher).UnbanReferral(context.Background(), "root", testAddress, "appealed"))
+
+	inTx(st)
+	st.EXPECT().SetReferralBanned(gomock.Any(), testAddress, true, gomock.Any()).Return(storage.ErrNotFound)
+	assert.ErrorIs(t, NewAdmin(st, testHasher).BanReferral(context.Background(), "root", testAddress, "reason"), ErrRequestNotFound)
+}
+
+func TestAdmin_SearchRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := storagemock.NewMockStorage(ctrl)
+
+	st.EXPECT().SearchRequests(gomock.Any(), storage.RequestFilter{Owners: []string{testOwner}, ReferralCode: "code"}, 10).
+		Return([]*storage.Request{{Owner: testOwner}}, nil)
+
+	rr, err := NewAdmin(st, testHasher).SearchRequests(context.Background(), RequestFilter{Email: "Decentr+1@decentr.xyz", ReferralCode: "code"}, 10)
+	require.NoError(t, err)
+	assert.Len(t, rr, 1)
+
+	_, err = NewAdmin(st, testHasher).SearchRequests(context.Background(), RequestFilter{}, 10)
+	assert.ErrorIs(t, err, ErrEmptyFilter)
+}
@@ -0,0 +1,153 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: admin.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	service "github.com/Decentr-net/vulcan/internal/service"
+	storage "github.com/Decentr-net/vulcan/internal/storage"
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockAdmin is a mock of Admin interface
+type MockAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminMockRecorder
+}
+
+// MockAdminMockRecorder is the mock recorder for MockAdmin
+type MockAdminMockRecorder struct {
+	mock *MockAdmin
+}
+
+// NewMockAdmin creates a new mock instance
+func NewMockAdmin(ctrl *gomock.Controller) *MockAdmin {
+	mock := &MockAdmin{ctrl: ctrl}
+	mock.recorder = &MockAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAdmin) EXPECT() *MockAdminMockRecorder {
+	return m.recorder
+}
+
+// GetFraudRules mocks base method
+func (m *MockAdmin) GetFraudRules(ctx context.Context) ([]*storage.FraudRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFraudRules", ctx)
+	ret0, _ := ret[0].([]*storage.FraudRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFraudRules indicates an expected call of GetFraudRules
+func (mr *MockAdminMockRecorder) GetFraudRules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFraudRules", reflect.TypeOf((*MockAdmin)(nil).GetFraudRules), ctx)
+}
+
+// CreateFraudRule mocks base method
+func (m *MockAdmin) CreateFraudRule(ctx context.Context, actor string, kind storage.FraudRuleKind, pattern, reason string) (*storage.FraudRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFraudRule", ctx, actor, kind, pattern, reason)
+	ret0, _ := ret[0].(*storage.FraudRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFraudRule indicates an expected call of CreateFraudRule
+func (mr *MockAdminMockRecorder) CreateFraudRule(ctx, actor, kind, pattern, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFraudRule", reflect.TypeOf((*MockAdmin)(nil).CreateFraudRule), ctx, actor, kind, pattern, reason)
+}
+
+// DeleteFraudRule mocks base method
+func (m *MockAdmin) DeleteFraudRule(ctx context.Context, actor string, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFraudRule", ctx, actor, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFraudRule indicates an expected call of DeleteFraudRule
+func (mr *MockAdminMockRecorder) DeleteFraudRule(ctx, actor, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFraudRule", reflect.TypeOf((*MockAdmin)(nil).DeleteFraudRule), ctx, actor, id, reason)
+}
+
+// BanReferral mocks base method
+func (m *MockAdmin) BanReferral(ctx context.Context, actor, address, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanReferral", ctx, actor, address, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanReferral indicates an expected call of BanReferral
+func (mr *MockAdminMockRecorder) BanReferral(ctx, actor, address, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanReferral", reflect.TypeOf((*MockAdmin)(nil).BanReferral), ctx, actor, address, reason)
+}
+
+// UnbanReferral mocks base method
+func (m *MockAdmin) UnbanReferral(ctx context.Context, actor, address, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanReferral", ctx, actor, address, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanReferral indicates an expected call of UnbanReferral
+func (mr *MockAdminMockRecorder) UnbanReferral(ctx, actor, address, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanReferral", reflect.TypeOf((*MockAdmin)(nil).UnbanReferral), ctx, actor, address, reason)
+}
+
+// SearchRequests mocks base method
+func (m *MockAdmin) SearchRequests(ctx context.Context, filter service.RequestFilter, limit int) ([]*storage.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchRequests", ctx, filter, limit)
+	ret0, _ := ret[0].([]*storage.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchRequests indicates an expected call of SearchRequests
+func (mr *MockAdminMockRecorder) SearchRequests(ctx, filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchRequests", reflect.TypeOf((*MockAdmin)(nil).SearchRequests), ctx, filter, limit)
+}
+
+// GetAuditRecords mocks base method
+func (m *MockAdmin) GetAuditRecords(ctx context.Context, target string, limit int) ([]*storage.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditRecords", ctx, target, limit)
+	ret0, _ := ret[0].([]*storage.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditRecords indicates an expected call of GetAuditRecords
+func (mr *MockAdminMockRecorder) GetAuditRecords(ctx, target, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditRecords", reflect.TypeOf((*MockAdmin)(nil).GetAuditRecords), ctx, target, limit)
+}
+
+// GetEmails mocks base method
+func (m *MockAdmin) GetEmails(ctx context.Context, owner string, limit int) ([]*storage.Email, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmails", ctx, owner, limit)
+	ret0, _ := ret[0].([]*storage.Email)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmails indicates an expected call of GetEmails
+func (mr *MockAdminMockRecorder) GetEmails(ctx, owner, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmails", reflect.TypeOf((*MockAdmin)(nil).GetEmails), ctx, owner, limit)
+}
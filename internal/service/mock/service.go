@@ -7,9 +7,11 @@ package mock
 import (
 	context "context"
 	referral "github.com/Decentr-net/vulcan/internal/referral"
+	service "github.com/Decentr-net/vulcan/internal/service"
 	storage "github.com/Decentr-net/vulcan/internal/storage"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockService is a mock of Service interface
@@ -50,17 +52,135 @@ func (mr *MockServiceMockRecorder) Register(ctx, email, address, referralCode in
 }
 
 // Confirm mocks base method
-func (m *MockService) Confirm(ctx context.Context, owner, code string) error {
+func (m *MockService) Confirm(ctx context.Context, email, code string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Confirm", ctx, owner, code)
+	ret := m.ctrl.Call(m, "Confirm", ctx, email, code)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Confirm indicates an expected call of Confirm
-func (mr *MockServiceMockRecorder) Confirm(ctx, owner, code interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) Confirm(ctx, email, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockService)(nil).Confirm), ctx, owner, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockService)(nil).Confirm), ctx, email, code)
+}
+
+// ConfirmLink mocks base method
+func (m *MockService) ConfirmLink(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmLink", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmLink indicates an expected call of ConfirmLink
+func (mr *MockServiceMockRecorder) ConfirmLink(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmLink", reflect.TypeOf((*MockService)(nil).ConfirmLink), ctx, token)
+}
+
+// Resend mocks base method
+func (m *MockService) Resend(ctx context.Context, email string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resend", ctx, email)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resend indicates an expected call of Resend
+func (mr *MockServiceMockRecorder) Resend(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resend", reflect.TypeOf((*MockService)(nil).Resend), ctx, email)
+}
+
+// IssueStatusProof mocks base method
+func (m *MockService) IssueStatusProof(ctx context.Context, email, address string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueStatusProof", ctx, email, address)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueStatusProof indicates an expected call of IssueStatusProof
+func (mr *MockServiceMockRecorder) IssueStatusProof(ctx, email, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueStatusProof", reflect.TypeOf((*MockService)(nil).IssueStatusProof), ctx, email, address)
+}
+
+// GetRegistrationStatusByAddress mocks base method
+func (m *MockService) GetRegistrationStatusByAddress(ctx context.Context, address string) (*service.RegistrationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationStatusByAddress", ctx, address)
+	ret0, _ := ret[0].(*service.RegistrationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationStatusByAddress indicates an expected call of GetRegistrationStatusByAddress
+func (mr *MockServiceMockRecorder) GetRegistrationStatusByAddress(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationStatusByAddress", reflect.TypeOf((*MockService)(nil).GetRegistrationStatusByAddress), ctx, address)
+}
+
+// GetRegistrationStatusByProof mocks base method
+func (m *MockService) GetRegistrationStatusByProof(ctx context.Context, proof string) (*service.RegistrationStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationStatusByProof", ctx, proof)
+	ret0, _ := ret[0].(*service.RegistrationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationStatusByProof indicates an expected call of GetRegistrationStatusByProof
+func (mr *MockServiceMockRecorder) GetRegistrationStatusByProof(ctx, proof interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationStatusByProof", reflect.TypeOf((*MockService)(nil).GetRegistrationStatusByProof), ctx, proof)
+}
+
+// IssueChallenge mocks base method
+func (m *MockService) IssueChallenge(ctx context.Context, address string) (string, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueChallenge", ctx, address)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IssueChallenge indicates an expected call of IssueChallenge
+func (mr *MockServiceMockRecorder) IssueChallenge(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueChallenge", reflect.TypeOf((*MockService)(nil).IssueChallenge), ctx, address)
+}
+
+// CheckChallenge mocks base method
+func (m *MockService) CheckChallenge(ctx context.Context, address, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckChallenge", ctx, address, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckChallenge indicates an expected call of CheckChallenge
+func (mr *MockServiceMockRecorder) CheckChallenge(ctx, address, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckChallenge", reflect.TypeOf((*MockService)(nil).CheckChallenge), ctx, address, nonce)
+}
+
+// ConsumeChallenge mocks base method
+func (m *MockService) ConsumeChallenge(ctx context.Context, address, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeChallenge", ctx, address, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeChallenge indicates an expected call of ConsumeChallenge
+func (mr *MockServiceMockRecorder) ConsumeChallenge(ctx, address, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeChallenge", reflect.TypeOf((*MockService)(nil).ConsumeChallenge), ctx, address, nonce)
 }
 
 // GetRegisterStats mocks base method
@@ -194,17 +314,3 @@ func (mr *MockServiceMockRecorder) RegisterTestnetAccount(ctx, address interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterTestnetAccount", reflect.TypeOf((*MockService)(nil).RegisterTestnetAccount), ctx, address)
 }
-
-// CheckRecaptcha mocks base method
-func (m *MockService) CheckRecaptcha(ctx context.Context, action, recaptchaResponse string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckRecaptcha", ctx, action, recaptchaResponse)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// CheckRecaptcha indicates an expected call of CheckRecaptcha
-func (mr *MockServiceMockRecorder) CheckRecaptcha(ctx, action, recaptchaResponse interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRecaptcha", reflect.TypeOf((*MockService)(nil).CheckRecaptcha), ctx, action, recaptchaResponse)
-}
@@ -3,45 +3,52 @@ package service
 
 import (
 	"context"
-	"crypto/md5" // nolint:gosec
 	"crypto/rand"
 	"database/sql"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"math/big"
 	"net/url"
-	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/Decentr-net/vulcan/internal/blockchain"
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/fraud"
 	"github.com/Decentr-net/vulcan/internal/mail"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/proof"
 	"github.com/Decentr-net/vulcan/internal/referral"
 	"github.com/Decentr-net/vulcan/internal/storage"
+	"github.com/Decentr-net/vulcan/internal/token"
 )
 
-const codeBytesSize = 3
 const throttlingInterval = time.Minute
 
+// linkSeparator separates owner and code in confirmation link tokens, neither of them contains it.
+// It separates fields of status proofs as well.
+const linkSeparator = "\n"
+
+const (
+	challengeNonceLength = 64
+	// challengeLimit is how many live challenges an address has, so issuing one doesn't invalidate
+	// the challenge another client is signing.
+	challengeLimit = 5
+	hexAlphabet    = "0123456789abcdef"
+)
+
 // nolint
 var giveStakesAmount = sdk.NewInt(1e9) // only for testnet
 
-var plustPartRegexp = regexp.MustCompile(`\+.+\@`) // nolint
-
 //go:generate mockgen -destination=./mock/service.go -package=mock -source=service.go
 
 // ErrAlreadyExists is returned when request is already created for requested email or address.
 var ErrAlreadyExists = fmt.Errorf("email or address is already taken")
 
-// ErrRecaptcha is returned when captcha isn't passed.
-var ErrRecaptcha = fmt.Errorf("recaptcha error")
-
 // ErrAlreadyConfirmed is returned when request is already confirmed.
 var ErrAlreadyConfirmed = fmt.Errorf("already confirmed")
 
@@ -51,6 +58,27 @@ var ErrRequestNotFound = fmt.Errorf("request not found")
 // ErrTooManyAttempts is returned when throttling interval didn't pass.
 var ErrTooManyAttempts = fmt.Errorf("too many attempts")
 
+// ErrCodeExpired is returned when confirmation code is expired.
+var ErrCodeExpired = fmt.Errorf("code is expired")
+
+// ErrConfirmationLocked is returned when too many wrong codes were sent for the request.
+var ErrConfirmationLocked = fmt.Errorf("confirmation is locked")
+
+// ErrResendLimit is returned when verification email is resent too many times, the user should register again.
+var ErrResendLimit = fmt.Errorf("resend limit is reached")
+
+// ErrInvalidProof is returned when status proof is invalid or expired.
+var ErrInvalidProof = fmt.Errorf("invalid proof")
+
+// ErrInvalidChallenge is returned when wallet ownership challenge doesn't match or is expired.
+var ErrInvalidChallenge = fmt.Errorf("invalid challenge")
+
+// ErrInvalidLink is returned when confirmation link token is invalid.
+var ErrInvalidLink = fmt.Errorf("invalid confirmation link")
+
+// ErrLinkExpired is returned when confirmation link code is replaced by a resend or a new registration.
+var ErrLinkExpired = fmt.Errorf("confirmation link is expired")
+
 // ErrReferralTrackingNotFound ...
 var ErrReferralTrackingNotFound = fmt.Errorf("referral tracking not found")
 
@@ -66,7 +94,23 @@ var ErrFraudEmail = fmt.Errorf("email from fraud domain")
 // Service ...
 type Service interface {
 	Register(ctx context.Context, email, address string, referralCode *string) error
-	Confirm(ctx context.Context, owner, code string) error
+	Confirm(ctx context.Context, email, code string) error
+	// ConfirmLink confirms the registration by token of the link sent with the verification email.
+	ConfirmLink(ctx context.Context, token string) error
+	// Resend reissues confirmation code and sends it again. It returns time the next resend is allowed at,
+	// zero time means no more resends are allowed. The time is returned with ErrTooManyAttempts as well.
+	Resend(ctx context.Context, email string) (time.Time, error)
+	// IssueStatusProof returns proof the status of the email's registration by the address can be queried with.
+	// The proof is bound to the request, so it's invalidated when the request is replaced.
+	IssueStatusProof(ctx context.Context, email, address string) (string, error)
+	GetRegistrationStatusByAddress(ctx context.Context, address string) (*RegistrationStatus, error)
+	GetRegistrationStatusByProof(ctx context.Context, proof string) (*RegistrationStatus, error)
+	// IssueChallenge returns nonce the address owner signs to prove wallet ownership and its expiration time.
+	IssueChallenge(ctx context.Context, address string) (string, time.Time, error)
+	// CheckChallenge checks the nonce is issued for the address and isn't used yet.
+	CheckChallenge(ctx context.Context, address, nonce string) error
+	// ConsumeChallenge checks the nonce is issued for the address and invalidates it.
+	ConsumeChallenge(ctx context.Context, address, nonce string) error
 	GetRegisterStats(ctx context.Context) ([]*storage.RegisterStats, int, error)
 	GetOwnReferralCode(ctx context.Context, address string) (string, error)
 	GetReferralConfig() referral.Config
@@ -77,41 +121,102 @@ type Service interface {
 	ListDloanRequests(ctx context.Context, take, skip int) ([]*storage.DLoan, error)
 
 	RegisterTestnetAccount(ctx context.Context, address string) error
+}
+
+// RegistrationState ...
+type RegistrationState string
+
+const (
+	// PendingRegistrationState means the registration isn't confirmed yet.
+	PendingRegistrationState RegistrationState = "pending"
+	// ConfirmedRegistrationState means the registration is confirmed without payout, e.g. in testnet.
+	ConfirmedRegistrationState RegistrationState = "confirmed"
+	// PayoutPendingRegistrationState means the registration is confirmed and initial stakes aren't sent yet.
+	PayoutPendingRegistrationState RegistrationState = "payout_pending"
+	// PaidRegistrationState means initial stakes are sent.
+	PaidRegistrationState RegistrationState = "paid"
+	// FailedRegistrationState means initial stakes payout is failed.
+	FailedRegistrationState RegistrationState = "failed"
+)
+
+// RegistrationStatus ...
+type RegistrationStatus struct {
+	State       RegistrationState
+	Address     string
+	CreatedAt   time.Time
+	ConfirmedAt sql.NullTime
+	// PaidAt is set when the payout is committed.
+	PaidAt sql.NullTime
+	TxHash sql.NullString
+	// NextResendAt is zero if the confirmation code can't be resent anymore.
+	NextResendAt time.Time
+}
 
-	CheckRecaptcha(ctx context.Context, action, recaptchaResponse string) error
+// CodeConfig contains confirmation code settings.
+type CodeConfig struct {
+	// Length is a number of code symbols.
+	Length int
+	// Alphabet contains symbols code consists of.
+	Alphabet string
+	// TTL is a code lifetime, zero means the code never expires.
+	TTL time.Duration
+	// MaxAttempts is a number of failed confirmations before the request is locked, zero means no limit.
+	MaxAttempts int
+	// ResendInterval is a minimal interval between code resends.
+	ResendInterval time.Duration
+	// MaxResends is a number of resends per registration, zero means no limit.
+	MaxResends int
+	// LinkURL is an url of the confirmation link endpoint, verification emails go without link if it's empty.
+	LinkURL string
 }
 
 // Service ...
 type service struct {
 	storage storage.Storage
 	sender  mail.Sender
-	bc      blockchain.Blockchain
+	fraud   fraud.Checker
+	hasher  *owner.Hasher
+	keyring *envelope.Keyring
+	proofs  *proof.Signer
+	links   *proof.Signer
 
-	rc              referral.Config
-	recaptchaSecret string
+	rc referral.Config
 
 	initialStakes sdk.Int
 	initialMemo   string
+
+	codes        CodeConfig
+	challengeTTL time.Duration
 }
 
 // New creates new instance of service.
 func New(
 	storage storage.Storage,
 	sender mail.Sender,
-	bc blockchain.Blockchain,
+	fraud fraud.Checker,
+	hasher *owner.Hasher,
+	keyring *envelope.Keyring,
+	proofs *proof.Signer,
+	links *proof.Signer,
 	initialStakes sdk.Int,
 	initialMemo string,
 	rc referral.Config,
-	recaptchaSecret string,
+	codes CodeConfig,
+	challengeTTL time.Duration,
 ) Service {
 	s := &service{
-		storage:         storage,
-		sender:          sender,
-		bc:              bc,
-		rc:              rc,
-		recaptchaSecret: recaptchaSecret,
-		initialStakes:   initialStakes,
-		initialMemo:     initialMemo,
+		storage:       storage,
+		sender:        sender,
+		fraud:         fraud,
+		hasher:        hasher,
+		keyring:       keyring,
+		proofs:        proofs,
+		links:         links,
+		rc:            rc,
+		initialStakes: initialStakes,
+		initialMemo:   initialMemo,
+		codes:         codes,
+		challengeTTL:  challengeTTL,
 	}
 
 	return s
@@ -122,22 +227,42 @@ func (s *service) GetReferralConfig() referral.Config {
 }
 
 func (s *service) Register(ctx context.Context, email, address string, referralCode *string) error {
-	var (
-		owner = getEmailHash(truncatePlusPart(email))
-		code  = randomCode()
-	)
+	owner := s.hasher.Hash(email)
 
-	if err := s.checkRegistrationConflicts(ctx, email, address); err != nil {
+	existing, err := s.checkRegistrationConflicts(ctx, email, address)
+	if err != nil {
 		return err
 	}
 
-	isFraud, err := s.storage.DoesEmailHaveFraudDomain(ctx, email)
+	code, err := randomCode(s.codes.Length, s.codes.Alphabet)
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	hashedCode, err := token.Hash(code)
+	if err != nil {
+		return fmt.Errorf("failed to hash code: %w", err)
+	}
+
+	verdict, err := s.fraud.Check(ctx, email)
 	if err != nil {
 		return fmt.Errorf("failed to check for fraud: %w", err)
 	}
 
-	if isFraud {
-		return ErrFraudEmail
+	if verdict != nil {
+		rejection := &storage.FraudRejection{
+			Reason:  verdict.Reason,
+			Domain:  fraud.Domain(email),
+			Address: address,
+		}
+		if verdict.Rule != nil {
+			rejection.RuleID = sql.NullInt64{Valid: true, Int64: verdict.Rule.ID}
+		}
+		if err := s.storage.CreateFraudRejection(ctx, rejection); err != nil {
+			log.WithError(err).Error("failed to create fraud rejection")
+		}
+
+		return fmt.Errorf("%w: %s", ErrFraudEmail, verdict.Reason)
 	}
 
 	var referralCodeAsNullString sql.NullString
@@ -163,14 +288,26 @@ func (s *service) Register(ctx context.Context, email, address string, referralC
 		}
 	}
 
-	if err := s.storage.UpsertRequest(ctx, owner, email, address, code, referralCodeAsNullString); err != nil {
+	encryptedEmail, err := s.keyring.Encrypt(email)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt email: %w", err)
+	}
+
+	// the request is stored with a previous owner key, it's moved to the current one to be replaced
+	if existing != nil && existing.Owner != owner {
+		if err := s.storage.UpdateRequestOwners(ctx, map[string]string{existing.Owner: owner}); err != nil {
+			return fmt.Errorf("failed to update request owner: %w", err)
+		}
+	}
+
+	if err := s.storage.UpsertRequest(ctx, owner, encryptedEmail, address, hashedCode, referralCodeAsNullString); err != nil {
 		if errors.Is(err, storage.ErrAddressIsTaken) {
 			return ErrAlreadyExists
 		}
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	s.sender.SendVerificationEmailAsync(ctx, email, code)
+	s.sender.SendVerificationEmailAsync(ctx, owner, encryptedEmail, code, s.confirmationLink(owner, code))
 
 	return nil
 }
@@ -198,38 +335,225 @@ func (s *service) ListDloanRequests(ctx context.Context, take, skip int) ([]*sto
 	return s.storage.GetDLoans(ctx, take, skip)
 }
 
-func (s *service) checkRegistrationConflicts(ctx context.Context, email, address string) error {
+func (s *service) Resend(ctx context.Context, email string) (time.Time, error) {
+	req, err := s.storage.GetRequestByOwner(ctx, s.hasher.Owners(email)...)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return time.Time{}, ErrRequestNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	if req.ConfirmedAt.Valid {
+		return time.Time{}, ErrAlreadyConfirmed
+	}
+
+	if s.codes.MaxResends > 0 && req.ResendCount >= s.codes.MaxResends {
+		return time.Time{}, ErrResendLimit
+	}
+
+	if next := req.CodeSentAt.Add(s.codes.ResendInterval); next.After(time.Now()) {
+		return next, ErrTooManyAttempts
+	}
+
+	code, err := randomCode(s.codes.Length, s.codes.Alphabet)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	hashedCode, err := token.Hash(code)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to hash code: %w", err)
+	}
+
+	if err := s.storage.ReissueCode(ctx, req.Owner, hashedCode, req.ResendCount); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// the request is confirmed or resent concurrently
+			return time.Now().Add(s.codes.ResendInterval), ErrTooManyAttempts
+		}
+		return time.Time{}, fmt.Errorf("failed to reissue code: %w", err)
+	}
+
+	s.sender.SendVerificationEmailAsync(ctx, req.Owner, req.Email, code, s.confirmationLink(req.Owner, code))
+
+	if s.codes.MaxResends > 0 && req.ResendCount+1 >= s.codes.MaxResends {
+		return time.Time{}, nil
+	}
+
+	return time.Now().Add(s.codes.ResendInterval), nil
+}
+
+func (s *service) IssueChallenge(ctx context.Context, address string) (string, time.Time, error) {
+	nonce, err := randomCode(challengeNonceLength, hexAlphabet)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	expiresAt, err := s.storage.CreateChallenge(ctx, address, nonce, s.challengeTTL, challengeLimit)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create challenge: %w", err)
+	}
+
+	return nonce, expiresAt, nil
+}
+
+func (s *service) CheckChallenge(ctx context.Context, address, nonce string) error {
+	if err := s.storage.CheckChallenge(ctx, address, nonce); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrInvalidChallenge
+		}
+		return fmt.Errorf("failed to check challenge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) ConsumeChallenge(ctx context.Context, address, nonce string) error {
+	if err := s.storage.ConsumeChallenge(ctx, address, nonce); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrInvalidChallenge
+		}
+		return fmt.Errorf("failed to consume challenge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) IssueStatusProof(ctx context.Context, email, address string) (string, error) {
+	req, err := s.storage.GetRequestByOwner(ctx, s.hasher.Owners(email)...)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", ErrRequestNotFound
+		}
+		return "", fmt.Errorf("failed to get request: %w", err)
+	}
+
+	// the request can be replaced by a concurrent registration, its status isn't exposed then
+	if req.Address != address {
+		return "", ErrRequestNotFound
+	}
+
+	return s.proofs.Sign(statusProofSubject(req)), nil
+}
+
+func (s *service) GetRegistrationStatusByAddress(ctx context.Context, address string) (*RegistrationStatus, error) {
+	req, err := s.storage.GetRequestByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return s.getRegistrationStatus(ctx, req)
+}
+
+func (s *service) GetRegistrationStatusByProof(ctx context.Context, p string) (*RegistrationStatus, error) {
+	subject, err := s.proofs.Verify(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProof, err)
+	}
+
+	parts := strings.SplitN(subject, linkSeparator, 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed subject", ErrInvalidProof)
+	}
+
+	req, err := s.storage.GetRequestByOwner(ctx, parts[2])
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	if statusProofSubject(req) != subject {
+		return nil, fmt.Errorf("%w: request is replaced", ErrInvalidProof)
+	}
+
+	return s.getRegistrationStatus(ctx, req)
+}
+
+// statusProofSubject binds the proof to the request's creation time and address,
+// so the proof is invalidated when the request is replaced.
+func statusProofSubject(req *storage.Request) string {
+	return strings.Join([]string{strconv.FormatInt(req.CreatedAt.UnixNano(), 10), req.Address, req.Owner}, linkSeparator)
+}
+
+func (s *service) getRegistrationStatus(ctx context.Context, req *storage.Request) (*RegistrationStatus, error) {
+	status := &RegistrationStatus{
+		State:       PendingRegistrationState,
+		Address:     req.Address,
+		CreatedAt:   req.CreatedAt,
+		ConfirmedAt: req.ConfirmedAt,
+	}
+
+	if !req.ConfirmedAt.Valid {
+		if s.codes.MaxResends == 0 || req.ResendCount < s.codes.MaxResends {
+			status.NextResendAt = req.CodeSentAt.Add(s.codes.ResendInterval)
+		}
+		return status, nil
+	}
+
+	p, err := s.storage.GetRegistrationPayout(ctx, req.Owner)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			status.State = ConfirmedRegistrationState
+			return status, nil
+		}
+		return nil, fmt.Errorf("failed to get payout: %w", err)
+	}
+
+	status.TxHash = p.TxHash
+
+	switch p.Status {
+	case storage.CommittedPayoutStatus:
+		status.State = PaidRegistrationState
+		status.PaidAt = sql.NullTime{Valid: true, Time: p.UpdatedAt}
+	case storage.FailedPayoutStatus:
+		status.State = FailedRegistrationState
+	default:
+		status.State = PayoutPendingRegistrationState
+	}
+
+	return status, nil
+}
+
+// checkRegistrationConflicts returns request of the email's mailbox if it can be replaced with a new one.
+func (s *service) checkRegistrationConflicts(ctx context.Context, email, address string) (*storage.Request, error) {
+	owners := s.hasher.Owners(email)
+
 	r, err := s.storage.GetRequestByAddress(ctx, address)
 	if err != nil {
 		if !errors.Is(err, storage.ErrNotFound) {
-			return fmt.Errorf("failed to check conflicts: %w", err)
+			return nil, fmt.Errorf("failed to check conflicts: %w", err)
 		}
 
-		if r, err = s.storage.GetRequestByOwner(ctx, getEmailHash(truncatePlusPart(email))); err != nil && !errors.Is(err, storage.ErrNotFound) {
-			return fmt.Errorf("failed to check conflicts: %w", err)
+		if r, err = s.storage.GetRequestByOwner(ctx, owners...); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("failed to check conflicts: %w", err)
 		}
 	}
 
 	if errors.Is(err, storage.ErrNotFound) {
-		return nil
+		return nil, nil
 	}
 
-	if r.Email != email {
-		return fmt.Errorf("%w: address is already taken", ErrAlreadyExists)
+	if !contains(owners, r.Owner) {
+		return nil, fmt.Errorf("%w: address is already taken", ErrAlreadyExists)
 	}
 
 	if r.CreatedAt.Add(throttlingInterval).After(time.Now()) {
-		return ErrTooManyAttempts
+		return nil, ErrTooManyAttempts
 	}
 	if r.ConfirmedAt.Valid {
-		return ErrAlreadyExists
+		return nil, ErrAlreadyExists
 	}
 
-	return nil
+	return r, nil
 }
 
 func (s *service) Confirm(ctx context.Context, email, code string) error {
-	req, err := s.storage.GetRequestByOwner(ctx, getEmailHash(truncatePlusPart(email)))
+	req, err := s.storage.GetRequestByOwner(ctx, s.hasher.Owners(email)...)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return ErrRequestNotFound
@@ -237,31 +561,98 @@ func (s *service) Confirm(ctx context.Context, email, code string) error {
 		return fmt.Errorf("failed to check request: %w", err)
 	}
 
+	return s.confirm(ctx, req, code, false)
+}
+
+func (s *service) ConfirmLink(ctx context.Context, t string) error {
+	if s.links == nil {
+		return ErrInvalidLink
+	}
+
+	v, err := s.links.Verify(t)
+	if err != nil {
+		if errors.Is(err, proof.ErrExpired) {
+			return ErrCodeExpired
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidLink, err)
+	}
+
+	parts := strings.SplitN(v, linkSeparator, 2)
+	if len(parts) != 2 {
+		return ErrInvalidLink
+	}
+
+	req, err := s.storage.GetRequestByOwner(ctx, parts[0])
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrRequestNotFound
+		}
+		return fmt.Errorf("failed to check request: %w", err)
+	}
+
+	return s.confirm(ctx, req, parts[1], true)
+}
+
+// confirm checks the code and confirms the request.
+// The code of a signed link can't be guessed, so its mismatch means the code is replaced and isn't a failed attempt.
+func (s *service) confirm(ctx context.Context, req *storage.Request, code string, signed bool) error {
 	if req.ConfirmedAt.Valid {
 		return ErrAlreadyConfirmed
 	}
 
-	if req.Code != code {
-		return ErrRequestNotFound
+	if s.codes.MaxAttempts > 0 && req.FailedAttempts >= s.codes.MaxAttempts {
+		return ErrConfirmationLocked
 	}
 
-	if err := s.bc.SendStakes([]blockchain.Stake{{Address: req.Address, Amount: s.initialStakes}}, s.initialMemo); err != nil {
-		return fmt.Errorf("failed to send stakes to %s on mainnet: %w", req.Address, err)
+	if s.codes.TTL > 0 && req.CodeSentAt.Add(s.codes.TTL).Before(time.Now()) {
+		return ErrCodeExpired
 	}
 
-	s.sender.SendWelcomeEmailAsync(ctx, req.Email)
+	if !token.Compare(req.Code, code) {
+		if signed {
+			return ErrLinkExpired
+		}
 
-	req.ConfirmedAt = sql.NullTime{
-		Time:  time.Now(),
-		Valid: true,
+		attempts, err := s.storage.IncrementFailedAttempts(ctx, req.Owner)
+		if err != nil {
+			return fmt.Errorf("failed to increment failed attempts: %w", err)
+		}
+
+		if s.codes.MaxAttempts > 0 && attempts >= s.codes.MaxAttempts {
+			log.WithField("owner", req.Owner).Warn("confirmation locked")
+			return ErrConfirmationLocked
+		}
+
+		return ErrRequestNotFound
 	}
 
-	if err := s.storage.SetConfirmed(ctx, req.Owner); err != nil {
-		return fmt.Errorf("failed to update request: %w", err)
+	// stakes are sent by payout worker, so the request can't be paid twice
+	if err := s.storage.InTx(ctx, func(st storage.Storage) error {
+		if err := st.SetConfirmed(ctx, req.Owner); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return ErrAlreadyConfirmed
+			}
+			return fmt.Errorf("failed to update request: %w", err)
+		}
+
+		if err := st.CreatePayout(ctx, &storage.Payout{
+			Address: req.Address,
+			Amount:  s.initialStakes,
+			Memo:    s.initialMemo,
+			Purpose: storage.RegistrationPayoutPurpose,
+			Owner:   sql.NullString{Valid: true, String: req.Owner},
+		}); err != nil {
+			return fmt.Errorf("failed to create payout: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
+	s.sender.SendWelcomeEmailAsync(ctx, req.Owner, req.Email)
+
 	logger := log.WithFields(log.Fields{
-		"code":          req.Code,
 		"address":       req.Address,
 		"created_at":    req.CreatedAt,
 		"email":         req.Email,
@@ -378,71 +769,21 @@ func (s *service) GetRegisterStats(ctx context.Context) ([]*storage.RegisterStat
 }
 
 func (s *service) RegisterTestnetAccount(ctx context.Context, address string) error {
-	if err := s.bc.SendStakes([]blockchain.Stake{
-		{
+	return s.storage.InTx(ctx, func(st storage.Storage) error {
+		if err := st.CreateTestnetConfirmedRequest(ctx, address); err != nil {
+			return fmt.Errorf("failed to create confirmed request: %w", err)
+		}
+
+		if err := st.CreatePayout(ctx, &storage.Payout{
 			Address: address,
 			Amount:  giveStakesAmount,
-		},
-	}, ""); err != nil {
-		return fmt.Errorf("failed to give stakes: %w", err)
-	}
-
-	if err := s.storage.CreateTestnetConfirmedRequest(ctx, address); err != nil {
-		return fmt.Errorf("failed to create confirmed request: %w", err)
-	}
-
-	return nil
-}
-
-func (s *service) CheckRecaptcha(ctx context.Context, action, recaptchaResponse string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://www.google.com/recaptcha/api/siteverify", nil)
-	if err != nil {
-		return err
-	}
-
-	// Add necessary request parameters.
-	q := url.Values{}
-	q.Add("secret", s.recaptchaSecret)
-	q.Add("response", recaptchaResponse)
-	req.URL.RawQuery = q.Encode()
-
-	// Make request
-	c := http.Client{Timeout: time.Second * 5}
-	resp, err := c.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close() // nolint
-
-	// Decode response.
-	var body struct {
-		Success     bool      `json:"success"`
-		Score       float64   `json:"score"`
-		Action      string    `json:"action"`
-		ChallengeTS time.Time `json:"challenge_ts"`
-		Hostname    string    `json:"hostname"`
-		ErrorCodes  []string  `json:"error-codes"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return fmt.Errorf("failed to unmarshal recaptcha response: %w", err)
-	}
-
-	// Check recaptcha verification success.
-	if !body.Success {
-		return fmt.Errorf("%w: unsuccessful recaptcha verify request", ErrRecaptcha)
-	}
-
-	// Check response score.
-	if body.Score < 0.8 {
-		return fmt.Errorf("%w: lower received score than expected", ErrRecaptcha)
-	}
-
-	// Check response action.
-	if body.Action != action {
-		return fmt.Errorf("%w: mismatched recaptcha action", ErrRecaptcha)
-	}
+			Purpose: storage.TestnetPayoutPurpose,
+		}); err != nil {
+			return fmt.Errorf("failed to create payout: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func transformStatsAsGrowth(stats []*storage.RegisterStats, total int) {
@@ -457,18 +798,43 @@ func transformStatsAsGrowth(stats []*storage.RegisterStats, total int) {
 	}
 }
 
-func truncatePlusPart(email string) string {
-	return plustPartRegexp.ReplaceAllString(email, "@")
+// confirmationLink returns link confirming the request with the code, the token is signed owner and code.
+func (s *service) confirmationLink(owner, code string) string {
+	if s.links == nil || s.codes.LinkURL == "" {
+		return ""
+	}
+
+	sep := "?"
+	if strings.Contains(s.codes.LinkURL, "?") {
+		sep = "&"
+	}
+
+	return s.codes.LinkURL + sep + "token=" + url.QueryEscape(s.links.Sign(owner+linkSeparator+code))
 }
 
-func getEmailHash(email string) string {
-	b := md5.Sum([]byte(strings.ToLower(email))) // nolint:gosec
-	return hex.EncodeToString(b[:])
+func randomCode(length int, alphabet string) (string, error) {
+	var (
+		b   = make([]byte, length)
+		max = big.NewInt(int64(len(alphabet)))
+	)
+
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random: %w", err)
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+
+	return string(b), nil
 }
 
-func randomCode() string {
-	b := make([]byte, codeBytesSize)
-	_, _ = rand.Read(b)
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
 
-	return hex.EncodeToString(b)
+	return false
 }
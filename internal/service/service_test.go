@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,98 +16,199 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/Decentr-net/vulcan/internal/blockchain"
-	blockchainmock "github.com/Decentr-net/vulcan/internal/blockchain/mock"
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/fraud"
+	fraudmock "github.com/Decentr-net/vulcan/internal/fraud/mock"
 	mailmock "github.com/Decentr-net/vulcan/internal/mail/mock"
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/proof"
 	"github.com/Decentr-net/vulcan/internal/storage"
 	storagemock "github.com/Decentr-net/vulcan/internal/storage/mock"
+	"github.com/Decentr-net/vulcan/internal/token"
 )
 
 var (
 	errTest     = fmt.Errorf("test")
-	testOwner   = "9790d13a4778f68308977117dd470bb4"
 	testAddress = "decentr1vg085ra5hw8mx5rrheqf8fruks0xv4urqkuqga"
 	testEmail   = "decentr@decentr.xyz"
 	testCode    = "1234"
 
 	initialStakes = sdk.NewInt(100)
+
+	testPayout = &storage.Payout{
+		Address: testAddress,
+		Amount:  initialStakes,
+		Purpose: storage.RegistrationPayoutPurpose,
+		Owner:   sql.NullString{Valid: true, String: testOwner},
+	}
+
+	testHasher = newTestHasher()
+	testOwner  = testHasher.Hash(testEmail)
+
+	testKeyring = newTestKeyring()
+
+	testCodes = CodeConfig{
+		Length:         6,
+		Alphabet:       "0123456789abcdef",
+		TTL:            time.Hour,
+		MaxAttempts:    3,
+		ResendInterval: time.Minute,
+		MaxResends:     3,
+	}
 )
 
+func newTestHasher() *owner.Hasher {
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
+
+	h, err := owner.NewHasher(n, []owner.Key{{ID: "test", Secret: []byte("0123456789abcdef0123456789abcdef")}}, false)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+func newTestKeyring() *envelope.Keyring {
+	k, err := envelope.NewKeyring([]envelope.Key{{ID: "test", Secret: []byte("0123456789abcdef0123456789abcdef")}})
+	if err != nil {
+		panic(err)
+	}
+
+	return k
+}
+
+// encrypted matches values which are encrypted with testKeyring.
+type encrypted string
+
+func (e encrypted) Matches(x interface{}) bool {
+	s, ok := x.(string)
+	if !ok || !envelope.IsEncrypted(s) {
+		return false
+	}
+
+	v, err := testKeyring.Decrypt(s)
+	return err == nil && v == string(e)
+}
+
+func (e encrypted) String() string {
+	return "is encrypted " + string(e)
+}
+
 func TestService_Register(t *testing.T) {
 	tt := []struct {
 		name          string
-		mockSetupFunc func(s *storagemock.MockStorage, m *mailmock.MockSender)
+		mockSetupFunc func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker)
 		err           error
 	}{
 		{
 			name: "success",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
-				s.EXPECT().DoesEmailHaveFraudDomain(gomock.Any(), testEmail).Return(false, nil)
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, nil)
 				var code string
-				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, testEmail, testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).DoAndReturn(
+				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).DoAndReturn(
 					func(_ context.Context, _, _, _, c string, _ sql.NullString) error {
 						code = c
 						return nil
 					},
 				)
-				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testEmail, gomock.Any()).Do(func(_ context.Context, _, c string) {
-					assert.Equal(t, code, c)
+				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, encrypted(testEmail), gomock.Any(), "").Do(func(_ context.Context, _, _, c, _ string) {
+					assert.True(t, token.IsHashed(code))
+					assert.True(t, token.Compare(code, c))
 				})
 			},
 		},
 		{
 			name: "already registered",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(&storage.Request{Owner: testOwner, ConfirmedAt: sql.NullTime{Valid: true}}, nil)
 			},
 			err: ErrAlreadyExists,
 		},
 		{
 			name: "already registered#2",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{Owner: testOwner, ConfirmedAt: sql.NullTime{Valid: true}}, nil)
 			},
 			err: ErrAlreadyExists,
 		},
+		{
+			name: "address is taken by another email",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(&storage.Request{Owner: "test:another"}, nil)
+			},
+			err: ErrAlreadyExists,
+		},
 		{
 			name: "too many attempts",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{Owner: getEmailHash(testEmail), Email: testEmail, CreatedAt: time.Now()}, nil)
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{Owner: testOwner, Email: testEmail, CreatedAt: time.Now()}, nil)
 			},
 			err: ErrTooManyAttempts,
 		},
 		{
 			name: "not confirmed request already exists",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
-				s.EXPECT().DoesEmailHaveFraudDomain(gomock.Any(), testEmail).Return(false, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, nil)
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{Owner: getEmailHash(testEmail), Email: testEmail, Address: testAddress, Code: testCode}, nil)
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{Owner: testOwner, Email: testEmail, Address: testAddress, Code: testCode}, nil)
 				var code string
-				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, testEmail, testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).DoAndReturn(
+				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).DoAndReturn(
 					func(_ context.Context, _, _, _, c string, _ sql.NullString) error {
 						code = c
 						return nil
 					},
 				)
-				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testEmail, gomock.Any()).Do(func(_ context.Context, _, c string) {
-					assert.Equal(t, code, c)
+				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, encrypted(testEmail), gomock.Any(), "").Do(func(_ context.Context, _, _, c, _ string) {
+					assert.True(t, token.IsHashed(code))
+					assert.True(t, token.Compare(code, c))
 				})
 			},
 		},
+		{
+			name: "fraud email",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(&fraud.Verdict{
+					Rule:   &storage.FraudRule{ID: 1, Kind: storage.ExactFraudRuleKind, Pattern: "decentr.xyz"},
+					Reason: "domain is decentr.xyz",
+				}, nil)
+				s.EXPECT().CreateFraudRejection(gomock.Any(), &storage.FraudRejection{
+					RuleID:  sql.NullInt64{Valid: true, Int64: 1},
+					Reason:  "domain is decentr.xyz",
+					Domain:  "decentr.xyz",
+					Address: testAddress,
+				})
+			},
+			err: ErrFraudEmail,
+		},
+		{
+			name: "fraud check failed",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, errTest)
+			},
+			err: errTest,
+		},
 		{
 			name: "getByAddressFailed",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, errTest)
 			},
 			err: errTest,
 		},
 		{
 			name: "getByOwnerFailed",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, errTest)
 			},
@@ -113,32 +216,32 @@ func TestService_Register(t *testing.T) {
 		},
 		{
 			name: "errAddressIsBusy",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
-				s.EXPECT().DoesEmailHaveFraudDomain(gomock.Any(), testEmail).Return(false, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, nil)
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
-				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, testEmail, testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(storage.ErrAddressIsTaken)
+				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(storage.ErrAddressIsTaken)
 			},
 			err: ErrAlreadyExists,
 		},
 		{
 			name: "setFailed",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
-				s.EXPECT().DoesEmailHaveFraudDomain(gomock.Any(), testEmail).Return(false, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, nil)
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
-				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, testEmail, testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(errTest)
+				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(errTest)
 			},
 			err: errTest,
 		},
 		{
 			name: "senderFailed",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
-				s.EXPECT().DoesEmailHaveFraudDomain(gomock.Any(), testEmail).Return(false, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, f *fraudmock.MockChecker) {
+				f.EXPECT().Check(gomock.Any(), testEmail).Return(nil, nil)
 				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
-				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, testEmail, testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(nil)
-				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testEmail, gomock.Any())
+				s.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Not(gomock.Len(0)), sql.NullString{}).Return(nil)
+				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, encrypted(testEmail), gomock.Any(), "")
 			},
 			err: nil,
 		},
@@ -154,16 +257,21 @@ func TestService_Register(t *testing.T) {
 
 			st := storagemock.NewMockStorage(ctrl)
 			sender := mailmock.NewMockSender(ctrl)
+			fc := fraudmock.NewMockChecker(ctrl)
 
 			ctx := context.Background()
 
 			s := &service{
 				storage:       st,
+				hasher:        testHasher,
+				keyring:       testKeyring,
 				sender:        sender,
+				fraud:         fc,
 				initialStakes: initialStakes,
+				codes:         testCodes,
 			}
 
-			tc.mockSetupFunc(st, sender)
+			tc.mockSetupFunc(st, sender, fc)
 
 			assert.True(t, errors.Is(s.Register(ctx, testEmail, testAddress, nil), tc.err))
 			time.Sleep(100 * time.Millisecond)
@@ -171,6 +279,44 @@ func TestService_Register(t *testing.T) {
 	}
 }
 
+func TestService_Register_PreviousOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+	sender := mailmock.NewMockSender(ctrl)
+	fc := fraudmock.NewMockChecker(ctrl)
+
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	require.NoError(t, err)
+	previous, err := owner.NewHasher(n, []owner.Key{{ID: "old", Secret: []byte("abcdef0123456789abcdef0123456789")}}, false)
+	require.NoError(t, err)
+	hasher, err := owner.NewHasher(n, []owner.Key{
+		{ID: "test", Secret: []byte("0123456789abcdef0123456789abcdef")},
+		{ID: "old", Secret: []byte("abcdef0123456789abcdef0123456789")},
+	}, false)
+	require.NoError(t, err)
+
+	previousOwner := previous.Hash(testEmail)
+
+	st.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
+	st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner, previousOwner).Return(&storage.Request{Owner: previousOwner, Address: testAddress}, nil)
+	fc.EXPECT().Check(gomock.Any(), testEmail)
+	st.EXPECT().UpdateRequestOwners(gomock.Any(), map[string]string{previousOwner: testOwner})
+	st.EXPECT().UpsertRequest(gomock.Any(), testOwner, encrypted(testEmail), testAddress, gomock.Any(), sql.NullString{})
+	sender.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, encrypted(testEmail), gomock.Any(), "")
+
+	s := &service{
+		storage: st,
+		hasher:  hasher,
+		keyring: testKeyring,
+		sender:  sender,
+		fraud:   fc,
+		codes:   testCodes,
+	}
+
+	require.NoError(t, s.Register(context.Background(), testEmail, testAddress, nil))
+}
+
 func TestService_GetReferralCode(t *testing.T) {
 	tt := []struct {
 		name   string
@@ -200,6 +346,7 @@ func TestService_GetReferralCode(t *testing.T) {
 
 			s := &service{
 				storage:       st,
+				hasher:        testHasher,
 				initialStakes: initialStakes,
 			}
 
@@ -214,83 +361,154 @@ func TestService_GetReferralCode(t *testing.T) {
 }
 
 func TestService_Confirm(t *testing.T) {
+	request := func(code string) *storage.Request {
+		return &storage.Request{
+			Owner:      testOwner,
+			Email:      testEmail,
+			Address:    testAddress,
+			Code:       code,
+			CreatedAt:  time.Now(),
+			CodeSentAt: time.Now(),
+		}
+	}
+
+	inTx := func(s *storagemock.MockStorage) {
+		s.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+			return f(s)
+		})
+	}
+
 	tt := []struct {
 		name          string
-		mockSetupFunc func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain)
+		mockSetupFunc func(s *storagemock.MockStorage, m *mailmock.MockSender)
 		err           error
 	}{
 		{
 			name: "success",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
-					Owner:   testOwner,
-					Email:   testEmail,
-					Address: testAddress,
-					Code:    testCode,
-				}, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(testCode), nil)
 
-				bc.EXPECT().SendStakes([]blockchain.Stake{
-					{Address: testAddress, Amount: initialStakes},
-				}, "").Return(nil)
-				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testEmail)
+				inTx(s)
 				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), testPayout).Return(nil)
+				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testOwner, testEmail)
+			},
+		},
+		{
+			name: "success with hashed code",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				code, err := token.Hash(testCode)
+				require.NoError(t, err)
+
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(code), nil)
+
+				inTx(s)
+				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), testPayout).Return(nil)
+				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testOwner, testEmail)
+			},
+		},
+		{
+			name: "success with referral",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request(testCode)
+				r.RegistrationReferralCode = sql.NullString{Valid: true, String: "referral"}
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+
+				inTx(s)
+				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), testPayout).Return(nil)
+				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testOwner, testEmail)
+				s.EXPECT().CreateReferralTracking(gomock.Any(), testAddress, "referral").Return(storage.ErrReferralTrackingExists)
 			},
 		},
 		{
 			name: "not found",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
 			},
 			err: ErrRequestNotFound,
 		},
+		{
+			name: "already confirmed",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request(testCode)
+				r.ConfirmedAt = sql.NullTime{Valid: true, Time: time.Now()}
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+			},
+			err: ErrAlreadyConfirmed,
+		},
+		{
+			name: "confirmed concurrently",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(testCode), nil)
+
+				inTx(s)
+				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(storage.ErrNotFound)
+			},
+			err: ErrAlreadyConfirmed,
+		},
 		{
 			name: "wrong code",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
-					Owner:   testOwner,
-					Email:   testEmail,
-					Address: testAddress,
-					Code:    "wrong",
-				}, nil)
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request("wrong"), nil)
+				s.EXPECT().IncrementFailedAttempts(gomock.Any(), testOwner).Return(1, nil)
 			},
 			err: ErrRequestNotFound,
 		},
+		{
+			name: "wrong code locks",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request("wrong")
+				r.FailedAttempts = 2
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+				s.EXPECT().IncrementFailedAttempts(gomock.Any(), testOwner).Return(3, nil)
+			},
+			err: ErrConfirmationLocked,
+		},
+		{
+			name: "locked",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request(testCode)
+				r.FailedAttempts = 3
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+			},
+			err: ErrConfirmationLocked,
+		},
+		{
+			name: "expired",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request(testCode)
+				r.CodeSentAt = time.Now().Add(-2 * time.Hour)
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+			},
+			err: ErrCodeExpired,
+		},
 		{
 			name: "check error",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
 				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, errTest)
 			},
 			err: errTest,
 		},
 		{
-			name: "send error",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
-					Owner:   testOwner,
-					Email:   testEmail,
-					Address: testAddress,
-					Code:    testCode,
-				}, nil)
-				bc.EXPECT().SendStakes([]blockchain.Stake{
-					{Address: testAddress, Amount: initialStakes},
-				}, "").Return(errTest)
+			name: "set error",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(testCode), nil)
+
+				inTx(s)
+				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(errTest)
 			},
 			err: errTest,
 		},
 		{
-			name: "set error",
-			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender, bc *blockchainmock.MockBlockchain) {
-				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
-					Owner:   testOwner,
-					Email:   testEmail,
-					Address: testAddress,
-					Code:    testCode,
-				}, nil)
-				bc.EXPECT().SendStakes([]blockchain.Stake{
-					{Address: testAddress, Amount: initialStakes},
-				}, "").Return(nil)
-				m.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testEmail)
-				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(errTest)
+			name: "payout error",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(testCode), nil)
+
+				inTx(s)
+				s.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), testPayout).Return(errTest)
 			},
 			err: errTest,
 		},
@@ -305,46 +523,614 @@ func TestService_Confirm(t *testing.T) {
 
 			st := storagemock.NewMockStorage(ctrl)
 			sn := mailmock.NewMockSender(ctrl)
-			bc := blockchainmock.NewMockBlockchain(ctrl)
 
 			ctx := context.Background()
 
 			s := &service{
 				storage:       st,
+				hasher:        testHasher,
 				sender:        sn,
-				bc:            bc,
 				initialStakes: initialStakes,
+				codes:         testCodes,
 			}
 
-			tc.mockSetupFunc(st, sn, bc)
+			tc.mockSetupFunc(st, sn)
 
 			assert.ErrorIs(t, s.Confirm(ctx, testEmail, testCode), tc.err)
 		})
 	}
 }
 
-func TestService_RegisterTestnetAccount(t *testing.T) {
+func TestService_Resend(t *testing.T) {
+	request := func(sentAt time.Time, resends int) *storage.Request {
+		return &storage.Request{
+			Owner:       testOwner,
+			Email:       "enc:test:email",
+			Address:     testAddress,
+			Code:        testCode,
+			CreatedAt:   sentAt,
+			CodeSentAt:  sentAt,
+			ResendCount: resends,
+		}
+	}
+
+	tt := []struct {
+		name          string
+		mockSetupFunc func(s *storagemock.MockStorage, m *mailmock.MockSender)
+		next          time.Duration
+		err           error
+	}{
+		{
+			name: "success",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-2*time.Minute), 1), nil)
+				var code string
+				s.EXPECT().ReissueCode(gomock.Any(), testOwner, gomock.Any(), 1).DoAndReturn(
+					func(_ context.Context, _, c string, _ int) error {
+						code = c
+						return nil
+					},
+				)
+				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, "enc:test:email", gomock.Any(), "").Do(func(_ context.Context, _, _, c, _ string) {
+					assert.True(t, token.Compare(code, c))
+				})
+			},
+			next: time.Minute,
+		},
+		{
+			name: "last resend",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-2*time.Minute), 2), nil)
+				s.EXPECT().ReissueCode(gomock.Any(), testOwner, gomock.Any(), 2)
+				m.EXPECT().SendVerificationEmailAsync(gomock.Any(), testOwner, "enc:test:email", gomock.Any(), "")
+			},
+		},
+		{
+			name: "throttled",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-30*time.Second), 0), nil)
+			},
+			next: 30 * time.Second,
+			err:  ErrTooManyAttempts,
+		},
+		{
+			name: "resent concurrently",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-2*time.Minute), 0), nil)
+				s.EXPECT().ReissueCode(gomock.Any(), testOwner, gomock.Any(), 0).Return(storage.ErrNotFound)
+			},
+			next: time.Minute,
+			err:  ErrTooManyAttempts,
+		},
+		{
+			name: "limit",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-time.Hour), 3), nil)
+			},
+			err: ErrResendLimit,
+		},
+		{
+			name: "confirmed",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				r := request(time.Now().Add(-time.Hour), 0)
+				r.ConfirmedAt = sql.NullTime{Valid: true, Time: time.Now()}
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(r, nil)
+			},
+			err: ErrAlreadyConfirmed,
+		},
+		{
+			name: "not found",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
+			},
+			err: ErrRequestNotFound,
+		},
+		{
+			name: "reissue error",
+			mockSetupFunc: func(s *storagemock.MockStorage, m *mailmock.MockSender) {
+				s.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(request(time.Now().Add(-2*time.Minute), 0), nil)
+				s.EXPECT().ReissueCode(gomock.Any(), testOwner, gomock.Any(), 0).Return(errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			sn := mailmock.NewMockSender(ctrl)
+
+			s := &service{
+				storage: st,
+				hasher:  testHasher,
+				sender:  sn,
+				codes:   testCodes,
+			}
+
+			tc.mockSetupFunc(st, sn)
+
+			next, err := s.Resend(context.Background(), testEmail)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.next == 0 {
+				assert.True(t, next.IsZero())
+			} else {
+				assert.WithinDuration(t, time.Now().Add(tc.next), next, 5*time.Second)
+			}
+		})
+	}
+}
+
+func TestService_GetRegistrationStatus(t *testing.T) {
+	createdAt := time.Now().Add(-time.Hour)
+	confirmedAt := sql.NullTime{Valid: true, Time: createdAt.Add(time.Minute)}
+
+	request := func(confirmed bool, resends int) *storage.Request {
+		r := &storage.Request{
+			Owner:       testOwner,
+			Address:     testAddress,
+			CreatedAt:   createdAt,
+			CodeSentAt:  createdAt,
+			ResendCount: resends,
+		}
+		if confirmed {
+			r.ConfirmedAt = confirmedAt
+		}
+		return r
+	}
+
+	payout := func(status storage.PayoutStatus) *storage.Payout {
+		return &storage.Payout{
+			Status:    status,
+			TxHash:    sql.NullString{Valid: status != storage.PendingPayoutStatus, String: "hash"},
+			UpdatedAt: createdAt.Add(time.Hour),
+		}
+	}
+
 	tt := []struct {
 		name          string
-		mockSetupFunc func(bc *blockchainmock.MockBlockchain, storage *storagemock.MockStorage)
+		mockSetupFunc func(s *storagemock.MockStorage)
+		status        *RegistrationStatus
 		err           error
+	}{
+		{
+			name: "pending",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(false, 1), nil)
+			},
+			status: &RegistrationStatus{
+				State:        PendingRegistrationState,
+				Address:      testAddress,
+				CreatedAt:    createdAt,
+				NextResendAt: createdAt.Add(testCodes.ResendInterval),
+			},
+		},
+		{
+			name: "pending without resends",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(false, 3), nil)
+			},
+			status: &RegistrationStatus{
+				State:     PendingRegistrationState,
+				Address:   testAddress,
+				CreatedAt: createdAt,
+			},
+		},
+		{
+			name: "confirmed",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(true, 0), nil)
+				s.EXPECT().GetRegistrationPayout(gomock.Any(), testOwner).Return(nil, storage.ErrNotFound)
+			},
+			status: &RegistrationStatus{
+				State:       ConfirmedRegistrationState,
+				Address:     testAddress,
+				CreatedAt:   createdAt,
+				ConfirmedAt: confirmedAt,
+			},
+		},
+		{
+			name: "payout pending",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(true, 0), nil)
+				s.EXPECT().GetRegistrationPayout(gomock.Any(), testOwner).Return(payout(storage.ApprovalPayoutStatus), nil)
+			},
+			status: &RegistrationStatus{
+				State:       PayoutPendingRegistrationState,
+				Address:     testAddress,
+				CreatedAt:   createdAt,
+				ConfirmedAt: confirmedAt,
+				TxHash:      sql.NullString{Valid: true, String: "hash"},
+			},
+		},
+		{
+			name: "paid",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(true, 0), nil)
+				s.EXPECT().GetRegistrationPayout(gomock.Any(), testOwner).Return(payout(storage.CommittedPayoutStatus), nil)
+			},
+			status: &RegistrationStatus{
+				State:       PaidRegistrationState,
+				Address:     testAddress,
+				CreatedAt:   createdAt,
+				ConfirmedAt: confirmedAt,
+				PaidAt:      sql.NullTime{Valid: true, Time: createdAt.Add(time.Hour)},
+				TxHash:      sql.NullString{Valid: true, String: "hash"},
+			},
+		},
+		{
+			name: "failed",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(true, 0), nil)
+				s.EXPECT().GetRegistrationPayout(gomock.Any(), testOwner).Return(payout(storage.FailedPayoutStatus), nil)
+			},
+			status: &RegistrationStatus{
+				State:       FailedRegistrationState,
+				Address:     testAddress,
+				CreatedAt:   createdAt,
+				ConfirmedAt: confirmedAt,
+				TxHash:      sql.NullString{Valid: true, String: "hash"},
+			},
+		},
+		{
+			name: "not found",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(nil, storage.ErrNotFound)
+			},
+			err: ErrRequestNotFound,
+		},
+		{
+			name: "payout error",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().GetRequestByAddress(gomock.Any(), testAddress).Return(request(true, 0), nil)
+				s.EXPECT().GetRegistrationPayout(gomock.Any(), testOwner).Return(nil, errTest)
+			},
+			err: errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+
+			s := &service{
+				storage: st,
+				codes:   testCodes,
+			}
+
+			tc.mockSetupFunc(st)
+
+			status, err := s.GetRegistrationStatusByAddress(context.Background(), testAddress)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, status)
+		})
+	}
+}
+
+func TestService_GetRegistrationStatusByProof(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+
+	signer, err := proof.NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	require.NoError(t, err)
+
+	s := &service{
+		storage: st,
+		hasher:  testHasher,
+		proofs:  signer,
+		codes:   testCodes,
+	}
+
+	req := &storage.Request{
+		Owner:     testOwner,
+		Address:   testAddress,
+		CreatedAt: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+	}
+	st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(req, nil).Times(2)
+
+	p, err := s.IssueStatusProof(context.Background(), testEmail, testAddress)
+	require.NoError(t, err)
+
+	status, err := s.GetRegistrationStatusByProof(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, testAddress, status.Address)
+
+	_, err = s.GetRegistrationStatusByProof(context.Background(), "invalid")
+	assert.ErrorIs(t, err, ErrInvalidProof)
+
+	// proof isn't issued for another address
+	st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(req, nil)
+	_, err = s.IssueStatusProof(context.Background(), testEmail, "decentr1another")
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+
+	// proof is invalidated when the request is replaced
+	st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
+		Owner:     testOwner,
+		Address:   testAddress,
+		CreatedAt: req.CreatedAt.Add(time.Second),
+	}, nil)
+	_, err = s.GetRegistrationStatusByProof(context.Background(), p)
+	assert.ErrorIs(t, err, ErrInvalidProof)
+}
+
+func TestService_IssueStatusProof_PreviousOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+
+	n, err := normalizer.New(normalizer.DefaultConfig())
+	require.NoError(t, err)
+	previous, err := owner.NewHasher(n, []owner.Key{{ID: "old", Secret: []byte("abcdef0123456789abcdef0123456789")}}, false)
+	require.NoError(t, err)
+	hasher, err := owner.NewHasher(n, []owner.Key{
+		{ID: "test", Secret: []byte("0123456789abcdef0123456789abcdef")},
+		{ID: "old", Secret: []byte("abcdef0123456789abcdef0123456789")},
+	}, true)
+	require.NoError(t, err)
+
+	signer, err := proof.NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	require.NoError(t, err)
+
+	s := &service{
+		storage: st,
+		hasher:  hasher,
+		proofs:  signer,
+		codes:   testCodes,
+	}
+
+	// the request isn't rehashed yet, it's found by any owner of the email like on registration
+	previousOwner := previous.Hash(testEmail)
+	req := &storage.Request{Owner: previousOwner, Address: testAddress, CreatedAt: time.Now()}
+	st.EXPECT().GetRequestByOwner(gomock.Any(), hasher.Owners(testEmail)).Return(req, nil)
+	st.EXPECT().GetRequestByOwner(gomock.Any(), previousOwner).Return(req, nil)
+
+	p, err := s.IssueStatusProof(context.Background(), testEmail, testAddress)
+	require.NoError(t, err)
+
+	status, err := s.GetRegistrationStatusByProof(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, testAddress, status.Address)
+}
+
+func TestService_ConfirmLink(t *testing.T) {
+	links, err := proof.NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	require.NoError(t, err)
+
+	codes := testCodes
+	codes.LinkURL = "https://vulcan.decentr.xyz/v1/confirm/link"
+
+	newService := func(ctrl *gomock.Controller) (*service, *storagemock.MockStorage, *mailmock.MockSender) {
+		st := storagemock.NewMockStorage(ctrl)
+		sn := mailmock.NewMockSender(ctrl)
+
+		return &service{
+			storage:       st,
+			sender:        sn,
+			links:         links,
+			codes:         codes,
+			initialStakes: initialStakes,
+		}, st, sn
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s, st, sn := newService(ctrl)
+
+		link := s.confirmationLink(testOwner, testCode)
+		require.True(t, strings.HasPrefix(link, codes.LinkURL+"?token="))
+
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+
+		st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
+			Owner:      testOwner,
+			Email:      testEmail,
+			Address:    testAddress,
+			Code:       testCode,
+			CodeSentAt: time.Now(),
+		}, nil)
+		st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+			return f(st)
+		})
+		st.EXPECT().SetConfirmed(gomock.Any(), testOwner).Return(nil)
+		st.EXPECT().CreatePayout(gomock.Any(), testPayout).Return(nil)
+		sn.EXPECT().SendWelcomeEmailAsync(gomock.Any(), testOwner, testEmail)
+
+		require.NoError(t, s.ConfirmLink(context.Background(), u.Query().Get("token")))
+	})
+
+	t.Run("resent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s, st, _ := newService(ctrl)
+
+		st.EXPECT().GetRequestByOwner(gomock.Any(), testOwner).Return(&storage.Request{
+			Owner:      testOwner,
+			Code:       "reissued",
+			CodeSentAt: time.Now(),
+		}, nil)
+
+		// the link is replaced by a resend, the click isn't a failed attempt
+		assert.ErrorIs(t, s.ConfirmLink(context.Background(), links.Sign(testOwner+linkSeparator+testCode)), ErrLinkExpired)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s, _, _ := newService(ctrl)
+
+		assert.ErrorIs(t, s.ConfirmLink(context.Background(), "token"), ErrInvalidLink)
+		assert.ErrorIs(t, s.ConfirmLink(context.Background(), links.Sign(testOwner)), ErrInvalidLink)
+		assert.ErrorIs(t, (&service{}).ConfirmLink(context.Background(), links.Sign(testOwner+linkSeparator+testCode)), ErrInvalidLink)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Empty(t, (&service{codes: testCodes, links: links}).confirmationLink(testOwner, testCode))
+		assert.Empty(t, (&service{codes: codes}).confirmationLink(testOwner, testCode))
+	})
+}
+
+func TestService_IssueChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	st := storagemock.NewMockStorage(ctrl)
+
+	s := &service{
+		storage:      st,
+		challengeTTL: time.Minute,
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
+
+	var nonce string
+	st.EXPECT().CreateChallenge(gomock.Any(), testAddress, gomock.Len(challengeNonceLength), time.Minute, challengeLimit).DoAndReturn(
+		func(_ context.Context, _, n string, _ time.Duration, _ int) (time.Time, error) {
+			nonce = n
+			return expiresAt, nil
+		},
+	)
+
+	n, exp, err := s.IssueChallenge(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, nonce, n)
+	assert.Equal(t, expiresAt, exp)
+
+	st.EXPECT().CreateChallenge(gomock.Any(), testAddress, gomock.Any(), time.Minute, challengeLimit).Return(time.Time{}, errTest)
+
+	_, _, err = s.IssueChallenge(context.Background(), testAddress)
+	assert.ErrorIs(t, err, errTest)
+}
+
+func TestService_CheckChallenge(t *testing.T) {
+	tt := []struct {
+		name       string
+		storageErr error
+		err        error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:       "invalid",
+			storageErr: storage.ErrNotFound,
+			err:        ErrInvalidChallenge,
+		},
+		{
+			name:       "error",
+			storageErr: errTest,
+			err:        errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			st.EXPECT().CheckChallenge(gomock.Any(), testAddress, "nonce").Return(tc.storageErr)
+
+			s := &service{
+				storage: st,
+			}
+
+			err := s.CheckChallenge(context.Background(), testAddress, "nonce")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_ConsumeChallenge(t *testing.T) {
+	tt := []struct {
+		name       string
+		storageErr error
+		err        error
 	}{
 		{
 			name: "success",
-			mockSetupFunc: func(bc *blockchainmock.MockBlockchain, storage *storagemock.MockStorage) {
-				bc.EXPECT().SendStakes([]blockchain.Stake{
-					{Address: testAddress, Amount: giveStakesAmount},
-				}, "").Return(nil)
+		},
+		{
+			name:       "invalid",
+			storageErr: storage.ErrNotFound,
+			err:        ErrInvalidChallenge,
+		},
+		{
+			name:       "error",
+			storageErr: errTest,
+			err:        errTest,
+		},
+	}
+
+	for i := range tt {
+		tc := tt[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
 
-				storage.EXPECT().CreateTestnetConfirmedRequest(gomock.Any(), testAddress).Return(nil)
+			ctrl := gomock.NewController(t)
+
+			st := storagemock.NewMockStorage(ctrl)
+			st.EXPECT().ConsumeChallenge(gomock.Any(), testAddress, "nonce").Return(tc.storageErr)
+
+			s := &service{
+				storage: st,
+			}
+
+			err := s.ConsumeChallenge(context.Background(), testAddress, "nonce")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_RegisterTestnetAccount(t *testing.T) {
+	tt := []struct {
+		name          string
+		mockSetupFunc func(storage *storagemock.MockStorage)
+		err           error
+	}{
+		{
+			name: "success",
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().CreateTestnetConfirmedRequest(gomock.Any(), testAddress).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), &storage.Payout{
+					Address: testAddress,
+					Amount:  giveStakesAmount,
+					Purpose: storage.TestnetPayoutPurpose,
+				}).Return(nil)
 			},
 		},
 		{
 			name: "error",
-			mockSetupFunc: func(bc *blockchainmock.MockBlockchain, storage *storagemock.MockStorage) {
-				bc.EXPECT().SendStakes([]blockchain.Stake{
-					{Address: testAddress, Amount: giveStakesAmount},
-				}, "").Return(errTest)
+			mockSetupFunc: func(s *storagemock.MockStorage) {
+				s.EXPECT().CreateTestnetConfirmedRequest(gomock.Any(), testAddress).Return(nil)
+				s.EXPECT().CreatePayout(gomock.Any(), gomock.Any()).Return(errTest)
 			},
 			err: errTest,
 		},
@@ -357,19 +1143,18 @@ func TestService_RegisterTestnetAccount(t *testing.T) {
 
 			ctrl := gomock.NewController(t)
 
-			bc := blockchainmock.NewMockBlockchain(ctrl)
-			storage := storagemock.NewMockStorage(ctrl)
-
-			ctx := context.Background()
+			st := storagemock.NewMockStorage(ctrl)
+			st.EXPECT().InTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(storage.Storage) error) error {
+				return f(st)
+			})
 
 			s := &service{
-				bc:      bc,
-				storage: storage,
+				storage: st,
 			}
 
-			tc.mockSetupFunc(bc, storage)
+			tc.mockSetupFunc(st)
 
-			assert.ErrorIs(t, s.RegisterTestnetAccount(ctx, testAddress), tc.err)
+			assert.ErrorIs(t, s.RegisterTestnetAccount(context.Background(), testAddress), tc.err)
 		})
 	}
 }
@@ -398,19 +1183,14 @@ func Test_transformStatsAsGrowth(t *testing.T) {
 	require.Equal(t, storage.RegisterStats{Date: date, Value: total}, *stats[3])
 }
 
-func Test_getEmailHash(t *testing.T) {
-	assert.Equal(t, testOwner, getEmailHash(testEmail))
-	assert.Equal(t, getEmailHash("email@email.email"), getEmailHash("Email@email.email"))
-}
-
 func Test_randomCode(t *testing.T) {
-	c := randomCode()
+	c, err := randomCode(8, "0123456789")
+	require.NoError(t, err)
 
-	assert.Len(t, c, codeBytesSize*2)
-	assert.NotEqual(t, c, randomCode())
-}
+	assert.Len(t, c, 8)
+	assert.Regexp(t, "^[0-9]{8}$", c)
 
-func Test_truncatePlusPart(t *testing.T) {
-	assert.Equal(t, "email@email.com", truncatePlusPart("email+acc1@email.com"))
-	assert.Equal(t, "email@email.com", truncatePlusPart("email@email.com"))
+	c2, err := randomCode(8, "0123456789")
+	require.NoError(t, err)
+	assert.NotEqual(t, c, c2)
 }
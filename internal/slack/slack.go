@@ -0,0 +1,24 @@
+// Package slack contains the logrus hook which sends alerts to slack.
+package slack
+
+import (
+	"github.com/johntdyer/slackrus"
+	"github.com/sirupsen/logrus"
+)
+
+// NewHook returns logrus hook which sends entries with "sender" field set to "slack" to the slack channel.
+// Entries of info level and above are sent, so warnings and errors are alerted as well.
+func NewHook(url, channel string) logrus.Hook {
+	return &slackrus.SlackrusHook{
+		HookURL:        url,
+		AcceptedLevels: slackrus.LevelThreshold(logrus.InfoLevel),
+		Channel:        channel,
+		IconEmoji:      ":bread:",
+		Username:       "vulcan",
+		Filters: []slackrus.Filter{
+			func(entry *logrus.Entry) bool {
+				return entry.Data["sender"] == "slack"
+			},
+		},
+	}
+}
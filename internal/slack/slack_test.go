@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHook(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		msgs []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var msg struct {
+			Attachments []struct {
+				Fallback string `json:"fallback"`
+			} `json:"attachments"`
+		}
+		require.NoError(t, json.Unmarshal(b, &msg))
+		require.Len(t, msg.Attachments, 1)
+
+		mu.Lock()
+		msgs = append(msgs, msg.Attachments[0].Fallback)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.DebugLevel)
+	l.AddHook(NewHook(srv.URL, "alerts"))
+
+	alert := l.WithField("sender", "slack")
+	alert.Debug("debug")
+	alert.Info("info")
+	alert.Warn("warn")
+	alert.Error("error")
+	l.Error("not an alert")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"info", "warn", "error"}, msgs)
+}
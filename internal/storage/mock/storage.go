@@ -11,6 +11,7 @@ import (
 	types "github.com/cosmos/cosmos-sdk/types"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockStorage is a mock of Storage interface
@@ -81,18 +82,23 @@ func (mr *MockStorageMockRecorder) GetConfirmedRegistrationsStats(ctx interface{
 }
 
 // GetRequestByOwner mocks base method
-func (m *MockStorage) GetRequestByOwner(ctx context.Context, owner string) (*storage.Request, error) {
+func (m *MockStorage) GetRequestByOwner(ctx context.Context, owners ...string) (*storage.Request, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRequestByOwner", ctx, owner)
+	varargs := []interface{}{ctx}
+	for _, a := range owners {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetRequestByOwner", varargs...)
 	ret0, _ := ret[0].(*storage.Request)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRequestByOwner indicates an expected call of GetRequestByOwner
-func (mr *MockStorageMockRecorder) GetRequestByOwner(ctx, owner interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetRequestByOwner(ctx interface{}, owners ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestByOwner", reflect.TypeOf((*MockStorage)(nil).GetRequestByOwner), ctx, owner)
+	varargs := append([]interface{}{ctx}, owners...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestByOwner", reflect.TypeOf((*MockStorage)(nil).GetRequestByOwner), varargs...)
 }
 
 // GetRequestByOwnReferralCode mocks base method
@@ -125,6 +131,93 @@ func (mr *MockStorageMockRecorder) GetRequestByAddress(ctx, address interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestByAddress", reflect.TypeOf((*MockStorage)(nil).GetRequestByAddress), ctx, address)
 }
 
+// SearchRequests mocks base method
+func (m *MockStorage) SearchRequests(ctx context.Context, filter storage.RequestFilter, limit int) ([]*storage.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchRequests", ctx, filter, limit)
+	ret0, _ := ret[0].([]*storage.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchRequests indicates an expected call of SearchRequests
+func (mr *MockStorageMockRecorder) SearchRequests(ctx, filter, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchRequests", reflect.TypeOf((*MockStorage)(nil).SearchRequests), ctx, filter, limit)
+}
+
+// ListRequests mocks base method
+func (m *MockStorage) ListRequests(ctx context.Context, afterOwner string, limit int) ([]*storage.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRequests", ctx, afterOwner, limit)
+	ret0, _ := ret[0].([]*storage.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRequests indicates an expected call of ListRequests
+func (mr *MockStorageMockRecorder) ListRequests(ctx, afterOwner, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRequests", reflect.TypeOf((*MockStorage)(nil).ListRequests), ctx, afterOwner, limit)
+}
+
+// UpdateRequestOwners mocks base method
+func (m *MockStorage) UpdateRequestOwners(ctx context.Context, owners map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequestOwners", ctx, owners)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRequestOwners indicates an expected call of UpdateRequestOwners
+func (mr *MockStorageMockRecorder) UpdateRequestOwners(ctx, owners interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestOwners", reflect.TypeOf((*MockStorage)(nil).UpdateRequestOwners), ctx, owners)
+}
+
+// GetOwnerKeyIDs mocks base method
+func (m *MockStorage) GetOwnerKeyIDs(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnerKeyIDs", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnerKeyIDs indicates an expected call of GetOwnerKeyIDs
+func (mr *MockStorageMockRecorder) GetOwnerKeyIDs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnerKeyIDs", reflect.TypeOf((*MockStorage)(nil).GetOwnerKeyIDs), ctx)
+}
+
+// UpdateRequestEmails mocks base method
+func (m *MockStorage) UpdateRequestEmails(ctx context.Context, emails map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequestEmails", ctx, emails)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRequestEmails indicates an expected call of UpdateRequestEmails
+func (mr *MockStorageMockRecorder) UpdateRequestEmails(ctx, emails interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestEmails", reflect.TypeOf((*MockStorage)(nil).UpdateRequestEmails), ctx, emails)
+}
+
+// SetReferralBanned mocks base method
+func (m *MockStorage) SetReferralBanned(ctx context.Context, address string, banned bool, reason sql.NullString) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReferralBanned", ctx, address, banned, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReferralBanned indicates an expected call of SetReferralBanned
+func (mr *MockStorageMockRecorder) SetReferralBanned(ctx, address, banned, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReferralBanned", reflect.TypeOf((*MockStorage)(nil).SetReferralBanned), ctx, address, banned, reason)
+}
+
 // SetConfirmed mocks base method
 func (m *MockStorage) SetConfirmed(ctx context.Context, owner string) error {
 	m.ctrl.T.Helper()
@@ -167,6 +260,35 @@ func (mr *MockStorageMockRecorder) UpsertRequest(ctx, owner, email, address, cod
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRequest", reflect.TypeOf((*MockStorage)(nil).UpsertRequest), ctx, owner, email, address, code, referralCode)
 }
 
+// ReissueCode mocks base method
+func (m *MockStorage) ReissueCode(ctx context.Context, owner, code string, resendCount int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReissueCode", ctx, owner, code, resendCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReissueCode indicates an expected call of ReissueCode
+func (mr *MockStorageMockRecorder) ReissueCode(ctx, owner, code, resendCount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReissueCode", reflect.TypeOf((*MockStorage)(nil).ReissueCode), ctx, owner, code, resendCount)
+}
+
+// IncrementFailedAttempts mocks base method
+func (m *MockStorage) IncrementFailedAttempts(ctx context.Context, owner string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementFailedAttempts", ctx, owner)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementFailedAttempts indicates an expected call of IncrementFailedAttempts
+func (mr *MockStorageMockRecorder) IncrementFailedAttempts(ctx, owner interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementFailedAttempts", reflect.TypeOf((*MockStorage)(nil).IncrementFailedAttempts), ctx, owner)
+}
+
 // CreateReferralTracking mocks base method
 func (m *MockStorage) CreateReferralTracking(ctx context.Context, receiver, referralCode string) error {
 	m.ctrl.T.Helper()
@@ -269,19 +391,120 @@ func (mr *MockStorageMockRecorder) GetConfirmedReferralTrackingCount(ctx, sender
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfirmedReferralTrackingCount", reflect.TypeOf((*MockStorage)(nil).GetConfirmedReferralTrackingCount), ctx, sender)
 }
 
-// DoesEmailHaveFraudDomain mocks base method
-func (m *MockStorage) DoesEmailHaveFraudDomain(ctx context.Context, email string) (bool, error) {
+// GetFraudRules mocks base method
+func (m *MockStorage) GetFraudRules(ctx context.Context) ([]*storage.FraudRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFraudRules", ctx)
+	ret0, _ := ret[0].([]*storage.FraudRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFraudRules indicates an expected call of GetFraudRules
+func (mr *MockStorageMockRecorder) GetFraudRules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFraudRules", reflect.TypeOf((*MockStorage)(nil).GetFraudRules), ctx)
+}
+
+// GetFraudRule mocks base method
+func (m *MockStorage) GetFraudRule(ctx context.Context, id int64) (*storage.FraudRule, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoesEmailHaveFraudDomain", ctx, email)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetFraudRule", ctx, id)
+	ret0, _ := ret[0].(*storage.FraudRule)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DoesEmailHaveFraudDomain indicates an expected call of DoesEmailHaveFraudDomain
-func (mr *MockStorageMockRecorder) DoesEmailHaveFraudDomain(ctx, email interface{}) *gomock.Call {
+// GetFraudRule indicates an expected call of GetFraudRule
+func (mr *MockStorageMockRecorder) GetFraudRule(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoesEmailHaveFraudDomain", reflect.TypeOf((*MockStorage)(nil).DoesEmailHaveFraudDomain), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFraudRule", reflect.TypeOf((*MockStorage)(nil).GetFraudRule), ctx, id)
+}
+
+// CreateFraudRule mocks base method
+func (m *MockStorage) CreateFraudRule(ctx context.Context, r *storage.FraudRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFraudRule", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFraudRule indicates an expected call of CreateFraudRule
+func (mr *MockStorageMockRecorder) CreateFraudRule(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFraudRule", reflect.TypeOf((*MockStorage)(nil).CreateFraudRule), ctx, r)
+}
+
+// CreateFraudRules mocks base method
+func (m *MockStorage) CreateFraudRules(ctx context.Context, rules []*storage.FraudRule) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFraudRules", ctx, rules)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFraudRules indicates an expected call of CreateFraudRules
+func (mr *MockStorageMockRecorder) CreateFraudRules(ctx, rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFraudRules", reflect.TypeOf((*MockStorage)(nil).CreateFraudRules), ctx, rules)
+}
+
+// DeleteFraudRule mocks base method
+func (m *MockStorage) DeleteFraudRule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFraudRule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFraudRule indicates an expected call of DeleteFraudRule
+func (mr *MockStorageMockRecorder) DeleteFraudRule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFraudRule", reflect.TypeOf((*MockStorage)(nil).DeleteFraudRule), ctx, id)
+}
+
+// CreateFraudRejection mocks base method
+func (m *MockStorage) CreateFraudRejection(ctx context.Context, r *storage.FraudRejection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFraudRejection", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFraudRejection indicates an expected call of CreateFraudRejection
+func (mr *MockStorageMockRecorder) CreateFraudRejection(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFraudRejection", reflect.TypeOf((*MockStorage)(nil).CreateFraudRejection), ctx, r)
+}
+
+// CreateAuditRecord mocks base method
+func (m *MockStorage) CreateAuditRecord(ctx context.Context, r *storage.AuditRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditRecord", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditRecord indicates an expected call of CreateAuditRecord
+func (mr *MockStorageMockRecorder) CreateAuditRecord(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditRecord", reflect.TypeOf((*MockStorage)(nil).CreateAuditRecord), ctx, r)
+}
+
+// GetAuditRecords mocks base method
+func (m *MockStorage) GetAuditRecords(ctx context.Context, target string, limit int) ([]*storage.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditRecords", ctx, target, limit)
+	ret0, _ := ret[0].([]*storage.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditRecords indicates an expected call of GetAuditRecords
+func (mr *MockStorageMockRecorder) GetAuditRecords(ctx, target, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditRecords", reflect.TypeOf((*MockStorage)(nil).GetAuditRecords), ctx, target, limit)
 }
 
 // CreateDLoan mocks base method
@@ -312,3 +535,378 @@ func (mr *MockStorageMockRecorder) GetDLoans(ctx, take, skip interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDLoans", reflect.TypeOf((*MockStorage)(nil).GetDLoans), ctx, take, skip)
 }
+
+// CreatePayout mocks base method
+func (m *MockStorage) CreatePayout(ctx context.Context, p *storage.Payout) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayout", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayout indicates an expected call of CreatePayout
+func (mr *MockStorageMockRecorder) CreatePayout(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayout", reflect.TypeOf((*MockStorage)(nil).CreatePayout), ctx, p)
+}
+
+// GetRegistrationPayout mocks base method
+func (m *MockStorage) GetRegistrationPayout(ctx context.Context, owner string) (*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationPayout", ctx, owner)
+	ret0, _ := ret[0].(*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegistrationPayout indicates an expected call of GetRegistrationPayout
+func (mr *MockStorageMockRecorder) GetRegistrationPayout(ctx, owner interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationPayout", reflect.TypeOf((*MockStorage)(nil).GetRegistrationPayout), ctx, owner)
+}
+
+// GetPendingPayouts mocks base method
+func (m *MockStorage) GetPendingPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingPayouts", ctx, limit)
+	ret0, _ := ret[0].([]*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingPayouts indicates an expected call of GetPendingPayouts
+func (mr *MockStorageMockRecorder) GetPendingPayouts(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingPayouts", reflect.TypeOf((*MockStorage)(nil).GetPendingPayouts), ctx, limit)
+}
+
+// SetPayoutsBatch mocks base method
+func (m *MockStorage) SetPayoutsBatch(ctx context.Context, ids []int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayoutsBatch", ctx, ids, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPayoutsBatch indicates an expected call of SetPayoutsBatch
+func (mr *MockStorageMockRecorder) SetPayoutsBatch(ctx, ids, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayoutsBatch", reflect.TypeOf((*MockStorage)(nil).SetPayoutsBatch), ctx, ids, key)
+}
+
+// GetBatchPayouts mocks base method
+func (m *MockStorage) GetBatchPayouts(ctx context.Context) ([]*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBatchPayouts", ctx)
+	ret0, _ := ret[0].([]*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBatchPayouts indicates an expected call of GetBatchPayouts
+func (mr *MockStorageMockRecorder) GetBatchPayouts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBatchPayouts", reflect.TypeOf((*MockStorage)(nil).GetBatchPayouts), ctx)
+}
+
+// GetBroadcastPayouts mocks base method
+func (m *MockStorage) GetBroadcastPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBroadcastPayouts", ctx, limit)
+	ret0, _ := ret[0].([]*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBroadcastPayouts indicates an expected call of GetBroadcastPayouts
+func (mr *MockStorageMockRecorder) GetBroadcastPayouts(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBroadcastPayouts", reflect.TypeOf((*MockStorage)(nil).GetBroadcastPayouts), ctx, limit)
+}
+
+// SetPayoutBroadcast mocks base method
+func (m *MockStorage) SetPayoutBroadcast(ctx context.Context, id int64, txHash string, fee types.Coins, gas uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayoutBroadcast", ctx, id, txHash, fee, gas)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPayoutBroadcast indicates an expected call of SetPayoutBroadcast
+func (mr *MockStorageMockRecorder) SetPayoutBroadcast(ctx, id, txHash, fee, gas interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayoutBroadcast", reflect.TypeOf((*MockStorage)(nil).SetPayoutBroadcast), ctx, id, txHash, fee, gas)
+}
+
+// GetTimedOutPayouts mocks base method
+func (m *MockStorage) GetTimedOutPayouts(ctx context.Context, since time.Time, limit int) ([]*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTimedOutPayouts", ctx, since, limit)
+	ret0, _ := ret[0].([]*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimedOutPayouts indicates an expected call of GetTimedOutPayouts
+func (mr *MockStorageMockRecorder) GetTimedOutPayouts(ctx, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTimedOutPayouts", reflect.TypeOf((*MockStorage)(nil).GetTimedOutPayouts), ctx, since, limit)
+}
+
+// SetPayoutResult mocks base method
+func (m *MockStorage) SetPayoutResult(ctx context.Context, id int64, status storage.PayoutStatus, height int64, code uint32, rawLog string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayoutResult", ctx, id, status, height, code, rawLog)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPayoutResult indicates an expected call of SetPayoutResult
+func (mr *MockStorageMockRecorder) SetPayoutResult(ctx, id, status, height, code, rawLog interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayoutResult", reflect.TypeOf((*MockStorage)(nil).SetPayoutResult), ctx, id, status, height, code, rawLog)
+}
+
+// SetPayoutFailed mocks base method
+func (m *MockStorage) SetPayoutFailed(ctx context.Context, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayoutFailed", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPayoutFailed indicates an expected call of SetPayoutFailed
+func (mr *MockStorageMockRecorder) SetPayoutFailed(ctx, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayoutFailed", reflect.TypeOf((*MockStorage)(nil).SetPayoutFailed), ctx, id, reason)
+}
+
+// SetPayoutApproval mocks base method
+func (m *MockStorage) SetPayoutApproval(ctx context.Context, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayoutApproval", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPayoutApproval indicates an expected call of SetPayoutApproval
+func (mr *MockStorageMockRecorder) SetPayoutApproval(ctx, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayoutApproval", reflect.TypeOf((*MockStorage)(nil).SetPayoutApproval), ctx, id, reason)
+}
+
+// ApprovePayout mocks base method
+func (m *MockStorage) ApprovePayout(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApprovePayout", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApprovePayout indicates an expected call of ApprovePayout
+func (mr *MockStorageMockRecorder) ApprovePayout(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApprovePayout", reflect.TypeOf((*MockStorage)(nil).ApprovePayout), ctx, id)
+}
+
+// GetApprovalPayouts mocks base method
+func (m *MockStorage) GetApprovalPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApprovalPayouts", ctx, limit)
+	ret0, _ := ret[0].([]*storage.Payout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApprovalPayouts indicates an expected call of GetApprovalPayouts
+func (mr *MockStorageMockRecorder) GetApprovalPayouts(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApprovalPayouts", reflect.TypeOf((*MockStorage)(nil).GetApprovalPayouts), ctx, limit)
+}
+
+// LockSpending mocks base method
+func (m *MockStorage) LockSpending(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockSpending", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockSpending indicates an expected call of LockSpending
+func (mr *MockStorageMockRecorder) LockSpending(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockSpending", reflect.TypeOf((*MockStorage)(nil).LockSpending), ctx)
+}
+
+// GetSpending mocks base method
+func (m *MockStorage) GetSpending(ctx context.Context, purpose storage.PayoutPurpose) (*storage.Spending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpending", ctx, purpose)
+	ret0, _ := ret[0].(*storage.Spending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpending indicates an expected call of GetSpending
+func (mr *MockStorageMockRecorder) GetSpending(ctx, purpose interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpending", reflect.TypeOf((*MockStorage)(nil).GetSpending), ctx, purpose)
+}
+
+// HitRateLimit mocks base method
+func (m *MockStorage) HitRateLimit(ctx context.Context, key string, window time.Duration) (*storage.RateLimitHits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HitRateLimit", ctx, key, window)
+	ret0, _ := ret[0].(*storage.RateLimitHits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HitRateLimit indicates an expected call of HitRateLimit
+func (mr *MockStorageMockRecorder) HitRateLimit(ctx, key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HitRateLimit", reflect.TypeOf((*MockStorage)(nil).HitRateLimit), ctx, key, window)
+}
+
+// DeleteExpiredRateLimits mocks base method
+func (m *MockStorage) DeleteExpiredRateLimits(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRateLimits", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredRateLimits indicates an expected call of DeleteExpiredRateLimits
+func (mr *MockStorageMockRecorder) DeleteExpiredRateLimits(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRateLimits", reflect.TypeOf((*MockStorage)(nil).DeleteExpiredRateLimits), ctx)
+}
+
+// CreateEmail mocks base method
+func (m *MockStorage) CreateEmail(ctx context.Context, e *storage.Email) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmail", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmail indicates an expected call of CreateEmail
+func (mr *MockStorageMockRecorder) CreateEmail(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmail", reflect.TypeOf((*MockStorage)(nil).CreateEmail), ctx, e)
+}
+
+// ClaimQueuedEmails mocks base method
+func (m *MockStorage) ClaimQueuedEmails(ctx context.Context, limit int, lease time.Duration) ([]*storage.Email, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimQueuedEmails", ctx, limit, lease)
+	ret0, _ := ret[0].([]*storage.Email)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimQueuedEmails indicates an expected call of ClaimQueuedEmails
+func (mr *MockStorageMockRecorder) ClaimQueuedEmails(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimQueuedEmails", reflect.TypeOf((*MockStorage)(nil).ClaimQueuedEmails), ctx, limit, lease)
+}
+
+// GetEmails mocks base method
+func (m *MockStorage) GetEmails(ctx context.Context, owner string, limit int) ([]*storage.Email, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmails", ctx, owner, limit)
+	ret0, _ := ret[0].([]*storage.Email)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmails indicates an expected call of GetEmails
+func (mr *MockStorageMockRecorder) GetEmails(ctx, owner, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmails", reflect.TypeOf((*MockStorage)(nil).GetEmails), ctx, owner, limit)
+}
+
+// SetEmailSent mocks base method
+func (m *MockStorage) SetEmailSent(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailSent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmailSent indicates an expected call of SetEmailSent
+func (mr *MockStorageMockRecorder) SetEmailSent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailSent", reflect.TypeOf((*MockStorage)(nil).SetEmailSent), ctx, id)
+}
+
+// RetryEmail mocks base method
+func (m *MockStorage) RetryEmail(ctx context.Context, id int64, delay time.Duration, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryEmail", ctx, id, delay, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryEmail indicates an expected call of RetryEmail
+func (mr *MockStorageMockRecorder) RetryEmail(ctx, id, delay, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryEmail", reflect.TypeOf((*MockStorage)(nil).RetryEmail), ctx, id, delay, reason)
+}
+
+// SetEmailFailed mocks base method
+func (m *MockStorage) SetEmailFailed(ctx context.Context, id int64, status storage.EmailStatus, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailFailed", ctx, id, status, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmailFailed indicates an expected call of SetEmailFailed
+func (mr *MockStorageMockRecorder) SetEmailFailed(ctx, id, status, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailFailed", reflect.TypeOf((*MockStorage)(nil).SetEmailFailed), ctx, id, status, reason)
+}
+
+// CreateChallenge mocks base method
+func (m *MockStorage) CreateChallenge(ctx context.Context, address, nonce string, ttl time.Duration, limit int) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChallenge", ctx, address, nonce, ttl, limit)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateChallenge indicates an expected call of CreateChallenge
+func (mr *MockStorageMockRecorder) CreateChallenge(ctx, address, nonce, ttl, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChallenge", reflect.TypeOf((*MockStorage)(nil).CreateChallenge), ctx, address, nonce, ttl, limit)
+}
+
+// CheckChallenge mocks base method
+func (m *MockStorage) CheckChallenge(ctx context.Context, address, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckChallenge", ctx, address, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckChallenge indicates an expected call of CheckChallenge
+func (mr *MockStorageMockRecorder) CheckChallenge(ctx, address, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckChallenge", reflect.TypeOf((*MockStorage)(nil).CheckChallenge), ctx, address, nonce)
+}
+
+// ConsumeChallenge mocks base method
+func (m *MockStorage) ConsumeChallenge(ctx context.Context, address, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeChallenge", ctx, address, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeChallenge indicates an expected call of ConsumeChallenge
+func (mr *MockStorageMockRecorder) ConsumeChallenge(ctx, address, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeChallenge", reflect.TypeOf((*MockStorage)(nil).ConsumeChallenge), ctx, address, nonce)
+}
@@ -7,6 +7,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/google/uuid"
@@ -81,9 +82,14 @@ func (p pg) InTx(ctx context.Context, f func(s storage.Storage) error) error {
 	return nil
 }
 
-func (p pg) GetRequestByOwner(ctx context.Context, owner string) (*storage.Request, error) {
+func (p pg) GetRequestByOwner(ctx context.Context, owners ...string) (*storage.Request, error) {
 	var r storage.Request
-	if err := sqlx.GetContext(ctx, p.ext, &r, `SELECT * FROM request WHERE owner=$1`, owner); err != nil {
+	if err := sqlx.GetContext(ctx, p.ext, &r, `
+			SELECT * FROM request
+			WHERE owner = ANY($1)
+			ORDER BY array_position($1, owner)
+			LIMIT 1
+	`, pq.Array(owners)); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrNotFound
 		}
@@ -117,9 +123,96 @@ func (p pg) GetRequestByAddress(ctx context.Context, address string) (*storage.R
 	return &r, nil
 }
 
+func (p pg) SearchRequests(ctx context.Context, filter storage.RequestFilter, limit int) ([]*storage.Request, error) {
+	var rr []*storage.Request
+	if err := sqlx.SelectContext(ctx, p.ext, &rr, `
+			SELECT * FROM request
+			WHERE ($1 = '' OR address = $1)
+				AND ($2::VARCHAR[] IS NULL OR owner = ANY($2))
+				AND ($3 = '' OR own_referral_code = $3 OR registration_referral_code = $3)
+			ORDER BY created_at DESC
+			LIMIT $4
+	`, filter.Address, pq.Array(filter.Owners), filter.ReferralCode, limit); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return rr, nil
+}
+
+func (p pg) ListRequests(ctx context.Context, afterOwner string, limit int) ([]*storage.Request, error) {
+	var rr []*storage.Request
+	if err := sqlx.SelectContext(ctx, p.ext, &rr, `
+			SELECT * FROM request
+			WHERE owner > $1
+			ORDER BY owner
+			LIMIT $2
+	`, afterOwner, limit); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return rr, nil
+}
+
+func (p pg) UpdateRequestOwners(ctx context.Context, owners map[string]string) error {
+	if len(owners) == 0 {
+		return nil
+	}
+
+	old := make([]string, 0, len(owners))
+	updated := make([]string, 0, len(owners))
+	for k, v := range owners {
+		old, updated = append(old, k), append(updated, v)
+	}
+
+	// temporary owners can't match real ones, so the second step never conflicts with not updated requests
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE request SET owner = 'rehash:' || o.updated
+			FROM UNNEST($1::VARCHAR[], $2::VARCHAR[]) AS o(old, updated)
+			WHERE request.owner = o.old
+	`, pq.Array(old), pq.Array(updated)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE request SET owner = SUBSTRING(owner FROM 8)
+			WHERE owner LIKE 'rehash:%'
+	`); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetOwnerKeyIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	if err := sqlx.SelectContext(ctx, p.ext, &ids, `
+			SELECT DISTINCT CASE WHEN STRPOS(owner, ':') = 0 THEN '' ELSE SPLIT_PART(owner, ':', 1) END
+			FROM request
+	`); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (p pg) SetReferralBanned(ctx context.Context, address string, banned bool, reason sql.NullString) error {
+	res, err := p.ext.ExecContext(ctx, `
+		UPDATE request SET referral_banned=$2, referral_ban_reason=$3 WHERE address=$1
+	`, address, banned, reason)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
 func (p pg) SetConfirmed(ctx context.Context, owner string) error {
 	res, err := p.ext.ExecContext(ctx, `
-		UPDATE request SET confirmed_at=CURRENT_TIMESTAMP WHERE owner=$1
+		UPDATE request SET confirmed_at=CURRENT_TIMESTAMP WHERE owner=$1 AND confirmed_at IS NULL
 	`, owner)
 
 	if err != nil {
@@ -133,15 +226,41 @@ func (p pg) SetConfirmed(ctx context.Context, owner string) error {
 	return nil
 }
 
+func (p pg) UpdateRequestEmails(ctx context.Context, emails map[string]string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	owners := make([]string, 0, len(emails))
+	updated := make([]string, 0, len(emails))
+	for k, v := range emails {
+		owners, updated = append(owners, k), append(updated, v)
+	}
+
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE request SET email = e.email
+			FROM UNNEST($1::VARCHAR[], $2::VARCHAR[]) AS e(owner, email)
+			WHERE request.owner = e.owner
+	`, pq.Array(owners), pq.Array(updated)); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
 func (p pg) UpsertRequest(ctx context.Context, owner, email, address, code string, referralCode sql.NullString) error {
 	if _, err := p.ext.ExecContext(ctx, `
-			INSERT INTO request (owner, email, address, code, created_at, registration_referral_code)
-			VALUES($1, $2, $3, $4, CURRENT_TIMESTAMP, $5) ON CONFLICT(email) DO
+			INSERT INTO request (owner, email, address, code, created_at, registration_referral_code, code_sent_at)
+			VALUES($1, $2, $3, $4, CURRENT_TIMESTAMP, $5, CURRENT_TIMESTAMP) ON CONFLICT(owner) DO
 			UPDATE SET 
+			           email=EXCLUDED.email,
 			           address=EXCLUDED.address, 
 			           code=EXCLUDED.code, 
 			           created_at=EXCLUDED.created_at,
-			           registration_referral_code=EXCLUDED.registration_referral_code
+			           registration_referral_code=EXCLUDED.registration_referral_code,
+			           failed_attempts=0,
+			           code_sent_at=EXCLUDED.code_sent_at,
+			           resend_count=0
 	`, owner, email, address, code, referralCode); err != nil {
 		if isUniqueViolationErr(err, "request_address_key") ||
 			isUniqueViolationErr(err, "request_owner_key") {
@@ -153,6 +272,38 @@ func (p pg) UpsertRequest(ctx context.Context, owner, email, address, code strin
 	return nil
 }
 
+func (p pg) ReissueCode(ctx context.Context, owner, code string, resendCount int) error {
+	res, err := p.ext.ExecContext(ctx, `
+		UPDATE request
+		SET code=$2, code_sent_at=CURRENT_TIMESTAMP, failed_attempts=0, resend_count=resend_count+1
+		WHERE owner=$1 AND confirmed_at IS NULL AND resend_count=$3
+	`, owner, code, resendCount)
+
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) IncrementFailedAttempts(ctx context.Context, owner string) (int, error) {
+	var attempts int
+	if err := sqlx.GetContext(ctx, p.ext, &attempts, `
+		UPDATE request SET failed_attempts=failed_attempts+1 WHERE owner=$1 RETURNING failed_attempts
+	`, owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return attempts, nil
+}
+
 func (p pg) CreateTestnetConfirmedRequest(ctx context.Context, address string) error {
 	uniqueValue := "[testnet]" + uuid.New().String()
 
@@ -302,12 +453,621 @@ func (p pg) GetConfirmedRegistrationsTotal(ctx context.Context) (int, error) {
 	return total, err
 }
 
-func (p pg) DoesEmailHaveFraudDomain(ctx context.Context, email string) (bool, error) {
-	var check bool
-	err := sqlx.GetContext(ctx, p.ext, &check,
-		`SELECT EXISTS(SELECT * FROM email_fraud_domains WHERE $1 LIKE '%'||domain)`, email)
+func (p pg) GetFraudRules(ctx context.Context) ([]*storage.FraudRule, error) {
+	var rules []*storage.FraudRule
+	if err := sqlx.SelectContext(ctx, p.ext, &rules, `
+			SELECT id, kind, pattern, source, created_at
+			FROM fraud_rule
+			ORDER BY id
+	`); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (p pg) GetFraudRule(ctx context.Context, id int64) (*storage.FraudRule, error) {
+	var r storage.FraudRule
+	if err := sqlx.GetContext(ctx, p.ext, &r, `
+			SELECT id, kind, pattern, source, created_at
+			FROM fraud_rule
+			WHERE id = $1
+	`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return &r, nil
+}
+
+func (p pg) CreateFraudRule(ctx context.Context, r *storage.FraudRule) error {
+	if err := sqlx.GetContext(ctx, p.ext, r, `
+			INSERT INTO fraud_rule (kind, pattern, source, created_at)
+			VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+			ON CONFLICT (kind, pattern) DO NOTHING
+			RETURNING id, kind, pattern, source, created_at
+	`, r.Kind, r.Pattern, r.Source); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrFraudRuleExists
+		}
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) CreateFraudRules(ctx context.Context, rules []*storage.FraudRule) (int, error) {
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	kinds := make([]string, len(rules))
+	patterns := make([]string, len(rules))
+	sources := make([]string, len(rules))
+	for i, v := range rules {
+		kinds[i], patterns[i], sources[i] = string(v.Kind), v.Pattern, v.Source
+	}
+
+	res, err := p.ext.ExecContext(ctx, `
+			INSERT INTO fraud_rule (kind, pattern, source, created_at)
+			SELECT kind::FRAUD_RULE_KIND, pattern, source, CURRENT_TIMESTAMP
+			FROM UNNEST($1::TEXT[], $2::TEXT[], $3::TEXT[]) AS r(kind, pattern, source)
+			ON CONFLICT (kind, pattern) DO NOTHING
+	`, pq.Array(kinds), pq.Array(patterns), pq.Array(sources))
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	c, _ := res.RowsAffected()
+
+	return int(c), nil
+}
+
+func (p pg) DeleteFraudRule(ctx context.Context, id int64) error {
+	res, err := p.ext.ExecContext(ctx, `DELETE FROM fraud_rule WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) CreateFraudRejection(ctx context.Context, r *storage.FraudRejection) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO fraud_rejection (rule_id, reason, domain, address, created_at)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+	`, r.RuleID, r.Reason, r.Domain, r.Address); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) CreateAuditRecord(ctx context.Context, r *storage.AuditRecord) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO admin_audit (actor, action, target, reason, created_at)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+	`, r.Actor, r.Action, r.Target, r.Reason); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetAuditRecords(ctx context.Context, target string, limit int) ([]*storage.AuditRecord, error) {
+	var rr []*storage.AuditRecord
+	if err := sqlx.SelectContext(ctx, p.ext, &rr, `
+			SELECT * FROM admin_audit
+			WHERE $1 = '' OR target = $1
+			ORDER BY id DESC
+			LIMIT $2
+	`, target, limit); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return rr, nil
+}
+
+func (p pg) CreatePayout(ctx context.Context, payout *storage.Payout) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			INSERT INTO payout (address, amount, memo, purpose, owner, referral_receiver, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, payout.Address, intDTO(payout.Amount), payout.Memo, payout.Purpose, payout.Owner, payout.ReferralReceiver); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+type payoutDTO struct {
+	ID               int64                 `db:"id"`
+	Address          string                `db:"address"`
+	Amount           intDTO                `db:"amount"`
+	Memo             string                `db:"memo"`
+	Status           storage.PayoutStatus  `db:"status"`
+	Purpose          storage.PayoutPurpose `db:"purpose"`
+	Owner            sql.NullString        `db:"owner"`
+	ReferralReceiver sql.NullString        `db:"referral_receiver"`
+	TxHash           sql.NullString        `db:"tx_hash"`
+	TxFee            sql.NullString        `db:"tx_fee"`
+	TxGas            sql.NullInt64         `db:"tx_gas"`
+	TxHeight         sql.NullInt64         `db:"tx_height"`
+	TxCode           sql.NullInt32         `db:"tx_code"`
+	TxRawLog         sql.NullString        `db:"tx_raw_log"`
+	Error            sql.NullString        `db:"error"`
+	CreatedAt        time.Time             `db:"created_at"`
+	UpdatedAt        time.Time             `db:"updated_at"`
+	BroadcastAt      sql.NullTime          `db:"broadcast_at"`
+	ApprovedAt       sql.NullTime          `db:"approved_at"`
+	BatchKey         sql.NullString        `db:"batch_key"`
+	BatchedAt        sql.NullTime          `db:"batched_at"`
+}
+
+func (d payoutDTO) toStorage() *storage.Payout {
+	return &storage.Payout{
+		ID:               d.ID,
+		Address:          d.Address,
+		Amount:           sdk.Int(d.Amount),
+		Memo:             d.Memo,
+		Status:           d.Status,
+		Purpose:          d.Purpose,
+		Owner:            d.Owner,
+		ReferralReceiver: d.ReferralReceiver,
+		TxHash:           d.TxHash,
+		TxFee:            d.TxFee,
+		TxGas:            d.TxGas,
+		TxHeight:         d.TxHeight,
+		TxCode:           d.TxCode,
+		TxRawLog:         d.TxRawLog,
+		Error:            d.Error,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+		BroadcastAt:      d.BroadcastAt,
+		ApprovedAt:       d.ApprovedAt,
+		BatchKey:         d.BatchKey,
+		BatchedAt:        d.BatchedAt,
+	}
+}
+
+func (p pg) selectPayouts(ctx context.Context, query string, args ...interface{}) ([]*storage.Payout, error) {
+	var dto []payoutDTO
+	if err := sqlx.SelectContext(ctx, p.ext, &dto, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	payouts := make([]*storage.Payout, len(dto))
+	for i, v := range dto {
+		payouts[i] = v.toStorage()
+	}
+
+	return payouts, nil
+}
+
+func (p pg) GetRegistrationPayout(ctx context.Context, owner string) (*storage.Payout, error) {
+	payouts, err := p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE owner = $1 AND purpose = 'registration'
+			ORDER BY id DESC
+			LIMIT 1
+	`, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payouts) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	return payouts[0], nil
+}
+
+func (p pg) GetPendingPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	return p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE status = 'pending' AND batch_key IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+	`, limit)
+}
+
+func (p pg) SetPayoutsBatch(ctx context.Context, ids []int64, key string) error {
+	if _, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET batch_key = $2,
+				batched_at = CURRENT_TIMESTAMP,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = ANY($1) AND status = 'pending'
+	`, pq.Array(ids), key); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) GetBatchPayouts(ctx context.Context) ([]*storage.Payout, error) {
+	// the whole batch is locked once one of its payouts is, so the rest of the batch isn't skipped
+	return p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE status = 'pending' AND batch_key = (
+				SELECT batch_key FROM payout
+				WHERE status = 'pending' AND batch_key IS NOT NULL
+				ORDER BY batched_at, id
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			ORDER BY id
+			FOR UPDATE
+	`)
+}
+
+func (p pg) GetBroadcastPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	return p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE status = 'broadcast'
+			ORDER BY broadcast_at
+			LIMIT $1
+	`, limit)
+}
+
+func (p pg) GetTimedOutPayouts(ctx context.Context, since time.Time, limit int) ([]*storage.Payout, error) {
+	return p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE status = 'failed' AND tx_hash IS NOT NULL AND tx_height IS NULL AND updated_at > $1
+			ORDER BY updated_at
+			LIMIT $2
+	`, since, limit)
+}
+
+func (p pg) SetPayoutBroadcast(ctx context.Context, id int64, txHash string, fee sdk.Coins, gas uint64) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET status = 'broadcast',
+				tx_hash = $2,
+				tx_fee = $3,
+				tx_gas = $4,
+				updated_at = CURRENT_TIMESTAMP,
+				broadcast_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+	`, id, txHash, fee.String(), int64(gas))
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) SetPayoutResult(ctx context.Context, id int64, status storage.PayoutStatus,
+	height int64, code uint32, rawLog string) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET status = $2,
+				tx_height = $3,
+				tx_code = $4,
+				tx_raw_log = $5,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND (status = 'broadcast' OR (status = 'failed' AND tx_hash IS NOT NULL AND tx_height IS NULL))
+	`, id, status, height, code, rawLog)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) SetPayoutFailed(ctx context.Context, id int64, reason string) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET status = 'failed',
+				error = $2,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) SetPayoutApproval(ctx context.Context, id int64, reason string) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET status = 'approval',
+				error = $2,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+	`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) ApprovePayout(ctx context.Context, id int64) error {
+	res, err := p.ext.ExecContext(ctx, `
+			UPDATE payout
+			SET status = 'pending',
+				error = NULL,
+				approved_at = CURRENT_TIMESTAMP,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'approval'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) GetApprovalPayouts(ctx context.Context, limit int) ([]*storage.Payout, error) {
+	return p.selectPayouts(ctx, `
+			SELECT * FROM payout
+			WHERE status = 'approval'
+			ORDER BY id
+			LIMIT $1
+	`, limit)
+}
+
+func (p pg) LockSpending(ctx context.Context) error {
+	if _, err := p.ext.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('payout_spending'))`); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
 
-	return check, err
+func (p pg) GetSpending(ctx context.Context, purpose storage.PayoutPurpose) (*storage.Spending, error) {
+	var dto struct {
+		Day   intDTO `db:"day"`
+		Month intDTO `db:"month"`
+	}
+
+	if err := sqlx.GetContext(ctx, p.ext, &dto, `
+			SELECT
+				COALESCE(SUM(amount) FILTER (WHERE COALESCE(broadcast_at, batched_at) >= DATE_TRUNC('day', CURRENT_TIMESTAMP)), 0)::BIGINT AS day,
+				COALESCE(SUM(amount), 0)::BIGINT AS month
+			FROM payout
+			WHERE purpose = $1
+				AND (status IN ('broadcast', 'committed') OR (status = 'pending' AND batch_key IS NOT NULL))
+				AND COALESCE(broadcast_at, batched_at) >= DATE_TRUNC('month', CURRENT_TIMESTAMP)
+	`, purpose); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return &storage.Spending{
+		Day:   sdk.Int(dto.Day),
+		Month: sdk.Int(dto.Month),
+	}, nil
+}
+
+func (p pg) HitRateLimit(ctx context.Context, key string, window time.Duration) (*storage.RateLimitHits, error) {
+	var dto struct {
+		Current  int     `db:"current"`
+		Previous int     `db:"previous"`
+		Elapsed  float64 `db:"elapsed"`
+	}
+
+	seconds := int64(window / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("window %s is less than second", window)
+	}
+
+	if err := sqlx.GetContext(ctx, p.ext, &dto, `
+			WITH cur AS (
+				INSERT INTO rate_limit (key, window_index, hits, expires_at)
+				VALUES (
+					$1,
+					FLOOR(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) / $2::BIGINT),
+					1,
+					CURRENT_TIMESTAMP + 2 * $2 * INTERVAL '1 second'
+				)
+				ON CONFLICT (key, window_index) DO UPDATE SET hits = rate_limit.hits + 1
+				RETURNING hits, window_index
+			)
+			SELECT
+				cur.hits AS current,
+				COALESCE(prev.hits, 0) AS previous,
+				EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) - cur.window_index * $2 AS elapsed
+			FROM cur
+			LEFT JOIN rate_limit prev ON prev.key = $1 AND prev.window_index = cur.window_index - 1
+	`, key, seconds); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return &storage.RateLimitHits{
+		Current:  dto.Current,
+		Previous: dto.Previous,
+		Elapsed:  time.Duration(dto.Elapsed * float64(time.Second)),
+	}, nil
+}
+
+func (p pg) DeleteExpiredRateLimits(ctx context.Context) error {
+	if _, err := p.ext.ExecContext(ctx, `DELETE FROM rate_limit WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) CreateEmail(ctx context.Context, e *storage.Email) error {
+	if _, err := p.ext.ExecContext(ctx, `
+		INSERT INTO email_outbox (owner, kind, recipient, payload)
+		VALUES ($1, $2, $3, $4)
+	`, e.Owner, e.Kind, e.Recipient, e.Payload); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return nil
+}
+
+func (p pg) ClaimQueuedEmails(ctx context.Context, limit int, lease time.Duration) ([]*storage.Email, error) {
+	var ee []*storage.Email
+	if err := sqlx.SelectContext(ctx, p.ext, &ee, `
+		WITH claimed AS (
+			UPDATE email_outbox
+			SET locked_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM email_outbox
+				WHERE status = 'queued' AND next_attempt_at <= CURRENT_TIMESTAMP
+					AND (locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)
+				ORDER BY next_attempt_at, id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT * FROM claimed ORDER BY next_attempt_at, id
+	`, limit, lease.Milliseconds()); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return ee, nil
+}
+
+func (p pg) GetEmails(ctx context.Context, owner string, limit int) ([]*storage.Email, error) {
+	var ee []*storage.Email
+	if err := sqlx.SelectContext(ctx, p.ext, &ee, `
+		SELECT * FROM email_outbox
+		WHERE owner = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, owner, limit); err != nil {
+		return nil, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return ee, nil
+}
+
+func (p pg) SetEmailSent(ctx context.Context, id int64) error {
+	return p.updateEmail(ctx, `
+		UPDATE email_outbox
+		SET status = 'sent',
+			payload = '',
+			attempts = attempts + 1,
+			reason = NULL,
+			locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP,
+			sent_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id)
+}
+
+func (p pg) RetryEmail(ctx context.Context, id int64, delay time.Duration, reason string) error {
+	return p.updateEmail(ctx, `
+		UPDATE email_outbox
+		SET attempts = attempts + 1,
+			reason = $2,
+			next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond',
+			locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, reason, delay.Milliseconds())
+}
+
+func (p pg) SetEmailFailed(ctx context.Context, id int64, status storage.EmailStatus, reason string) error {
+	return p.updateEmail(ctx, `
+		UPDATE email_outbox
+		SET status = $2,
+			payload = '',
+			attempts = attempts + 1,
+			reason = $3,
+			locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, status, reason)
+}
+
+func (p pg) updateEmail(ctx context.Context, query string, args ...interface{}) error {
+	res, err := p.ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) CreateChallenge(ctx context.Context, address, nonce string, ttl time.Duration, limit int) (time.Time, error) {
+	var expiresAt time.Time
+	if err := sqlx.GetContext(ctx, p.ext, &expiresAt, `
+		INSERT INTO challenge (address, nonce, expires_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 second')
+		RETURNING expires_at
+	`, address, nonce, int64(ttl/time.Second)); err != nil {
+		return time.Time{}, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if _, err := p.ext.ExecContext(ctx, `
+		DELETE FROM challenge WHERE address=$1 AND (
+			expires_at <= CURRENT_TIMESTAMP OR
+			nonce NOT IN (SELECT nonce FROM challenge WHERE address=$1 ORDER BY expires_at DESC LIMIT $2)
+		)
+	`, address, limit); err != nil {
+		return time.Time{}, fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	return expiresAt, nil
+}
+
+func (p pg) CheckChallenge(ctx context.Context, address, nonce string) error {
+	var exists bool
+	if err := sqlx.GetContext(ctx, p.ext, &exists, `
+		SELECT EXISTS (SELECT 1 FROM challenge WHERE address=$1 AND nonce=$2 AND expires_at > CURRENT_TIMESTAMP)
+	`, address, nonce); err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if !exists {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (p pg) ConsumeChallenge(ctx context.Context, address, nonce string) error {
+	res, err := p.ext.ExecContext(ctx, `
+		DELETE FROM challenge WHERE address=$1 AND nonce=$2 AND expires_at > CURRENT_TIMESTAMP
+	`, address, nonce)
+	if err != nil {
+		return fmt.Errorf("failed to exec query: %w", err)
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
 }
 
 func isUniqueViolationErr(err error, constraint string) bool {
@@ -118,12 +118,18 @@ func migrate(username, password, hostname, dbname string, port int) {
 }
 
 func cleanup(t *testing.T) {
-	_, err := db.ExecContext(ctx, "DELETE FROM referral_tracking")
+	_, err := db.ExecContext(ctx, "DELETE FROM email_outbox")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM payout")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM referral_tracking")
 	require.NoError(t, err)
 	_, err = db.ExecContext(ctx, "DELETE FROM request")
 	require.NoError(t, err)
 	_, err = db.ExecContext(ctx, "DELETE FROM dloan")
 	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM challenge")
+	require.NoError(t, err)
 }
 
 func TestPg_InsertRequest(t *testing.T) {
@@ -146,17 +152,34 @@ func TestPg_InsertRequest(t *testing.T) {
 	assert.Len(t, r.OwnReferralCode, 8)
 
 	require.True(t, errors.Is(storage.ErrAddressIsTaken, s.UpsertRequest(ctx, "own", "em", "address", "code", sql.NullString{})))
-	require.True(t, errors.Is(storage.ErrAddressIsTaken, s.UpsertRequest(ctx, "owner", "em", "address2", "code", sql.NullString{})))
 
-	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com",
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e2@mail.com",
 		"new", "code2", sql.NullString{}))
 	r, err = s.GetRequestByOwner(ctx, "owner")
 	require.NoError(t, err)
 
+	assert.Equal(t, "e2@mail.com", r.Email)
 	assert.Equal(t, "new", r.Address)
 	assert.Equal(t, "code2", r.Code)
 }
 
+func TestPg_UpdateRequestEmails(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "owner2", "e2@mail.com", "address2", "code", sql.NullString{}))
+
+	require.NoError(t, s.UpdateRequestEmails(ctx, map[string]string{"owner": "enc:k1:e"}))
+
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "enc:k1:e", r.Email)
+
+	r, err = s.GetRequestByOwner(ctx, "owner2")
+	require.NoError(t, err)
+	assert.Equal(t, "e2@mail.com", r.Email)
+}
+
 func TestPg_GetConfirmedRegistrationsTotal(t *testing.T) {
 	defer cleanup(t)
 
@@ -252,9 +275,200 @@ func TestPg_SetConfirmed(t *testing.T) {
 
 	assert.True(t, r.ConfirmedAt.Valid)
 
+	assert.True(t, errors.Is(storage.ErrNotFound, s.SetConfirmed(ctx, "owner")), "already confirmed")
 	assert.True(t, errors.Is(storage.ErrNotFound, s.SetConfirmed(ctx, "owner2")))
 }
 
+func TestPg_SearchRequests(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	require.NoError(t, s.UpsertRequest(ctx, "owner2", "e2@mail.com", "address2", "code",
+		sql.NullString{Valid: true, String: r.OwnReferralCode}))
+
+	rr, err := s.SearchRequests(ctx, storage.RequestFilter{Address: "address2"}, 10)
+	require.NoError(t, err)
+	require.Len(t, rr, 1)
+	assert.Equal(t, "owner2", rr[0].Owner)
+
+	rr, err = s.SearchRequests(ctx, storage.RequestFilter{Owners: []string{"k1:owner", "owner"}}, 10)
+	require.NoError(t, err)
+	require.Len(t, rr, 1)
+	assert.Equal(t, "address", rr[0].Address)
+
+	rr, err = s.SearchRequests(ctx, storage.RequestFilter{ReferralCode: r.OwnReferralCode}, 10)
+	require.NoError(t, err)
+	require.Len(t, rr, 2)
+	assert.Equal(t, "owner2", rr[0].Owner)
+
+	rr, err = s.SearchRequests(ctx, storage.RequestFilter{Address: "address", Owners: []string{"owner2"}}, 10)
+	require.NoError(t, err)
+	assert.Len(t, rr, 0)
+}
+
+func TestPg_GetOwnerKeyIDs(t *testing.T) {
+	defer cleanup(t)
+
+	ids, err := s.GetOwnerKeyIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	require.NoError(t, s.UpsertRequest(ctx, "k1:a", "a@mail.com", "address_a", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "k1:b", "b@mail.com", "address_b", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "k2:c", "c@mail.com", "address_c", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "md5", "d@mail.com", "address_d", "code", sql.NullString{}))
+
+	ids, err = s.GetOwnerKeyIDs(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2", ""}, ids)
+}
+
+func TestPg_UpdateRequestOwners(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "a", "a@mail.com", "address_a", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "b", "b@mail.com", "address_b", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "c", "c@mail.com", "address_c", "code", sql.NullString{}))
+	require.NoError(t, s.CreateEmail(ctx, &storage.Email{
+		Owner: "a", Kind: storage.VerificationEmailKind, Recipient: "enc:recipient",
+	}))
+
+	rr, err := s.ListRequests(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, rr, 2)
+	assert.Equal(t, "a", rr[0].Owner)
+	assert.Equal(t, "b", rr[1].Owner)
+
+	rr, err = s.ListRequests(ctx, "b", 2)
+	require.NoError(t, err)
+	require.Len(t, rr, 1)
+	assert.Equal(t, "c", rr[0].Owner)
+
+	require.NoError(t, s.InTx(ctx, func(s storage.Storage) error {
+		return s.UpdateRequestOwners(ctx, map[string]string{"a": "b", "b": "a", "c": "d"})
+	}))
+
+	for address, owner := range map[string]string{"address_a": "b", "address_b": "a", "address_c": "d"} {
+		r, err := s.GetRequestByAddress(ctx, address)
+		require.NoError(t, err)
+		assert.Equal(t, owner, r.Owner)
+	}
+
+	// outbox follows the request owner
+	emails, err := s.GetEmails(ctx, "b", 10)
+	require.NoError(t, err)
+	assert.Len(t, emails, 1)
+}
+
+func TestPg_SetReferralBanned(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+
+	require.NoError(t, s.SetReferralBanned(ctx, "address", true, sql.NullString{Valid: true, String: "fraud"}))
+	r, err := s.GetRequestByAddress(ctx, "address")
+	require.NoError(t, err)
+	assert.True(t, r.ReferralBanned)
+	assert.Equal(t, sql.NullString{Valid: true, String: "fraud"}, r.ReferralBanReason)
+
+	require.NoError(t, s.SetReferralBanned(ctx, "address", false, sql.NullString{}))
+	r, err = s.GetRequestByAddress(ctx, "address")
+	require.NoError(t, err)
+	assert.False(t, r.ReferralBanned)
+	assert.False(t, r.ReferralBanReason.Valid)
+
+	assert.ErrorIs(t, s.SetReferralBanned(ctx, "address2", true, sql.NullString{}), storage.ErrNotFound)
+}
+
+func TestPg_AuditRecords(t *testing.T) {
+	defer func() {
+		_, err := db.ExecContext(ctx, "DELETE FROM admin_audit")
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, s.CreateAuditRecord(ctx, &storage.AuditRecord{
+		Actor: "admin", Action: storage.BanReferralAuditAction, Target: "address", Reason: "fraud",
+	}))
+	require.NoError(t, s.CreateAuditRecord(ctx, &storage.AuditRecord{
+		Actor: "admin", Action: storage.CreateFraudRuleAuditAction, Target: "exact:mailinator.com",
+	}))
+
+	rr, err := s.GetAuditRecords(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, rr, 2)
+	assert.Equal(t, storage.CreateFraudRuleAuditAction, rr[0].Action)
+	assert.False(t, rr[0].CreatedAt.IsZero())
+
+	rr, err = s.GetAuditRecords(ctx, "address", 10)
+	require.NoError(t, err)
+	require.Len(t, rr, 1)
+	assert.Equal(t, "admin", rr[0].Actor)
+	assert.Equal(t, storage.BanReferralAuditAction, rr[0].Action)
+	assert.Equal(t, "fraud", rr[0].Reason)
+}
+
+func TestPg_IncrementFailedAttempts(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+
+	attempts, err := s.IncrementFailedAttempts(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	attempts, err = s.IncrementFailedAttempts(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.FailedAttempts)
+
+	// new code resets attempts
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code2", sql.NullString{}))
+	r, err = s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Zero(t, r.FailedAttempts)
+
+	_, err = s.IncrementFailedAttempts(ctx, "not_exists")
+	assert.True(t, errors.Is(err, storage.ErrNotFound))
+}
+
+func TestPg_ReissueCode(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+	_, err := s.IncrementFailedAttempts(ctx, "owner")
+	require.NoError(t, err)
+
+	r, err := s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Zero(t, r.ResendCount)
+	assert.False(t, r.CodeSentAt.IsZero())
+
+	require.NoError(t, s.ReissueCode(ctx, "owner", "code2", 0))
+	// resent concurrently
+	assert.True(t, errors.Is(s.ReissueCode(ctx, "owner", "code3", 0), storage.ErrNotFound))
+
+	r, err = s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "code2", r.Code)
+	assert.Equal(t, 1, r.ResendCount)
+	assert.Zero(t, r.FailedAttempts)
+	assert.False(t, r.CodeSentAt.Before(r.CreatedAt))
+
+	// registration resets resends
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code4", sql.NullString{}))
+	r, err = s.GetRequestByOwner(ctx, "owner")
+	require.NoError(t, err)
+	assert.Zero(t, r.ResendCount)
+
+	require.NoError(t, s.SetConfirmed(ctx, "owner"))
+	assert.True(t, errors.Is(s.ReissueCode(ctx, "owner", "code5", 0), storage.ErrNotFound))
+}
+
 func TestPg_GetRequestByAddress(t *testing.T) {
 	defer cleanup(t)
 
@@ -291,6 +505,19 @@ func TestPg_GetRequestByOwner(t *testing.T) {
 
 	_, err = s.GetRequestByAddress(ctx, "not_exists")
 	assert.True(t, errors.Is(err, storage.ErrNotFound))
+
+	require.NoError(t, s.UpsertRequest(ctx, "k1:owner", "e2@mail.com", "address2", "code", sql.NullString{}))
+
+	r, err = s.GetRequestByOwner(ctx, "k2:owner", "k1:owner", "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "k1:owner", r.Owner)
+
+	r, err = s.GetRequestByOwner(ctx, "owner", "k1:owner")
+	require.NoError(t, err)
+	assert.Equal(t, "owner", r.Owner)
+
+	_, err = s.GetRequestByOwner(ctx, "k2:owner")
+	assert.True(t, errors.Is(err, storage.ErrNotFound))
 }
 
 func TestPg_CreateConfirmedRequest(t *testing.T) {
@@ -438,14 +665,59 @@ func TestPg_GetUnconfirmedReferralTracking(t *testing.T) {
 	requireNoUnconfirmed()
 }
 
-func TestPg_DoesEmailHaveFraudDomain(t *testing.T) {
-	check, err := s.DoesEmailHaveFraudDomain(context.Background(), "valid@gmail.com")
+func TestPg_FraudRules(t *testing.T) {
+	rules, err := s.GetFraudRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, storage.ExactFraudRuleKind, rules[0].Kind)
+	assert.Equal(t, "aircase.tk", rules[0].Pattern)
+	assert.Equal(t, storage.WildcardFraudRuleKind, rules[1].Kind)
+	assert.Equal(t, "aircase.tk", rules[1].Pattern)
+
+	c, err := s.CreateFraudRules(ctx, []*storage.FraudRule{
+		{Kind: storage.ExactFraudRuleKind, Pattern: "aircase.tk", Source: "test"},
+		{Kind: storage.ExactFraudRuleKind, Pattern: "mailinator.com", Source: "test"},
+		{Kind: storage.RegexFraudRuleKind, Pattern: `^temp-\d+\.com$`, Source: "test"},
+	})
 	require.NoError(t, err)
-	require.False(t, check)
+	assert.Equal(t, 2, c)
+
+	rules, err = s.GetFraudRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	assert.Equal(t, "test", rules[2].Source)
+
+	require.NoError(t, s.CreateFraudRejection(ctx, &storage.FraudRejection{
+		RuleID:  sql.NullInt64{Valid: true, Int64: rules[2].ID},
+		Reason:  "exact domain mailinator.com",
+		Domain:  "mailinator.com",
+		Address: "address",
+	}))
+
+	r := &storage.FraudRule{Kind: storage.MXFraudRuleKind, Pattern: "mx.mailinator.com", Source: "admin"}
+	require.NoError(t, s.CreateFraudRule(ctx, r))
+	assert.NotZero(t, r.ID)
+	assert.False(t, r.CreatedAt.IsZero())
+	assert.ErrorIs(t, s.CreateFraudRule(ctx, &storage.FraudRule{Kind: r.Kind, Pattern: r.Pattern}), storage.ErrFraudRuleExists)
+
+	rule, err := s.GetFraudRule(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "mx.mailinator.com", rule.Pattern)
+
+	require.NoError(t, s.DeleteFraudRule(ctx, r.ID))
+	_, err = s.GetFraudRule(ctx, r.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	require.NoError(t, s.DeleteFraudRule(ctx, rules[2].ID))
+	require.NoError(t, s.DeleteFraudRule(ctx, rules[3].ID))
+	assert.ErrorIs(t, s.DeleteFraudRule(ctx, rules[3].ID), storage.ErrNotFound)
+
+	var ruleID sql.NullInt64
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT rule_id FROM fraud_rejection`).Scan(&ruleID))
+	assert.False(t, ruleID.Valid)
 
-	check, err = s.DoesEmailHaveFraudDomain(context.Background(), "forbidden@aircase.tk")
+	_, err = db.ExecContext(ctx, "DELETE FROM fraud_rejection")
 	require.NoError(t, err)
-	require.True(t, check)
 }
 
 func TestPg_GetReferralTrackingStats(t *testing.T) {
@@ -526,3 +798,347 @@ func TestPg_GetReferralTrackingStats(t *testing.T) {
 		Reward:     sdk.NewInt(10),
 	}, *stats[1])
 }
+
+func TestPg_Payouts(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address1", "code", sql.NullString{}))
+
+	require.NoError(t, s.CreatePayout(ctx, &storage.Payout{
+		Address: "address1",
+		Amount:  sdk.NewInt(100),
+		Memo:    "memo",
+		Purpose: storage.RegistrationPayoutPurpose,
+		Owner:   sql.NullString{Valid: true, String: "owner"},
+	}))
+	require.NoError(t, s.CreatePayout(ctx, &storage.Payout{Address: "address2", Amount: sdk.NewInt(200), Purpose: storage.TestnetPayoutPurpose}))
+
+	require.NoError(t, s.InTx(ctx, func(tx storage.Storage) error {
+		payouts, err := tx.GetPendingPayouts(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, payouts, 2)
+
+		assert.Equal(t, "address1", payouts[0].Address)
+		assert.Equal(t, sdk.NewInt(100), payouts[0].Amount)
+		assert.Equal(t, "memo", payouts[0].Memo)
+		assert.Equal(t, "owner", payouts[0].Owner.String)
+		assert.Equal(t, storage.PendingPayoutStatus, payouts[0].Status)
+		assert.Equal(t, storage.RegistrationPayoutPurpose, payouts[0].Purpose)
+		assert.False(t, payouts[1].Owner.Valid)
+
+		// locked payouts are skipped by concurrent workers
+		locked, err := s.GetPendingPayouts(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, locked)
+
+		require.NoError(t, tx.SetPayoutBroadcast(ctx, payouts[0].ID, "hash1", sdk.NewCoins(sdk.NewInt64Coin("udec", 25)), 1000))
+		require.NoError(t, tx.SetPayoutBroadcast(ctx, payouts[1].ID, "hash2", sdk.NewCoins(sdk.NewInt64Coin("udec", 25)), 1000))
+
+		return nil
+	}))
+
+	payouts, err := s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, payouts)
+
+	payouts, err = s.GetBroadcastPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 2)
+	assert.Equal(t, "hash1", payouts[0].TxHash.String)
+	assert.True(t, payouts[0].BroadcastAt.Valid)
+	assert.Equal(t, "25udec", payouts[0].TxFee.String)
+	assert.Equal(t, int64(1000), payouts[0].TxGas.Int64)
+
+	require.NoError(t, s.SetPayoutResult(ctx, payouts[0].ID, storage.CommittedPayoutStatus, 10, 0, "log"))
+	assert.True(t, errors.Is(s.SetPayoutResult(ctx, payouts[0].ID, storage.FailedPayoutStatus, 10, 1, "log"), storage.ErrNotFound),
+		"result can be set only once")
+	require.NoError(t, s.SetPayoutFailed(ctx, payouts[1].ID, "reason"))
+
+	payouts, err = s.GetBroadcastPayouts(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, payouts)
+
+	// tx of the payout failed because of timeout can be included later
+	payouts, err = s.GetTimedOutPayouts(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 1)
+	assert.Equal(t, "hash2", payouts[0].TxHash.String)
+	timedOutID := payouts[0].ID
+
+	payouts, err = s.GetTimedOutPayouts(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, payouts)
+
+	require.NoError(t, s.SetPayoutResult(ctx, timedOutID, storage.CommittedPayoutStatus, 11, 0, "log"))
+
+	payouts, err = s.GetTimedOutPayouts(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, payouts)
+
+	assert.True(t, errors.Is(s.SetPayoutBroadcast(ctx, 0, "hash", nil, 0), storage.ErrNotFound))
+	assert.True(t, errors.Is(s.SetPayoutFailed(ctx, 0, "reason"), storage.ErrNotFound))
+
+	p, err := s.GetRegistrationPayout(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, storage.CommittedPayoutStatus, p.Status)
+	assert.Equal(t, "hash1", p.TxHash.String)
+
+	_, err = s.GetRegistrationPayout(ctx, "not_exists")
+	assert.True(t, errors.Is(err, storage.ErrNotFound))
+}
+
+func TestPg_PayoutSpending(t *testing.T) {
+	defer cleanup(t)
+
+	for _, v := range []int64{100, 200, 400} {
+		require.NoError(t, s.CreatePayout(ctx, &storage.Payout{Address: "address", Amount: sdk.NewInt(v), Purpose: storage.TestnetPayoutPurpose}))
+	}
+	require.NoError(t, s.CreatePayout(ctx, &storage.Payout{Address: "address", Amount: sdk.NewInt(800), Purpose: storage.RegistrationPayoutPurpose}))
+
+	payouts, err := s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 4)
+
+	require.NoError(t, s.SetPayoutBroadcast(ctx, payouts[0].ID, "hash", nil, 0))
+	require.NoError(t, s.SetPayoutBroadcast(ctx, payouts[1].ID, "hash", nil, 0))
+	require.NoError(t, s.SetPayoutResult(ctx, payouts[1].ID, storage.FailedPayoutStatus, 10, 1, "log"))
+	require.NoError(t, s.SetPayoutBroadcast(ctx, payouts[3].ID, "hash", nil, 0))
+
+	require.NoError(t, s.InTx(ctx, func(tx storage.Storage) error {
+		require.NoError(t, tx.LockSpending(ctx))
+
+		spending, err := tx.GetSpending(ctx, storage.TestnetPayoutPurpose)
+		require.NoError(t, err)
+		assert.Equal(t, sdk.NewInt(100), spending.Day)
+		assert.Equal(t, sdk.NewInt(100), spending.Month)
+
+		return nil
+	}))
+
+	spending, err := s.GetSpending(ctx, storage.ReferralSenderPayoutPurpose)
+	require.NoError(t, err)
+	assert.True(t, spending.Day.IsZero())
+	assert.True(t, spending.Month.IsZero())
+
+	// approval
+	require.NoError(t, s.SetPayoutApproval(ctx, payouts[2].ID, "cap"))
+	payouts, err = s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, payouts)
+
+	payouts, err = s.GetApprovalPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 1)
+	assert.Equal(t, "cap", payouts[0].Error.String)
+
+	require.NoError(t, s.ApprovePayout(ctx, payouts[0].ID))
+	assert.True(t, errors.Is(s.ApprovePayout(ctx, payouts[0].ID), storage.ErrNotFound), "payout can be approved only once")
+
+	payouts, err = s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 1)
+	assert.True(t, payouts[0].ApprovedAt.Valid)
+}
+
+func TestPg_PayoutBatches(t *testing.T) {
+	defer cleanup(t)
+
+	for _, v := range []int64{100, 200, 400} {
+		require.NoError(t, s.CreatePayout(ctx, &storage.Payout{Address: "address", Amount: sdk.NewInt(v), Purpose: storage.TestnetPayoutPurpose}))
+	}
+
+	payouts, err := s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, payouts, 3)
+
+	batch, err := s.GetBatchPayouts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	require.NoError(t, s.SetPayoutsBatch(ctx, []int64{payouts[1].ID, payouts[0].ID}, "key"))
+
+	// batched payouts aren't batched again
+	pending, err := s.GetPendingPayouts(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, payouts[2].ID, pending[0].ID)
+
+	// batched payouts are counted in spending before the broadcast
+	spending, err := s.GetSpending(ctx, storage.TestnetPayoutPurpose)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.NewInt(300), spending.Day)
+	assert.Equal(t, sdk.NewInt(300), spending.Month)
+
+	errRollback := errors.New("rollback")
+	assert.ErrorIs(t, s.InTx(ctx, func(tx storage.Storage) error {
+		batch, err := tx.GetBatchPayouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, batch, 2)
+		assert.Equal(t, payouts[0].ID, batch[0].ID)
+		assert.Equal(t, payouts[1].ID, batch[1].ID)
+		assert.Equal(t, "key", batch[0].BatchKey.String)
+		assert.True(t, batch[0].BatchedAt.Valid)
+
+		// locked batches are skipped by concurrent workers
+		locked, err := s.GetBatchPayouts(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, locked)
+
+		return errRollback
+	}), errRollback)
+
+	// the batch is kept after rollback
+	batch, err = s.GetBatchPayouts(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	for _, v := range batch {
+		require.NoError(t, s.SetPayoutBroadcast(ctx, v.ID, "hash", nil, 0))
+	}
+
+	batch, err = s.GetBatchPayouts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	spending, err = s.GetSpending(ctx, storage.TestnetPayoutPurpose)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.NewInt(300), spending.Month)
+}
+
+func TestPg_HitRateLimit(t *testing.T) {
+	defer func() {
+		_, err := db.ExecContext(ctx, "DELETE FROM rate_limit")
+		require.NoError(t, err)
+	}()
+
+	for i := 1; i <= 3; i++ {
+		hits, err := s.HitRateLimit(ctx, "key", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, i, hits.Current)
+		assert.Equal(t, 0, hits.Previous)
+		assert.True(t, hits.Elapsed >= 0 && hits.Elapsed < time.Hour)
+	}
+
+	// previous window
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO rate_limit (key, window_index, hits, expires_at)
+		SELECT key, window_index - 1, 5, expires_at FROM rate_limit WHERE key = 'key'
+	`)
+	require.NoError(t, err)
+
+	hits, err := s.HitRateLimit(ctx, "key", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, hits.Current)
+	assert.Equal(t, 5, hits.Previous)
+
+	hits, err = s.HitRateLimit(ctx, "other", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits.Current)
+
+	_, err = db.ExecContext(ctx, `UPDATE rate_limit SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 second' WHERE key = 'key'`)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteExpiredRateLimits(ctx))
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM rate_limit`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestPg_Challenge(t *testing.T) {
+	defer cleanup(t)
+
+	expiresAt, err := s.CreateChallenge(ctx, "address", "nonce1", time.Minute, 2)
+	require.NoError(t, err)
+	assert.False(t, expiresAt.IsZero())
+
+	require.NoError(t, s.CheckChallenge(ctx, "address", "nonce1"))
+	assert.True(t, errors.Is(s.CheckChallenge(ctx, "address2", "nonce1"), storage.ErrNotFound))
+
+	// the latest challenges are kept
+	_, err = s.CreateChallenge(ctx, "address", "nonce2", time.Minute, 2)
+	require.NoError(t, err)
+	_, err = s.CreateChallenge(ctx, "address", "nonce3", time.Minute, 2)
+	require.NoError(t, err)
+	assert.True(t, errors.Is(s.CheckChallenge(ctx, "address", "nonce1"), storage.ErrNotFound))
+	assert.True(t, errors.Is(s.ConsumeChallenge(ctx, "address", "nonce1"), storage.ErrNotFound))
+	assert.True(t, errors.Is(s.ConsumeChallenge(ctx, "address2", "nonce2"), storage.ErrNotFound))
+
+	require.NoError(t, s.ConsumeChallenge(ctx, "address", "nonce2"))
+	// single use
+	assert.True(t, errors.Is(s.CheckChallenge(ctx, "address", "nonce2"), storage.ErrNotFound))
+	assert.True(t, errors.Is(s.ConsumeChallenge(ctx, "address", "nonce2"), storage.ErrNotFound))
+	require.NoError(t, s.ConsumeChallenge(ctx, "address", "nonce3"))
+
+	_, err = s.CreateChallenge(ctx, "address", "nonce4", 0, 2)
+	require.NoError(t, err)
+	assert.True(t, errors.Is(s.CheckChallenge(ctx, "address", "nonce4"), storage.ErrNotFound))
+	assert.True(t, errors.Is(s.ConsumeChallenge(ctx, "address", "nonce4"), storage.ErrNotFound))
+}
+
+func TestPg_EmailOutbox(t *testing.T) {
+	defer cleanup(t)
+
+	require.NoError(t, s.UpsertRequest(ctx, "owner", "e@mail.com", "address", "code", sql.NullString{}))
+	require.NoError(t, s.UpsertRequest(ctx, "other", "e2@mail.com", "address2", "code", sql.NullString{}))
+
+	for _, owner := range []string{"owner", "owner", "other"} {
+		require.NoError(t, s.CreateEmail(ctx, &storage.Email{
+			Owner:     owner,
+			Kind:      storage.VerificationEmailKind,
+			Recipient: "enc:recipient",
+			Payload:   "enc:payload",
+		}))
+	}
+
+	// the lease is over at once, so the emails can be claimed again
+	queued, err := s.ClaimQueuedEmails(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, queued, 3)
+
+	queued, err = s.ClaimQueuedEmails(ctx, 10, time.Hour)
+	require.NoError(t, err)
+
+	// claimed emails are skipped
+	other, err := s.ClaimQueuedEmails(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	require.Len(t, queued, 3)
+	assert.Equal(t, storage.QueuedEmailStatus, queued[0].Status)
+	assert.Equal(t, "enc:payload", queued[0].Payload)
+	assert.Zero(t, queued[0].Attempts)
+	assert.True(t, queued[0].LockedUntil.Valid)
+
+	require.NoError(t, s.SetEmailSent(ctx, queued[0].ID))
+	require.NoError(t, s.RetryEmail(ctx, queued[1].ID, time.Hour, "timeout"))
+	require.NoError(t, s.SetEmailFailed(ctx, queued[2].ID, storage.RejectedEmailStatus, "invalid recipient"))
+	assert.True(t, errors.Is(s.SetEmailSent(ctx, 0), storage.ErrNotFound))
+
+	// retried email waits for the next attempt
+	due, err := s.ClaimQueuedEmails(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	emails, err := s.GetEmails(ctx, "owner", 10)
+	require.NoError(t, err)
+	require.Len(t, emails, 2)
+
+	retried, sent := emails[0], emails[1]
+	assert.Equal(t, storage.SentEmailStatus, sent.Status)
+	assert.Empty(t, sent.Payload)
+	assert.True(t, sent.SentAt.Valid)
+	assert.Equal(t, 1, sent.Attempts)
+
+	assert.Equal(t, storage.QueuedEmailStatus, retried.Status)
+	assert.Equal(t, "enc:payload", retried.Payload)
+	assert.Equal(t, sql.NullString{Valid: true, String: "timeout"}, retried.Reason)
+	assert.False(t, retried.LockedUntil.Valid)
+	assert.True(t, retried.NextAttemptAt.After(retried.CreatedAt.Add(59*time.Minute)))
+
+	emails, err = s.GetEmails(ctx, "other", 10)
+	require.NoError(t, err)
+	require.Len(t, emails, 1)
+	assert.Equal(t, storage.RejectedEmailStatus, emails[0].Status)
+	assert.Empty(t, emails[0].Payload)
+	assert.Equal(t, "invalid recipient", emails[0].Reason.String)
+}
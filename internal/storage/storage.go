@@ -24,10 +24,13 @@ var ErrReferralTrackingExists = fmt.Errorf("referral tracking exists")
 // ErrReferralCodeNotFound ...
 var ErrReferralCodeNotFound = fmt.Errorf("referral code not found")
 
+// ErrFraudRuleExists ...
+var ErrFraudRuleExists = fmt.Errorf("fraud rule exists")
+
 // Request ...
 type Request struct {
 	Owner                    string         `db:"owner"`
-	Email                    string         `db:"email"`
+	Email                    string         `db:"email"` // encrypted with envelope.Keyring, emails stored before the encryption are plain
 	Address                  string         `db:"address"`
 	Code                     string         `db:"code"`
 	CreatedAt                time.Time      `db:"created_at"`
@@ -35,6 +38,10 @@ type Request struct {
 	OwnReferralCode          string         `db:"own_referral_code"`
 	RegistrationReferralCode sql.NullString `db:"registration_referral_code"`
 	ReferralBanned           bool           `db:"referral_banned"`
+	ReferralBanReason        sql.NullString `db:"referral_ban_reason"`
+	FailedAttempts           int            `db:"failed_attempts"`
+	CodeSentAt               time.Time      `db:"code_sent_at"`
+	ResendCount              int            `db:"resend_count"`
 }
 
 // DLoan ...
@@ -79,6 +86,191 @@ type ReferralTrackingStats struct {
 	Reward     sdk.Int `db:"reward"`
 }
 
+// PayoutStatus represents a payout workflow status: pending -> broadcast -> committed or failed.
+type PayoutStatus string
+
+const (
+	// PendingPayoutStatus means the payout is waiting for the worker.
+	PendingPayoutStatus PayoutStatus = "pending"
+	// BroadcastPayoutStatus means the payout tx is accepted by the node.
+	BroadcastPayoutStatus PayoutStatus = "broadcast"
+	// CommittedPayoutStatus means the payout tx is included in a block.
+	CommittedPayoutStatus PayoutStatus = "committed"
+	// FailedPayoutStatus means the payout tx was rejected.
+	FailedPayoutStatus PayoutStatus = "failed"
+	// ApprovalPayoutStatus means the payout exceeds spending cap and waits for manual approval.
+	ApprovalPayoutStatus PayoutStatus = "approval"
+)
+
+// PayoutPurpose is a reason of payout which spending caps are set for.
+type PayoutPurpose string
+
+const (
+	// RegistrationPayoutPurpose is initial stakes sent after the registration confirmation.
+	RegistrationPayoutPurpose PayoutPurpose = "registration"
+	// ReferralSenderPayoutPurpose is a reward sent to the referral code owner.
+	ReferralSenderPayoutPurpose PayoutPurpose = "referral_sender"
+	// ReferralReceiverPayoutPurpose is a reward sent to the user registered with the referral code.
+	ReferralReceiverPayoutPurpose PayoutPurpose = "referral_receiver"
+	// TestnetPayoutPurpose is stakes given in testnet.
+	TestnetPayoutPurpose PayoutPurpose = "testnet"
+)
+
+// Payout ...
+type Payout struct {
+	ID      int64         `db:"id"`
+	Address string        `db:"address"`
+	Amount  sdk.Int       `db:"amount"`
+	Memo    string        `db:"memo"`
+	Status  PayoutStatus  `db:"status"`
+	Purpose PayoutPurpose `db:"purpose"`
+	// Owner is set for registration payouts.
+	Owner sql.NullString `db:"owner"`
+	// ReferralReceiver is set for referral reward payouts.
+	ReferralReceiver sql.NullString `db:"referral_receiver"`
+	TxHash           sql.NullString `db:"tx_hash"`
+	TxHeight         sql.NullInt64  `db:"tx_height"`
+	TxCode           sql.NullInt32  `db:"tx_code"`
+	TxRawLog         sql.NullString `db:"tx_raw_log"`
+	Error            sql.NullString `db:"error"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+	BroadcastAt      sql.NullTime   `db:"broadcast_at"`
+	// TxFee and TxGas belong to the whole tx which can contain several payouts.
+	TxFee sql.NullString `db:"tx_fee"`
+	TxGas sql.NullInt64  `db:"tx_gas"`
+	// ApprovedAt is set when the payout is approved manually, such payouts aren't checked against spending caps.
+	ApprovedAt sql.NullTime `db:"approved_at"`
+	// BatchKey is idempotency key of the tx the payout is sent in, it's stored before the tx is broadcast.
+	BatchKey  sql.NullString `db:"batch_key"`
+	BatchedAt sql.NullTime   `db:"batched_at"`
+}
+
+// EmailKind is a kind of email template.
+type EmailKind string
+
+const (
+	// VerificationEmailKind is an email with confirmation code.
+	VerificationEmailKind EmailKind = "verification"
+	// WelcomeEmailKind is an email sent after the registration confirmation.
+	WelcomeEmailKind EmailKind = "welcome"
+)
+
+// EmailStatus represents an email delivery status: queued -> sent, rejected or failed.
+type EmailStatus string
+
+const (
+	// QueuedEmailStatus means the email is waiting for the outbox worker.
+	QueuedEmailStatus EmailStatus = "queued"
+	// SentEmailStatus means the email is accepted by mail provider.
+	SentEmailStatus EmailStatus = "sent"
+	// RejectedEmailStatus means mail provider rejected the email, it isn't retried.
+	RejectedEmailStatus EmailStatus = "rejected"
+	// FailedEmailStatus means the email isn't delivered within max attempts.
+	FailedEmailStatus EmailStatus = "failed"
+)
+
+// Email is an outbox email.
+type Email struct {
+	ID    int64     `db:"id"`
+	Owner string    `db:"owner"`
+	Kind  EmailKind `db:"kind"`
+	// Recipient and Payload are encrypted, Payload contains template params and is cleared after delivery.
+	Recipient     string         `db:"recipient"`
+	Payload       string         `db:"payload"`
+	Status        EmailStatus    `db:"status"`
+	Attempts      int            `db:"attempts"`
+	Reason        sql.NullString `db:"reason"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	LockedUntil   sql.NullTime   `db:"locked_until"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+	SentAt        sql.NullTime   `db:"sent_at"`
+}
+
+// Spending is amount of payouts sent in the current day and month.
+type Spending struct {
+	Day   sdk.Int
+	Month sdk.Int
+}
+
+// RateLimitHits are hits of a rate limit key in the current and previous fixed windows.
+type RateLimitHits struct {
+	Current  int
+	Previous int
+	// Elapsed is time passed since the current window start.
+	Elapsed time.Duration
+}
+
+// FraudRuleKind defines how a fraud rule pattern is matched.
+type FraudRuleKind string
+
+const (
+	// ExactFraudRuleKind matches the email domain equal to the pattern.
+	ExactFraudRuleKind FraudRuleKind = "exact"
+	// WildcardFraudRuleKind matches subdomains of the pattern.
+	WildcardFraudRuleKind FraudRuleKind = "wildcard"
+	// RegexFraudRuleKind matches the email domain with the regular expression.
+	RegexFraudRuleKind FraudRuleKind = "regex"
+	// MXFraudRuleKind matches MX hosts of the email domain equal to the pattern or its subdomains.
+	MXFraudRuleKind FraudRuleKind = "mx"
+)
+
+// FraudRule is a rule email domains are rejected by.
+type FraudRule struct {
+	ID      int64         `db:"id"`
+	Kind    FraudRuleKind `db:"kind"`
+	Pattern string        `db:"pattern"`
+	// Source is where the rule came from, e.g. list file name.
+	Source    string    `db:"source"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// FraudRejection is a registration rejected by fraud rules.
+type FraudRejection struct {
+	ID int64 `db:"id"`
+	// RuleID isn't set if the domain is rejected by a check without rule, e.g. missing MX records.
+	RuleID    sql.NullInt64 `db:"rule_id"`
+	Reason    string        `db:"reason"`
+	Domain    string        `db:"domain"`
+	Address   string        `db:"address"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+// RequestFilter filters requests by any of set fields.
+type RequestFilter struct {
+	Address string
+	// Owners matches any of owners.
+	Owners []string
+	// ReferralCode matches own and registration referral codes.
+	ReferralCode string
+}
+
+// AuditAction is a kind of administrative change.
+type AuditAction string
+
+const (
+	// CreateFraudRuleAuditAction means a fraud rule was created.
+	CreateFraudRuleAuditAction AuditAction = "create_fraud_rule"
+	// DeleteFraudRuleAuditAction means a fraud rule was deleted.
+	DeleteFraudRuleAuditAction AuditAction = "delete_fraud_rule"
+	// BanReferralAuditAction means a referral sender was banned.
+	BanReferralAuditAction AuditAction = "ban_referral"
+	// UnbanReferralAuditAction means a referral sender was unbanned.
+	UnbanReferralAuditAction AuditAction = "unban_referral"
+)
+
+// AuditRecord is an administrative change.
+type AuditRecord struct {
+	ID     int64       `db:"id"`
+	Actor  string      `db:"actor"`
+	Action AuditAction `db:"action"`
+	// Target is a changed entity, e.g. address or kind:pattern of fraud rule.
+	Target    string    `db:"target"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
 // RegisterStats ...
 type RegisterStats struct {
 	Date  time.Time `json:"date"`
@@ -93,18 +285,38 @@ type Storage interface {
 	GetConfirmedRegistrationsTotal(ctx context.Context) (int, error)
 	// GetConfirmedRegistrationsStats return confirmed accounts stats for the last 30 days
 	GetConfirmedRegistrationsStats(ctx context.Context) ([]*RegisterStats, error)
-	// GetRequestByOwner returns request by owner.
-	GetRequestByOwner(ctx context.Context, owner string) (*Request, error)
+	// GetRequestByOwner returns request by any of owners, the earlier owner is preferred.
+	GetRequestByOwner(ctx context.Context, owners ...string) (*Request, error)
 	// GetRequestByOwnReferralCode returns request by referral code.
 	GetRequestByOwnReferralCode(ctx context.Context, ownReferralCode string) (*Request, error)
 	// GetRequestByAddress returns request by address.
 	GetRequestByAddress(ctx context.Context, address string) (*Request, error)
-	// SetConfirmed sets request confirmed.
+	// SearchRequests returns requests matching the filter, newest first.
+	SearchRequests(ctx context.Context, filter RequestFilter, limit int) ([]*Request, error)
+	// ListRequests returns requests ordered by owner starting after the given owner.
+	ListRequests(ctx context.Context, afterOwner string, limit int) ([]*Request, error)
+	// UpdateRequestOwners replaces owners of requests by the old -> new map. New owners should be unique.
+	// Owners are changed in two steps, so swapped owners don't conflict; it should be called within transaction.
+	UpdateRequestOwners(ctx context.Context, owners map[string]string) error
+	// GetOwnerKeyIDs returns distinct ids of keys owners of requests are computed with.
+	// Empty id means unsalted md5 owners of requests which aren't rehashed yet.
+	GetOwnerKeyIDs(ctx context.Context) ([]string, error)
+	// UpdateRequestEmails replaces emails of requests by the owner -> email map.
+	UpdateRequestEmails(ctx context.Context, emails map[string]string) error
+	// SetReferralBanned bans or unbans referral sender. ErrNotFound is returned if there is no request with the address.
+	SetReferralBanned(ctx context.Context, address string, banned bool, reason sql.NullString) error
+	// SetConfirmed sets request confirmed. ErrNotFound is returned if there is no unconfirmed request.
 	SetConfirmed(ctx context.Context, owner string) error
 	// CreateTestnetConfirmedRequest creates a confirmed request. Must be used only in Testnet.
 	CreateTestnetConfirmedRequest(ctx context.Context, address string) error
-	// UpsertRequest inserts request into storage.
+	// UpsertRequest inserts or replaces request of the owner. Code is expected to be hashed with token.Hash,
+	// email is expected to be encrypted with envelope.Keyring.
 	UpsertRequest(ctx context.Context, owner, email, address, code string, referralCode sql.NullString) error
+	// ReissueCode replaces code of unconfirmed request, resets failed attempts and increments resends count.
+	// ErrNotFound is returned if the request is confirmed or its resends count isn't resendCount anymore.
+	ReissueCode(ctx context.Context, owner, code string, resendCount int) error
+	// IncrementFailedAttempts increments count of failed confirmation attempts and returns the new value.
+	IncrementFailedAttempts(ctx context.Context, owner string) (int, error)
 	// CreateReferralTracking creates a new referral tracking
 	CreateReferralTracking(ctx context.Context, receiver string, referralCode string) error
 	// TransitionReferralTrackingToInstalled transitions referral tracking of the given referral code receiver as installed
@@ -119,10 +331,86 @@ type Storage interface {
 	GetUnconfirmedReferralTracking(ctx context.Context, days int) ([]*ReferralTracking, error)
 	// GetConfirmedReferralTrackingCount returns count of confirmed referrals
 	GetConfirmedReferralTrackingCount(ctx context.Context, sender string) (int, error)
-	// DoesEmailHaveFraudDomain checks if the given email has fraud domain
-	DoesEmailHaveFraudDomain(ctx context.Context, email string) (bool, error)
+	// GetFraudRules returns all fraud rules.
+	GetFraudRules(ctx context.Context) ([]*FraudRule, error)
+	// GetFraudRule returns fraud rule by id.
+	GetFraudRule(ctx context.Context, id int64) (*FraudRule, error)
+	// CreateFraudRule creates fraud rule and sets its id and creation time. ErrFraudRuleExists is returned for duplicates.
+	CreateFraudRule(ctx context.Context, r *FraudRule) error
+	// CreateFraudRules creates fraud rules skipping existing ones and returns count of created rules.
+	CreateFraudRules(ctx context.Context, rules []*FraudRule) (int, error)
+	// DeleteFraudRule deletes fraud rule. ErrNotFound is returned if there is no such rule.
+	DeleteFraudRule(ctx context.Context, id int64) error
+	// CreateFraudRejection records a registration rejected by fraud rules.
+	CreateFraudRejection(ctx context.Context, r *FraudRejection) error
+	// CreateAuditRecord records an administrative change.
+	CreateAuditRecord(ctx context.Context, r *AuditRecord) error
+	// GetAuditRecords returns the latest administrative changes, only changes of the target are returned if it's set.
+	GetAuditRecords(ctx context.Context, target string, limit int) ([]*AuditRecord, error)
 	// CreateDLoan creates a dLoan.
 	CreateDLoan(ctx context.Context, address, firstName, lastName string, pdv float64) error
 	// GetDLoans returns a list of DLoans.
 	GetDLoans(ctx context.Context, take, skip int) ([]*DLoan, error)
+	// CreatePayout creates a pending payout. Only address, amount, memo, purpose and links to request/referral are used.
+	CreatePayout(ctx context.Context, p *Payout) error
+	// GetRegistrationPayout returns the latest registration payout of the owner.
+	// ErrNotFound is returned if there is no such payout.
+	GetRegistrationPayout(ctx context.Context, owner string) (*Payout, error)
+	// GetPendingPayouts returns pending payouts which aren't batched yet locking them till the end of transaction.
+	// Payouts locked by another transaction are skipped.
+	GetPendingPayouts(ctx context.Context, limit int) ([]*Payout, error)
+	// SetPayoutsBatch assigns pending payouts to the batch with the key.
+	SetPayoutsBatch(ctx context.Context, ids []int64, key string) error
+	// GetBatchPayouts returns pending payouts of the oldest batch ordered by id locking them till the end of transaction.
+	// Batches locked by another transaction are skipped.
+	GetBatchPayouts(ctx context.Context) ([]*Payout, error)
+	// GetBroadcastPayouts returns payouts waiting for inclusion in a block ordered by broadcast time.
+	GetBroadcastPayouts(ctx context.Context, limit int) ([]*Payout, error)
+	// SetPayoutBroadcast marks payout as broadcast with the given tx hash, fee and gas limit.
+	SetPayoutBroadcast(ctx context.Context, id int64, txHash string, fee sdk.Coins, gas uint64) error
+	// GetTimedOutPayouts returns payouts failed after since because their tx wasn't included in a block in time.
+	GetTimedOutPayouts(ctx context.Context, since time.Time, limit int) ([]*Payout, error)
+	// SetPayoutResult sets status of broadcast payout according to the tx included in a block.
+	// The result can be set for payouts failed because of timeout too, since their tx can be included later.
+	SetPayoutResult(ctx context.Context, id int64, status PayoutStatus, height int64, code uint32, rawLog string) error
+	// SetPayoutFailed marks payout as failed with the given reason.
+	SetPayoutFailed(ctx context.Context, id int64, reason string) error
+	// SetPayoutApproval marks payout as waiting for manual approval with the given reason.
+	SetPayoutApproval(ctx context.Context, id int64, reason string) error
+	// ApprovePayout returns payout waiting for approval to pending. ErrNotFound is returned if there is no such payout.
+	ApprovePayout(ctx context.Context, id int64) error
+	// GetApprovalPayouts returns payouts waiting for manual approval.
+	GetApprovalPayouts(ctx context.Context, limit int) ([]*Payout, error)
+	// LockSpending locks spending till the end of transaction, so concurrent workers check spending caps one by one.
+	LockSpending(ctx context.Context) error
+	// GetSpending returns amount of batched, broadcast and committed payouts of the purpose.
+	GetSpending(ctx context.Context, purpose PayoutPurpose) (*Spending, error)
+
+	// HitRateLimit increments hits of the key in the current window and returns hits of current and previous windows.
+	// Windows are aligned to unix epoch, so all replicas share them.
+	HitRateLimit(ctx context.Context, key string, window time.Duration) (*RateLimitHits, error)
+	// DeleteExpiredRateLimits deletes hits of windows which aren't used anymore.
+	DeleteExpiredRateLimits(ctx context.Context) error
+
+	// CreateEmail puts the email to the outbox. Only owner, kind, recipient and payload are used.
+	CreateEmail(ctx context.Context, e *Email) error
+	// ClaimQueuedEmails returns queued emails which attempt time has come and locks them for lease.
+	// Emails locked by another worker are skipped till their lease ends, updating the email status releases it.
+	ClaimQueuedEmails(ctx context.Context, limit int, lease time.Duration) ([]*Email, error)
+	// GetEmails returns the latest emails of the owner.
+	GetEmails(ctx context.Context, owner string, limit int) ([]*Email, error)
+	// SetEmailSent marks the email sent and clears its payload.
+	SetEmailSent(ctx context.Context, id int64) error
+	// RetryEmail increments attempts of the email and postpones the next attempt by delay.
+	RetryEmail(ctx context.Context, id int64, delay time.Duration, reason string) error
+	// SetEmailFailed marks the email rejected or failed and clears its payload.
+	SetEmailFailed(ctx context.Context, id int64, status EmailStatus, reason string) error
+
+	// CreateChallenge adds the address challenge valid for ttl and returns its expiration time.
+	// Only the latest limit challenges are kept per address, older and expired ones are deleted.
+	CreateChallenge(ctx context.Context, address, nonce string, ttl time.Duration, limit int) (time.Time, error)
+	// CheckChallenge checks the address challenge exists. ErrNotFound is returned if the challenge doesn't match or is expired.
+	CheckChallenge(ctx context.Context, address, nonce string) error
+	// ConsumeChallenge deletes the address challenge. ErrNotFound is returned if the challenge doesn't match or is expired.
+	ConsumeChallenge(ctx context.Context, address, nonce string) error
 }
@@ -0,0 +1,81 @@
+// Package token contains helpers to keep secret tokens (e.g. confirmation codes) hashed in storage.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	prefix   = "scrypt$"
+	saltSize = 16
+	keySize  = 32
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Hash returns salted hash of the token in form of "scrypt$<salt>$<key>".
+func Hash(token string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := derive(token, salt)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(salt) + "$" + hex.EncodeToString(key), nil
+}
+
+// Compare checks the token matches the hashed one in constant time.
+// Values without hash prefix are considered as legacy plaintext tokens.
+func Compare(hashed, token string) bool {
+	if !IsHashed(hashed) {
+		return subtle.ConstantTimeCompare([]byte(hashed), []byte(token)) == 1
+	}
+
+	parts := strings.Split(strings.TrimPrefix(hashed, prefix), "$")
+	if len(parts) != 2 {
+		return false
+	}
+
+	salt, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+
+	expected, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	key, err := derive(token, salt)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(expected, key) == 1
+}
+
+// IsHashed returns true if the value was produced by Hash.
+func IsHashed(v string) bool {
+	return strings.HasPrefix(v, prefix)
+}
+
+func derive(token string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(token), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	return key, nil
+}
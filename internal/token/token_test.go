@@ -0,0 +1,37 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	h1, err := Hash("a1b2c3")
+	require.NoError(t, err)
+	h2, err := Hash("a1b2c3")
+	require.NoError(t, err)
+
+	assert.True(t, IsHashed(h1))
+	assert.NotContains(t, h1, "a1b2c3")
+	assert.NotEqual(t, h1, h2, "salt should differ")
+}
+
+func TestCompare(t *testing.T) {
+	h, err := Hash("a1b2c3")
+	require.NoError(t, err)
+
+	assert.True(t, Compare(h, "a1b2c3"))
+	assert.False(t, Compare(h, "a1b2c4"))
+	assert.False(t, Compare(h, ""))
+
+	// legacy plaintext values
+	assert.True(t, Compare("a1b2c3", "a1b2c3"))
+	assert.False(t, Compare("a1b2c3", "a1b2c4"))
+	assert.False(t, Compare(h, h))
+
+	// malformed hashes
+	assert.False(t, Compare(prefix+"zz$00", "a1b2c3"))
+	assert.False(t, Compare(prefix+"00", "a1b2c3"))
+}
@@ -0,0 +1,135 @@
+// Package wallet contains the monitor of the hot wallet stakes are sent from.
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/decentr/config"
+	"github.com/Decentr-net/vulcan/internal/health"
+)
+
+// Monitor periodically checks the wallet balance and alerts when it's enough only for a few registrations.
+type Monitor struct {
+	bank    banktypes.QueryClient
+	address sdk.AccAddress
+	stake   sdk.Int
+	// thresholds are counts of registrations the balance is enough for, sorted in descending order.
+	thresholds []int
+
+	mu      sync.RWMutex
+	balance sdk.Int
+	// level is count of thresholds the balance is below.
+	level int
+}
+
+// NewMonitor returns new instance of Monitor.
+// Thresholds are counts of registrations paid with the given stake that the balance is enough for.
+func NewMonitor(bank banktypes.QueryClient, address sdk.AccAddress, stake sdk.Int, thresholds []int) *Monitor {
+	t := make([]int, len(thresholds))
+	copy(t, thresholds)
+	sort.Sort(sort.Reverse(sort.IntSlice(t)))
+
+	return &Monitor{
+		bank:       bank,
+		address:    address,
+		stake:      stake,
+		thresholds: t,
+	}
+}
+
+// Run runs the monitor loop.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	m.do(ctx)
+
+	ticker := time.NewTicker(interval)
+	go func(ticker *time.Ticker) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.do(ctx)
+			}
+		}
+	}(ticker)
+}
+
+// Ping returns health.ErrDegraded if the balance is below any threshold.
+func (m *Monitor) Ping(_ context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.level == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("wallet: %w: balance is %s%s, enough for about %d registrations",
+		health.ErrDegraded, m.balance, config.DefaultBondDenom, m.registrations(m.balance))
+}
+
+func (m *Monitor) do(ctx context.Context) {
+	resp, err := m.bank.Balance(ctx, &banktypes.QueryBalanceRequest{
+		Address: m.address.String(),
+		Denom:   config.DefaultBondDenom,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to get wallet balance")
+		return
+	}
+
+	balance := sdk.ZeroInt()
+	if resp.Balance != nil {
+		balance = resp.Balance.Amount
+	}
+
+	registrations := m.registrations(balance)
+
+	level := 0
+	for _, v := range m.thresholds {
+		if registrations < v {
+			level++
+		}
+	}
+
+	m.mu.Lock()
+	prev := m.level
+	m.balance, m.level = balance, level
+	m.mu.Unlock()
+
+	l := log.WithFields(log.Fields{
+		"address":       m.address.String(),
+		"balance":       balance.String(),
+		"registrations": registrations,
+	})
+
+	// alert only once per crossed threshold, the alert is repeated after the wallet is refilled
+	if level > prev {
+		l.WithField("sender", "slack").Warnf("wallet balance is low: enough for less than %d registrations",
+			m.thresholds[level-1])
+		return
+	}
+
+	l.Debug("wallet balance is checked")
+}
+
+func (m *Monitor) registrations(balance sdk.Int) int {
+	if !m.stake.IsPositive() {
+		return 0
+	}
+
+	n := balance.Quo(m.stake)
+	if !n.IsInt64() || n.Int64() > math.MaxInt32 {
+		return math.MaxInt32
+	}
+
+	return int(n.Int64())
+}
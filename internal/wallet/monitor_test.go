@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/Decentr-net/vulcan/internal/health"
+	"github.com/Decentr-net/vulcan/internal/slack"
+)
+
+type fakeBank struct {
+	banktypes.QueryClient
+
+	balance int64
+	err     error
+}
+
+func (f *fakeBank) Balance(_ context.Context, in *banktypes.QueryBalanceRequest, _ ...grpc.CallOption) (*banktypes.QueryBalanceResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	c := sdk.NewInt64Coin(in.Denom, f.balance)
+	return &banktypes.QueryBalanceResponse{Balance: &c}, nil
+}
+
+// slackServer counts alerts posted to slack.
+type slackServer struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (s *slackServer) ServeHTTP(_ http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	s.count++
+	s.mu.Unlock()
+}
+
+func (s *slackServer) alerts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+func TestMonitor(t *testing.T) {
+	// alerts are checked through the hook configured in production, so they are not filtered out by level
+	server := &slackServer{}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+	logrus.AddHook(slack.NewHook(srv.URL, "alerts"))
+
+	bank := &fakeBank{balance: 1000}
+	m := NewMonitor(bank, sdk.AccAddress("address"), sdk.NewInt(10), []int{10, 50})
+	ctx := context.Background()
+
+	m.do(ctx)
+	require.NoError(t, m.Ping(ctx))
+	assert.Equal(t, 0, server.alerts())
+
+	bank.balance = 400 // 40 registrations
+	m.do(ctx)
+	assert.True(t, errors.Is(m.Ping(ctx), health.ErrDegraded))
+	assert.Equal(t, 1, server.alerts())
+
+	m.do(ctx)
+	assert.Equal(t, 1, server.alerts(), "alert should not be repeated")
+
+	bank.balance = 50 // 5 registrations
+	m.do(ctx)
+	assert.Equal(t, 2, server.alerts())
+
+	bank.err = errors.New("unavailable")
+	m.do(ctx)
+	assert.True(t, errors.Is(m.Ping(ctx), health.ErrDegraded), "the last balance should be kept")
+
+	bank.err, bank.balance = nil, 1000
+	m.do(ctx)
+	require.NoError(t, m.Ping(ctx))
+
+	bank.balance = 400
+	m.do(ctx)
+	assert.Equal(t, 3, server.alerts(), "alert should be repeated after refill")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/storage/postgres"
+)
+
+var opts = struct {
+	Postgres string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
+
+	Limit int     `long:"limit" env:"LIMIT" default:"100" description:"count of listed payouts waiting for approval"`
+	IDs   []int64 `long:"id" description:"id of payout to be approved, payouts are only listed if no id is given"`
+}{}
+
+func main() {
+	parser := flags.NewParser(&opts, flags.Default)
+
+	_, err := parser.Parse()
+	if err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			os.Exit(0)
+		}
+		logrus.WithError(err).Fatal("error occurred while parsing flags")
+	}
+
+	db, err := sql.Open("postgres", opts.Postgres)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create postgres connection")
+	}
+
+	ctx := context.Background()
+
+	if err := db.PingContext(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to ping postgres")
+	}
+
+	s := postgres.New(db)
+
+	if len(opts.IDs) == 0 {
+		pp, err := s.GetApprovalPayouts(ctx, opts.Limit)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to get payouts waiting for approval")
+		}
+
+		for _, v := range pp {
+			logrus.WithFields(logrus.Fields{
+				"id":         v.ID,
+				"address":    v.Address,
+				"amount":     v.Amount.String(),
+				"purpose":    v.Purpose,
+				"reason":     v.Error.String,
+				"created_at": v.CreatedAt,
+			}).Info("payout is waiting for approval")
+		}
+		return
+	}
+
+	for _, id := range opts.IDs {
+		if err := s.ApprovePayout(ctx, id); err != nil {
+			logrus.WithError(err).WithField("id", id).Fatal("failed to approve payout")
+		}
+		logrus.WithField("id", id).Info("payout is approved")
+	}
+}
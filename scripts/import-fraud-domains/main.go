@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/fraud"
+	"github.com/Decentr-net/vulcan/internal/storage/postgres"
+)
+
+var opts = struct {
+	Postgres string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
+
+	File       string `long:"file" env:"FILE" required:"true" description:"path to disposable domains list"`
+	Source     string `long:"source" env:"SOURCE" description:"source the rules are marked with, file name is used if it's empty"`
+	Subdomains bool   `long:"subdomains" env:"SUBDOMAINS" description:"reject subdomains of listed domains too"`
+}{}
+
+func main() {
+	parser := flags.NewParser(&opts, flags.Default)
+
+	_, err := parser.Parse()
+	if err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			os.Exit(0)
+		}
+		logrus.WithError(err).Fatal("error occurred while parsing flags")
+	}
+
+	if opts.Source == "" {
+		opts.Source = filepath.Base(opts.File)
+	}
+
+	f, err := os.Open(opts.File)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to open file")
+	}
+	defer f.Close() // nolint:errcheck
+
+	rules, err := fraud.ParseList(f, opts.Source, opts.Subdomains)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to parse file")
+	}
+
+	db, err := sql.Open("postgres", opts.Postgres)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create postgres connection")
+	}
+
+	ctx := context.Background()
+
+	if err := db.PingContext(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to ping postgres")
+	}
+
+	n, err := postgres.New(db).CreateFraudRules(ctx, rules)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create fraud rules")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"parsed":   len(rules),
+		"imported": n,
+	}).Info("fraud rules are imported")
+}
@@ -0,0 +1,2 @@
+ALTER TABLE request
+    ADD COLUMN failed_attempts INT NOT NULL DEFAULT 0;
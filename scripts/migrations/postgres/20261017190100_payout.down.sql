@@ -0,0 +1,2 @@
+DROP TABLE payout;
+DROP TYPE PAYOUT_STATUS;
@@ -0,0 +1,16 @@
+CREATE TYPE PAYOUT_STATUS AS ENUM ('pending', 'broadcast', 'committed', 'failed');
+
+CREATE TABLE payout
+(
+    id         BIGSERIAL PRIMARY KEY,
+    address    VARCHAR       NOT NULL,
+    amount     BIGINT        NOT NULL,
+    memo       VARCHAR       NOT NULL DEFAULT (''),
+    status     PAYOUT_STATUS NOT NULL DEFAULT ('pending'),
+    tx_hash    VARCHAR,
+    error      TEXT,
+    created_at TIMESTAMP     NOT NULL,
+    updated_at TIMESTAMP     NOT NULL
+);
+
+CREATE INDEX payout_pending_idx ON payout (id) WHERE status = 'pending';
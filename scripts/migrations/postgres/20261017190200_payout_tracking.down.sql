@@ -0,0 +1,9 @@
+DROP INDEX payout_broadcast_idx;
+
+ALTER TABLE payout
+    DROP COLUMN owner,
+    DROP COLUMN referral_receiver,
+    DROP COLUMN broadcast_at,
+    DROP COLUMN tx_height,
+    DROP COLUMN tx_code,
+    DROP COLUMN tx_raw_log;
@@ -0,0 +1,11 @@
+ALTER TABLE payout
+    ADD COLUMN owner             VARCHAR REFERENCES request (owner) ON UPDATE CASCADE,
+    ADD COLUMN referral_receiver VARCHAR REFERENCES referral_tracking (receiver),
+    ADD COLUMN broadcast_at      TIMESTAMP,
+    ADD COLUMN tx_height         BIGINT,
+    ADD COLUMN tx_code           INT,
+    ADD COLUMN tx_raw_log        TEXT;
+
+CREATE INDEX payout_broadcast_idx ON payout (broadcast_at) WHERE status = 'broadcast';
+CREATE INDEX payout_owner_idx ON payout (owner);
+CREATE INDEX payout_referral_receiver_idx ON payout (referral_receiver);
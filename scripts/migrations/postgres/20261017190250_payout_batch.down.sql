@@ -0,0 +1,5 @@
+DROP INDEX payout_batch_idx;
+
+ALTER TABLE payout
+    DROP COLUMN batch_key,
+    DROP COLUMN batched_at;
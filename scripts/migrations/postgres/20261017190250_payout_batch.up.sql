@@ -0,0 +1,5 @@
+ALTER TABLE payout
+    ADD COLUMN batch_key  VARCHAR,
+    ADD COLUMN batched_at TIMESTAMP;
+
+CREATE INDEX payout_batch_idx ON payout (batch_key) WHERE status = 'pending';
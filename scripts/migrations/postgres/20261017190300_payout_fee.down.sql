@@ -0,0 +1,3 @@
+ALTER TABLE payout
+    DROP COLUMN tx_fee,
+    DROP COLUMN tx_gas;
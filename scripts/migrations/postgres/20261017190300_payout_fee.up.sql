@@ -0,0 +1,3 @@
+ALTER TABLE payout
+    ADD COLUMN tx_fee VARCHAR,
+    ADD COLUMN tx_gas BIGINT;
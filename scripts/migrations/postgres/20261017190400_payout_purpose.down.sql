@@ -0,0 +1,13 @@
+DROP INDEX payout_spending_idx;
+
+ALTER TABLE payout
+    DROP COLUMN purpose,
+    DROP COLUMN approved_at;
+
+DROP TYPE PAYOUT_PURPOSE;
+
+-- postgres can't drop a value of enum, so 'approval' stays in PAYOUT_STATUS
+UPDATE payout
+SET status = 'failed',
+    error  = 'spending cap is exceeded'
+WHERE status = 'approval';
@@ -0,0 +1,20 @@
+CREATE TYPE PAYOUT_PURPOSE AS ENUM ('registration', 'referral_sender', 'referral_receiver', 'testnet');
+
+-- payouts exceeding spending caps wait for manual approval
+ALTER TYPE PAYOUT_STATUS ADD VALUE 'approval';
+
+ALTER TABLE payout
+    ADD COLUMN purpose     PAYOUT_PURPOSE NOT NULL DEFAULT ('registration'),
+    ADD COLUMN approved_at TIMESTAMP;
+
+UPDATE payout
+SET purpose = CASE
+                  WHEN referral_receiver IS NULL THEN 'registration'
+                  WHEN address = referral_receiver THEN 'referral_receiver'
+                  ELSE 'referral_sender'
+    END::PAYOUT_PURPOSE;
+
+ALTER TABLE payout
+    ALTER COLUMN purpose DROP DEFAULT;
+
+CREATE INDEX payout_spending_idx ON payout (purpose, broadcast_at);
@@ -0,0 +1,9 @@
+CREATE TABLE rate_limit (
+    key VARCHAR NOT NULL,
+    window_index BIGINT NOT NULL,
+    hits INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (key, window_index)
+);
+
+CREATE INDEX rate_limit_expires_at_idx ON rate_limit (expires_at);
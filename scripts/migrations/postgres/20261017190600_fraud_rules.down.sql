@@ -0,0 +1,11 @@
+CREATE TABLE email_fraud_domains (
+    domain TEXT NOT NULL PRIMARY KEY
+);
+
+INSERT INTO email_fraud_domains
+SELECT pattern FROM fraud_rule WHERE kind IN ('exact', 'wildcard')
+ON CONFLICT DO NOTHING;
+
+DROP TABLE fraud_rejection;
+DROP TABLE fraud_rule;
+DROP TYPE FRAUD_RULE_KIND;
@@ -0,0 +1,32 @@
+CREATE TYPE FRAUD_RULE_KIND AS ENUM ('exact', 'wildcard', 'regex', 'mx');
+
+CREATE TABLE fraud_rule
+(
+    id         BIGSERIAL PRIMARY KEY,
+    kind       FRAUD_RULE_KIND NOT NULL,
+    pattern    TEXT            NOT NULL,
+    source     TEXT            NOT NULL,
+    created_at TIMESTAMP       NOT NULL,
+    UNIQUE (kind, pattern)
+);
+
+-- old domains were matched as suffixes, so they are kept for the domain itself and its subdomains
+INSERT INTO fraud_rule (kind, pattern, source, created_at)
+SELECT k.kind, LOWER(domain), 'migration', CURRENT_TIMESTAMP
+FROM email_fraud_domains,
+     (VALUES ('exact'::FRAUD_RULE_KIND), ('wildcard'::FRAUD_RULE_KIND)) AS k(kind)
+ON CONFLICT DO NOTHING;
+
+DROP TABLE email_fraud_domains;
+
+CREATE TABLE fraud_rejection
+(
+    id         BIGSERIAL PRIMARY KEY,
+    rule_id    BIGINT REFERENCES fraud_rule (id) ON DELETE SET NULL,
+    reason     TEXT      NOT NULL,
+    domain     TEXT      NOT NULL,
+    address    VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX fraud_rejection_rule_id_idx ON fraud_rejection (rule_id);
@@ -0,0 +1,4 @@
+DROP TABLE admin_audit;
+
+ALTER TABLE request
+    DROP COLUMN referral_ban_reason;
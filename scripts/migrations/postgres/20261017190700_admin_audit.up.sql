@@ -0,0 +1,14 @@
+ALTER TABLE request
+    ADD COLUMN referral_ban_reason TEXT;
+
+CREATE TABLE admin_audit
+(
+    id         BIGSERIAL PRIMARY KEY,
+    actor      TEXT      NOT NULL,
+    action     TEXT      NOT NULL,
+    target     TEXT      NOT NULL,
+    reason     TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX admin_audit_target_idx ON admin_audit (target);
@@ -0,0 +1 @@
+ALTER TABLE request ADD CONSTRAINT request_email_key UNIQUE (email);
@@ -0,0 +1,2 @@
+-- emails are encrypted with random data keys, so equal emails don't have equal values; requests are unique by owner
+ALTER TABLE request DROP CONSTRAINT request_email_key;
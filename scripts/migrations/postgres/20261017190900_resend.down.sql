@@ -0,0 +1,3 @@
+ALTER TABLE request
+    DROP COLUMN code_sent_at,
+    DROP COLUMN resend_count;
@@ -0,0 +1,5 @@
+ALTER TABLE request
+    ADD COLUMN code_sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN resend_count INT       NOT NULL DEFAULT 0;
+
+UPDATE request SET code_sent_at = created_at;
@@ -0,0 +1,6 @@
+CREATE TABLE challenge (
+    address    VARCHAR   NOT NULL,
+    nonce      VARCHAR   NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (address, nonce)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE email_outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    owner           VARCHAR   NOT NULL REFERENCES request (owner) ON UPDATE CASCADE,
+    kind            VARCHAR   NOT NULL,
+    recipient       VARCHAR   NOT NULL,
+    payload         VARCHAR   NOT NULL DEFAULT '',
+    status          VARCHAR   NOT NULL DEFAULT 'queued',
+    attempts        INT       NOT NULL DEFAULT 0,
+    reason          VARCHAR,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX email_outbox_queued_idx ON email_outbox (next_attempt_at) WHERE status = 'queued';
+CREATE INDEX email_outbox_owner_idx ON email_outbox (owner);
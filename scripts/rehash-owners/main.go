@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/normalizer"
+	"github.com/Decentr-net/vulcan/internal/owner"
+	"github.com/Decentr-net/vulcan/internal/storage"
+	"github.com/Decentr-net/vulcan/internal/storage/postgres"
+)
+
+const batchSize = 1000
+
+var opts = struct {
+	Postgres string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
+
+	EmailNormalizationConfig string   `long:"email.normalization_config" env:"EMAIL_NORMALIZATION_CONFIG" description:"path to json file with email normalization rules, built-in rules are used if it's empty"`
+	EmailEncryptionKeys      []string `long:"email.encryption_keys" env:"EMAIL_ENCRYPTION_KEYS" env-delim:"," description:"email encryption keys in id:base64(32 bytes secret) format"`
+	EmailEncryptionKeysFile  string   `long:"email.encryption_keys_file" env:"EMAIL_ENCRYPTION_KEYS_FILE" description:"path to file with email encryption keys, one key per line"`
+	OwnerKeys                []string `long:"owner.keys" env:"OWNER_KEYS" env-delim:"," required:"true" description:"owner HMAC keys in id:secret format, owners are rehashed with the first key"`
+	DryRun                   bool     `long:"dry-run" env:"DRY_RUN" description:"only report changes and collisions"`
+}{}
+
+func main() {
+	parser := flags.NewParser(&opts, flags.Default)
+
+	_, err := parser.Parse()
+	if err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			os.Exit(0)
+		}
+		logrus.WithError(err).Fatal("error occurred while parsing flags")
+	}
+
+	hasher := mustGetHasher()
+	keyring := mustGetKeyring()
+
+	db, err := sql.Open("postgres", opts.Postgres)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create postgres connection")
+	}
+
+	ctx := context.Background()
+
+	if err := db.PingContext(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to ping postgres")
+	}
+
+	s := postgres.New(db)
+
+	var (
+		total  int
+		groups = make(map[string][]*storage.Request)
+		after  string
+	)
+	for {
+		rr, err := s.ListRequests(ctx, after, batchSize)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to list requests")
+		}
+		if len(rr) == 0 {
+			break
+		}
+
+		for _, v := range rr {
+			email, err := keyring.Decrypt(v.Email)
+			if err != nil {
+				logrus.WithError(err).WithField("owner", v.Owner).Fatal("failed to decrypt email")
+			}
+
+			h := hasher.Hash(email)
+			groups[h] = append(groups[h], v)
+		}
+		total += len(rr)
+		after = rr[len(rr)-1].Owner
+	}
+
+	var (
+		changes    = make(map[string]string)
+		kept       = make(map[string]bool)
+		collisions int
+	)
+	for h, rr := range groups {
+		if len(rr) > 1 {
+			collisions++
+			for _, v := range rr {
+				kept[v.Owner] = true
+				logrus.WithFields(logrus.Fields{
+					"owner":     h,
+					"old_owner": v.Owner,
+					"address":   v.Address,
+					"confirmed": v.ConfirmedAt.Valid,
+				}).Warn("owner collision, request is kept unchanged")
+			}
+			continue
+		}
+
+		if rr[0].Owner != h {
+			changes[rr[0].Owner] = h
+		}
+	}
+
+	// a request can't take the owner of a request kept unchanged, so it's kept unchanged too
+	for conflicts := true; conflicts; {
+		conflicts = false
+		for old, h := range changes {
+			if kept[h] {
+				collisions++
+				conflicts = true
+				kept[old] = true
+				delete(changes, old)
+				logrus.WithFields(logrus.Fields{
+					"owner":     h,
+					"old_owner": old,
+				}).Warn("owner is taken by unchanged request, request is kept unchanged")
+			}
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"total":      total,
+		"changed":    len(changes),
+		"collisions": collisions,
+	}).Info("owners are rehashed")
+
+	if opts.DryRun {
+		return
+	}
+
+	if err := s.InTx(ctx, func(s storage.Storage) error {
+		return s.UpdateRequestOwners(ctx, changes)
+	}); err != nil {
+		logrus.WithError(err).Fatal("failed to update owners")
+	}
+
+	logrus.Info("owners are updated")
+}
+
+func mustGetHasher() *owner.Hasher {
+	keys, err := owner.ParseKeys(opts.OwnerKeys)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to parse owner keys")
+	}
+
+	h, err := owner.NewHasher(mustGetNormalizer(), keys, false)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create owner hasher")
+	}
+
+	return h
+}
+
+func mustGetKeyring() *envelope.Keyring {
+	k, err := envelope.OpenKeyring(opts.EmailEncryptionKeys, opts.EmailEncryptionKeysFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create email keyring")
+	}
+
+	return k
+}
+
+func mustGetNormalizer() *normalizer.Normalizer {
+	n, err := normalizer.Open(opts.EmailNormalizationConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create email normalizer")
+	}
+
+	return n
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Decentr-net/vulcan/internal/envelope"
+	"github.com/Decentr-net/vulcan/internal/storage/postgres"
+)
+
+const batchSize = 1000
+
+var opts = struct {
+	Postgres string `long:"postgres" env:"POSTGRES" default:"host=localhost port=5432 user=postgres password=root sslmode=disable" description:"postgres dsn"`
+
+	EmailEncryptionKeys     []string `long:"email.encryption_keys" env:"EMAIL_ENCRYPTION_KEYS" env-delim:"," description:"email encryption keys in id:base64(32 bytes secret) format, emails are rewrapped with the first key"`
+	EmailEncryptionKeysFile string   `long:"email.encryption_keys_file" env:"EMAIL_ENCRYPTION_KEYS_FILE" description:"path to file with email encryption keys, one key per line"`
+	DryRun                  bool     `long:"dry-run" env:"DRY_RUN" description:"only report count of emails to be rewrapped"`
+}{}
+
+func main() {
+	parser := flags.NewParser(&opts, flags.Default)
+
+	_, err := parser.Parse()
+	if err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stdout)
+			os.Exit(0)
+		}
+		logrus.WithError(err).Fatal("error occurred while parsing flags")
+	}
+
+	keyring := mustGetKeyring()
+
+	db, err := sql.Open("postgres", opts.Postgres)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create postgres connection")
+	}
+
+	ctx := context.Background()
+
+	if err := db.PingContext(ctx); err != nil {
+		logrus.WithError(err).Fatal("failed to ping postgres")
+	}
+
+	s := postgres.New(db)
+
+	var (
+		total     int
+		rewrapped int
+		after     string
+	)
+	for {
+		rr, err := s.ListRequests(ctx, after, batchSize)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to list requests")
+		}
+		if len(rr) == 0 {
+			break
+		}
+
+		emails := make(map[string]string)
+		for _, v := range rr {
+			email, changed, err := keyring.Rewrap(v.Email)
+			if err != nil {
+				logrus.WithError(err).WithField("owner", v.Owner).Fatal("failed to rewrap email")
+			}
+			if changed {
+				emails[v.Owner] = email
+			}
+		}
+
+		if !opts.DryRun {
+			if err := s.UpdateRequestEmails(ctx, emails); err != nil {
+				logrus.WithError(err).Fatal("failed to update emails")
+			}
+		}
+
+		total += len(rr)
+		rewrapped += len(emails)
+		after = rr[len(rr)-1].Owner
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"total":     total,
+		"rewrapped": rewrapped,
+		"dry_run":   opts.DryRun,
+	}).Info("emails are rewrapped")
+}
+
+func mustGetKeyring() *envelope.Keyring {
+	k, err := envelope.OpenKeyring(opts.EmailEncryptionKeys, opts.EmailEncryptionKeysFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create email keyring")
+	}
+
+	return k
+}